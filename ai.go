@@ -191,10 +191,7 @@ func processAIConversation(client *whatsmeow.Client, v *events.Message, query st
 	// 🚀 STEP 1: TRY CUSTOM API (Railway - Free Limit Saving)
 	// =================================================================
 	
-	customURL := os.Getenv("CUSTOM_API_URL")
-	if customURL == "" {
-		customURL = "https://gemini-api-production-b665.up.railway.app/chat"
-	}
+	customURL := Config().APIs.CustomAI
 
 	// Sirf tab try karo agar prompt length manageable ho (URL limit safe)
 	if len(fullPrompt) < 4000 {
@@ -205,6 +202,7 @@ func processAIConversation(client *whatsmeow.Client, v *events.Message, query st
 			finalResponse = customResp
 			log.Println("✅ Custom API Success!")
 		} else {
+			mAIErrors.Inc("custom")
 			log.Printf("⚠️ Custom API Failed (%v). Switching to Gemini Backup...", err)
 			usedSource = "Gemini"
 		}
@@ -243,6 +241,7 @@ func processAIConversation(client *whatsmeow.Client, v *events.Message, query st
 
 			if err != nil {
 				lastError = err
+				mAIErrors.Inc("gemini")
 				log.Printf("❌ Key #%d Failed: %v", currentKeyID, err)
 				keyMutex.Lock()
 				currentKeyID++
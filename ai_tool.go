@@ -30,9 +30,9 @@ func sendToolCard(client *whatsmeow.Client, v *events.Message, title, tool, info
 ║ 🛠️ Tool: %s
 ║ 🚦 Status: Active
 ╠══════════════════════╣
-║ ⚡ Power: 32GB RAM (Live)
+║ ⚡ Power: %s RAM (Live)
 ╚══════════════════════╝
-%s`, strings.ToUpper(title), tool, info)
+%s`, strings.ToUpper(title), tool, hostRAMLabel(), info)
 	replyMessage(client, v, card)
 }
 
@@ -75,28 +75,67 @@ func handleImagine(client *whatsmeow.Client, v *events.Message, prompt string) {
 }
 
 // 2. 🖥️ LIVE SERVER STATS (.stats) - No Fake Data
+// سب نمبر /metrics والے کاؤنٹرز اور /proc/meminfo سے (اس انسٹینس کے)
 func handleServerStats(client *whatsmeow.Client, v *events.Message) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
-	
+
 	used := m.Alloc / 1024 / 1024
 	sys := m.Sys / 1024 / 1024
-	numCPU := runtime.NumCPU()
-	goRoutines := runtime.NumGoroutine()
+	hostTotal, hostFree := systemMemoryMB()
+
+	clientsMutex.RLock()
+	totalBots := len(activeClients)
+	clientsMutex.RUnlock()
+
+	cmdCount, cmdAvg := mCommandLatency.Summary()
+	jobCount, _ := mJobDuration.Summary()
 
 	stats := fmt.Sprintf(`╔══════════════════════╗
 ║     🖥️ SYSTEM DASHBOARD    
 ╠══════════════════════╣
 ║ 🚀 RAM Used: %d MB
-║ 💎 Total RAM: 32 GB
-║ 🧬 System Memory: %d MB
+║ 💎 Host RAM: %s
+║ 🧬 Go Memory: %d MB
 ║ 🧠 CPU Cores: %d
-║ 🧵 Active Threads: %d
-║ 🟢 Status: Invincible
-╚══════════════════════╝`, used, sys, numCPU, goRoutines)
+║ 🧵 Goroutines: %d
+║ ⏱️ Uptime: %s
+╠══════════════════════╣
+║ 🤖 Bots Online: %d/%d
+║ 📩 Messages In: %.0f
+║ ⚡ Commands Run: %d (avg %s)
+║ 🏭 Jobs Done: %d (failed %.0f)
+║ 🧠 AI API Errors: %.0f
+╚══════════════════════╝`,
+		used, hostRAMText(hostTotal, hostFree), sys, runtime.NumCPU(), runtime.NumGoroutine(),
+		time.Since(startTime).Round(time.Second),
+		connectedBotCount(), totalBots,
+		mMessagesReceived.Total(),
+		cmdCount, (time.Duration(cmdAvg * float64(time.Second))).Round(time.Millisecond),
+		jobCount, mJobFailures.Total(),
+		mAIErrors.Total())
 	replyMessage(client, v, stats)
 }
 
+// hostRAMText: "2048 MB (1200 MB free)" — /proc/meminfo نہ ملے تو "N/A"
+func hostRAMText(total, free uint64) string {
+	if total == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%d MB (%d MB free)", total, free)
+}
+
+func hostRAMLabel() string {
+	total, _ := systemMemoryMB()
+	if total == 0 {
+		return "N/A"
+	}
+	if total >= 1024 {
+		return fmt.Sprintf("%.1fGB", float64(total)/1024)
+	}
+	return fmt.Sprintf("%dMB", total)
+}
+
 // 3. 🚀 REAL SPEED TEST (.speed) - Real Execution
 
 func handleSpeedTest(client *whatsmeow.Client, v *events.Message) {
@@ -161,7 +200,7 @@ func uploadToTempHost(data []byte, filename string) (string, error) {
 	writer.WriteField("reqtype", "fileupload")
 	writer.Close()
 
-	req, _ := http.NewRequest("POST", "https://catbox.moe/user/api.php", body)
+	req, _ := http.NewRequest("POST", Config().APIs.Catbox, body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	// ✅ اصلی براؤزر بن کر ریکویسٹ بھیجیں تاکہ بلاک نہ ہو
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)")
@@ -706,11 +745,16 @@ func handleMega(client *whatsmeow.Client, v *events.Message, urlStr string) {
 		os.Mkdir(tempDir, 0755)
 		defer os.RemoveAll(tempDir)
 
-		cmd := exec.Command("megadl", "--no-progress", "--path="+tempDir, urlStr)
-		output, err := cmd.CombinedOutput()
-		
+		var output []byte
+		err := runMediaJob(client, v, JobDownload, urlStr, func(ctx context.Context) error {
+			cmd := exec.CommandContext(ctx, "megadl", "--no-progress", "--path="+tempDir, urlStr)
+			var runErr error
+			output, runErr = cmd.CombinedOutput()
+			return runErr
+		})
+
 		if err != nil {
-			replyMessage(client, v, "❌ *Mega Error:* Invalid link or file too large.\nDetails: " + string(output))
+			replyMessage(client, v, jobErrorText(err, "❌ *Mega Error:* Invalid link or file too large.\nDetails: "+string(output)))
 			return
 		}
 
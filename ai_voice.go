@@ -26,7 +26,6 @@ import (
 
 // ⚙️ SETTINGS
 const PY_SERVER = "http://localhost:5000"
-const REMOTE_VOICE_URL = "https://voice-real-production.up.railway.app/speak"
 
 func KeepServerAlive() {
 	ticker := time.NewTicker(2 * time.Minute)
@@ -91,7 +90,7 @@ func HandleVoiceMessage(client *whatsmeow.Client, v *events.Message) {
 	if err != nil {
 		return
 	}
-	fmt.Println("🗣️ User Said:", userText)
+	msgLog(client, v).Info("🗣️ user said", "text", redactText(userText))
 
 	if replyContext != "" {
 		userText = fmt.Sprintf("(Reply to: '%s') %s", replyContext, userText)
@@ -101,7 +100,7 @@ func HandleVoiceMessage(client *whatsmeow.Client, v *events.Message) {
 	if aiResponse == "" {
 		return
 	}
-	fmt.Println("🤖 AI Response:", aiResponse)
+	msgLog(client, v).Info("🤖 AI response", "text", redactText(aiResponse))
 
 	rawAudio, err := GenerateVoice(aiResponse, senderID)
 	if err != nil || len(rawAudio) == 0 {
@@ -145,7 +144,7 @@ func GenerateVoice(text string, senderID string) ([]byte, error) {
 	if err != nil || voiceFile == "" {
 		voiceFile = "voice_1.wav"
 	}
-	return requestVoiceServer(REMOTE_VOICE_URL, text, voiceFile)
+	return requestVoiceServer(Config().APIs.RemoteVoice, text, voiceFile)
 }
 
 func requestVoiceServer(url string, text string, speakerFile string) ([]byte, error) {
@@ -210,10 +209,7 @@ func GetGeminiVoiceResponseWithHistory(query string, senderID string) (string, s
 	// ... (Rest of the code remains same) ...
 	
 	// 1. Try Custom API
-	customURL := os.Getenv("CUSTOM_API_URL")
-	if customURL == "" {
-		customURL = "https://gemini-api-production-b665.up.railway.app/chat"
-	}
+	customURL := Config().APIs.CustomAI
 
 	encodedPrompt := url.QueryEscape(systemPrompt)
 	apiReqURL := fmt.Sprintf("%s?message=%s", customURL, encodedPrompt)
@@ -233,6 +229,7 @@ func GetGeminiVoiceResponseWithHistory(query string, senderID string) (string, s
 			return apiResp.Response, ""
 		}
 	} else {
+		mAIErrors.Inc("custom")
 		fmt.Printf("⚠️ Custom API Failed (%v). Switching to Backup...\n", err)
 	}
 
@@ -244,6 +241,7 @@ func GetGeminiVoiceResponseWithHistory(query string, senderID string) (string, s
 		// Use flash model for speed
 		resp, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", genai.Text(systemPrompt), nil)
 		if err != nil {
+			mAIErrors.Inc("gemini")
 			fmt.Printf("❌ Key #%d Failed.\n", i+1)
 			continue
 		}
@@ -261,6 +259,7 @@ func TranscribeAudio(audioData []byte) (string, error) {
 	writer.Close()
 	resp, err := http.Post(PY_SERVER+"/transcribe", writer.FormDataContentType(), body)
 	if err != nil {
+		mAIErrors.Inc("transcribe")
 		return "", err
 	}
 	defer resp.Body.Close()
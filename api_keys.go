@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// =========================================================
+// 🔑 API KEYS + SCOPES (HTTP API کی حفاظت)
+// کلید صرف بنتے وقت ایک بار دکھتی ہے؛ Redis میں اس کا SHA-256 ہیش رہتا ہے۔
+// Authorization: Bearer <key>  یا  X-API-Key: <key>
+// config کا admin_token بھی مکمل (admin) رسائی دیتا ہے۔
+// =========================================================
+
+const (
+	ScopeReadHistory    = "read-history"    // chats / messages / media / statuses
+	ScopeManageSessions = "manage-sessions" // pair / delete sessions
+	ScopeManageSettings = "manage-settings" // bot / group settings API
+	ScopeSendMessages   = "send-messages"   // POST /api/bots/{id}/send
+	ScopeMetrics        = "metrics"         // /metrics (Prometheus scrape)
+	ScopeAdmin          = "admin"           // سب کچھ (+ config reload, wipe all)
+
+	apiKeysHash    = "api_keys"           // field = sha256(key), value = APIKey JSON
+	apiKeysUsedKey = "api_keys:last_used" // field = sha256(key), value = unix — کلید کا ریکارڈ دوبارہ نہیں لکھا جاتا
+	apiKeyPrefix   = "ib_"
+	apiKeyTouchEv  = time.Minute // LastUsed اتنی دیر بعد ہی اپڈیٹ ہو
+)
+
+var allScopes = []string{ScopeReadHistory, ScopeManageSessions, ScopeManageSettings, ScopeSendMessages, ScopeMetrics, ScopeAdmin}
+
+var errNoAPIKey = errors.New("api key not found")
+
+// APIKey: محفوظ شدہ کلید (اصل کلید کبھی محفوظ نہیں ہوتی)
+type APIKey struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Hash      string   `json:"hash"`
+	Scopes    []string `json:"scopes"`
+	Bots      []string `json:"bots,omitempty"` // خالی = تمام بوٹس؛ ورنہ صرف یہ نمبر
+	CreatedAt int64    `json:"created_at"`
+	LastUsed  int64    `json:"last_used,omitempty"`
+}
+
+// Allows: admin ہر اسکوپ کو کور کرتا ہے
+func (k *APIKey) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessBot: بوٹ تک محدود کلید صرف اپنے بوٹ کا ڈیٹا دیکھ سکتی ہے
+func (k *APIKey) CanAccessBot(botID string) bool {
+	if len(k.Bots) == 0 {
+		return true
+	}
+	botID = getCleanID(botID)
+	for _, b := range k.Bots {
+		if b == botID {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func parseScopes(list string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, s := range splitList(list) {
+		s = strings.ToLower(s)
+		valid := false
+		for _, a := range allScopes {
+			if s == a {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown scope %q (valid: %s)", s, strings.Join(allScopes, ", "))
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one scope is required (%s)", strings.Join(allScopes, ", "))
+	}
+	return out, nil
+}
+
+// CreateAPIKey نئی کلید بناتا ہے؛ اصل کلید صرف یہیں واپس ملتی ہے
+func CreateAPIKey(name string, scopes, bots []string) (string, *APIKey, error) {
+	if rdb == nil {
+		return "", nil, errors.New("redis not connected")
+	}
+	id := randomHex(4)
+	key := apiKeyPrefix + id + "_" + randomHex(24)
+	k := &APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      hashAPIKey(key),
+		Scopes:    scopes,
+		Bots:      bots,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := storeAPIKey(k); err != nil {
+		return "", nil, err
+	}
+	return key, k, nil
+}
+
+func storeAPIKey(k *APIKey) error {
+	raw, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return rdb.HSet(context.Background(), apiKeysHash, k.Hash, raw).Err()
+}
+
+// ListAPIKeys: تمام کلیدیں (پرانی پہلے)
+func ListAPIKeys() ([]*APIKey, error) {
+	if rdb == nil {
+		return nil, errors.New("redis not connected")
+	}
+	all, err := rdb.HGetAll(context.Background(), apiKeysHash).Result()
+	if err != nil {
+		return nil, err
+	}
+	used, _ := rdb.HGetAll(context.Background(), apiKeysUsedKey).Result()
+	keys := make([]*APIKey, 0, len(all))
+	for _, raw := range all {
+		var k APIKey
+		if json.Unmarshal([]byte(raw), &k) == nil {
+			if ts, err := strconv.ParseInt(used[k.Hash], 10, 64); err == nil {
+				k.LastUsed = ts
+			}
+			keys = append(keys, &k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+	return keys, nil
+}
+
+// RevokeAPIKey: ID سے کلید حذف
+func RevokeAPIKey(id string) error {
+	keys, err := ListAPIKeys()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.ID == id {
+			if err := rdb.HDel(context.Background(), apiKeysHash, k.Hash).Err(); err != nil {
+				return err
+			}
+			rdb.HDel(context.Background(), apiKeysUsedKey, k.Hash)
+			return nil
+		}
+	}
+	return errNoAPIKey
+}
+
+// lookupAPIKey: اصل کلید سے ریکارڈ (ہیش سے تلاش)
+func lookupAPIKey(key string) (*APIKey, error) {
+	if rdb == nil || !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, errNoAPIKey
+	}
+	raw, err := rdb.HGet(context.Background(), apiKeysHash, hashAPIKey(key)).Result()
+	if err != nil {
+		return nil, errNoAPIKey
+	}
+	var k APIKey
+	if err := json.Unmarshal([]byte(raw), &k); err != nil {
+		return nil, err
+	}
+
+	if ts, err := rdb.HGet(context.Background(), apiKeysUsedKey, k.Hash).Int64(); err == nil {
+		k.LastUsed = ts
+	}
+	if now := time.Now().Unix(); now-k.LastUsed > int64(apiKeyTouchEv/time.Second) {
+		k.LastUsed = now
+		// الگ hash میں: ریکارڈ دوبارہ لکھنے سے revoke شدہ کلید واپس آ سکتی تھی
+		go rdb.HSet(context.Background(), apiKeysUsedKey, k.Hash, now)
+	}
+	return &k, nil
+}
+
+// =========================================================
+// 🛡️ MIDDLEWARE
+// =========================================================
+
+type apiKeyCtxKey struct{}
+
+// requestCredential: ہیڈر (یا WebSocket کے لیے ?api_key=) سے کلید
+func requestCredential(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if k := r.Header.Get("X-API-Key"); k != "" {
+		return strings.TrimSpace(k)
+	}
+	// براؤزر WebSocket پر ہیڈر نہیں لگا سکتا
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+// authenticate: کلید یا admin_token چیک کر کے APIKey واپس کرتا ہے
+func authenticate(r *http.Request) (*APIKey, error) {
+	cred := requestCredential(r)
+	if cred == "" {
+		return nil, errNoAPIKey
+	}
+	if token := Config().AdminToken; token != "" && subtle.ConstantTimeCompare([]byte(cred), []byte(token)) == 1 {
+		return &APIKey{ID: "admin-token", Name: "config admin_token", Scopes: []string{ScopeAdmin}}, nil
+	}
+	return lookupAPIKey(cred)
+}
+
+// requireScope: http.HandleFunc کے گرد لپیٹیں
+func requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return requireAnyScope([]string{scope}, next)
+}
+
+// requireAnyScope: ان میں سے کوئی ایک اسکوپ کافی ہے
+func requireAnyScope(scopes []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := authenticate(r)
+		if err != nil {
+			logger.Warn("🚫 api unauthorized", "path", r.URL.Path, "remote", r.RemoteAddr)
+			writeAuthError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		allowed := false
+		for _, s := range scopes {
+			if key.Allows(s) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			logger.Warn("🚫 api forbidden", "path", r.URL.Path, "remote", r.RemoteAddr, "key", key.ID, "scope", strings.Join(scopes, "|"))
+			writeAuthError(w, http.StatusForbidden, "missing scope: "+strings.Join(scopes, " or "))
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyCtxKey{}, key)))
+	}
+}
+
+// requestAPIKey: ہینڈلر کے اندر موجودہ کلید
+func requestAPIKey(r *http.Request) *APIKey {
+	k, _ := r.Context().Value(apiKeyCtxKey{}).(*APIKey)
+	return k
+}
+
+// requireBotAccess: دوسرے بوٹ کا ڈیٹا مانگنے پر 403 لکھ کر false
+func requireBotAccess(w http.ResponseWriter, r *http.Request, botID string) bool {
+	key := requestAPIKey(r)
+	if key == nil || !key.CanAccessBot(botID) {
+		if key != nil {
+			logger.Warn("🚫 api cross-bot access", "path", r.URL.Path, "remote", r.RemoteAddr, "key", key.ID, "bot", botID)
+		}
+		writeAuthError(w, http.StatusForbidden, "no access to this bot")
+		return false
+	}
+	return true
+}
+
+func writeAuthError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="impossible-bot"`)
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": msg})
+}
+
+// warnIfNoAPIKeys: اسٹارٹ اپ پر یاد دہانی کہ API بند ہے
+func warnIfNoAPIKeys() {
+	keys, err := ListAPIKeys()
+	if err == nil && len(keys) == 0 && Config().AdminToken == "" {
+		fmt.Println("⚠️ [API KEYS] No API keys or admin_token configured — HTTP API is locked.")
+		fmt.Println("   Create one with: ./bot apikey create --name <name> --scopes admin")
+	}
+}
+
+// =========================================================
+// 💻 CLI:  ./bot apikey create|list|revoke
+// =========================================================
+
+// runAPIKeyCLI: os.Args[2:] کے ساتھ؛ exit code واپس کرتا ہے
+func runAPIKeyCLI(args []string) int {
+	usage := func() int {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  bot apikey create --name <name> --scopes <"+strings.Join(allScopes, ",")+"> [--bots <number,...>]")
+		fmt.Fprintln(os.Stderr, "  bot apikey list")
+		fmt.Fprintln(os.Stderr, "  bot apikey revoke <id>")
+		return 2
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	switch args[0] {
+	case "create":
+		var name, scopeList, botList string
+		for i := 1; i < len(args); i++ {
+			switch {
+			case args[i] == "--name" && i+1 < len(args):
+				i++
+				name = args[i]
+			case args[i] == "--scopes" && i+1 < len(args):
+				i++
+				scopeList = args[i]
+			case args[i] == "--bots" && i+1 < len(args):
+				i++
+				botList = args[i]
+			case strings.HasPrefix(args[i], "--bots="):
+				botList = strings.TrimPrefix(args[i], "--bots=")
+			case strings.HasPrefix(args[i], "--name="):
+				name = strings.TrimPrefix(args[i], "--name=")
+			case strings.HasPrefix(args[i], "--scopes="):
+				scopeList = strings.TrimPrefix(args[i], "--scopes=")
+			default:
+				return usage()
+			}
+		}
+		if name == "" {
+			return usage()
+		}
+		scopes, err := parseScopes(scopeList)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		var bots []string
+		for _, b := range splitList(botList) {
+			bots = append(bots, getCleanID(b))
+		}
+		key, k, err := CreateAPIKey(name, scopes, bots)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		fmt.Printf("✅ API key created (id: %s, scopes: %s)\n\n    %s\n\n", k.ID, strings.Join(k.Scopes, ","), key)
+		fmt.Println("⚠️ Store it now — it cannot be shown again.")
+		return 0
+
+	case "list":
+		keys, err := ListAPIKeys()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tBOTS\tCREATED\tLAST USED")
+		for _, k := range keys {
+			last := "never"
+			if k.LastUsed > 0 {
+				last = time.Unix(k.LastUsed, 0).Format("2006-01-02 15:04")
+			}
+			bots := "all"
+			if len(k.Bots) > 0 {
+				bots = strings.Join(k.Bots, ",")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, strings.Join(k.Scopes, ","), bots, time.Unix(k.CreatedAt, 0).Format("2006-01-02 15:04"), last)
+		}
+		tw.Flush()
+		return 0
+
+	case "revoke":
+		if len(args) != 2 {
+			return usage()
+		}
+		if err := RevokeAPIKey(args[1]); err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		fmt.Printf("🗑️ API key %s revoked\n", args[1])
+		return 0
+	}
+	return usage()
+}
@@ -21,12 +21,10 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-// ⚙️ SETTINGS
-const CustomAPIURL = "https://gemini-api-production-b665.up.railway.app/chat"
+// ⚙️ SETTINGS (CustomAI URL اب config میں: apis.custom_ai)
 
 const (
 	KeyAutoAITargets = "autoai:targets_set"
-	KeyChatHistory   = "chat:history:%s:%s" 
 	KeyLastMsgTime   = "autoai:last_msg_time:%s"
 	KeyLastOwnerMsg  = "autoai:last_owner_msg:%s"
 	KeyStickyOnline  = "autoai:sticky_online:%s"
@@ -82,6 +80,8 @@ func GetAllSenderIdentifiers(client *whatsmeow.Client, v *events.Message) []stri
 }
 
 // 📝 1. HISTORY RECORDER
+// میسج خود saveChatHistory سے MessageStore میں جاتا ہے (AI وہیں سے پڑھتا ہے)؛
+// یہاں صرف owner/activity ٹائمرز اور وائس نوٹ کا ٹرانسکرپٹ
 func RecordChatHistory(client *whatsmeow.Client, v *events.Message, botID string) {
 	if time.Since(v.Info.Timestamp) > 60*time.Second { return }
 	if v.Info.IsGroup || strings.Contains(v.Info.Chat.String(), "@newsletter") { return }
@@ -95,32 +95,14 @@ func RecordChatHistory(client *whatsmeow.Client, v *events.Message, botID string
 			rdb.Set(ctx, fmt.Sprintf(KeyLastActivity, chatID), time.Now().Unix(), 0)
 		}
 
-		senderName := "Me"
-		if !v.Info.IsFromMe { senderName = GetSenderName(client, v) }
-
-		text := ""
-		msgType := GetMessageType(v.Message)
-
-		if msgType == "audio" {
+		if GetMessageType(v.Message) == "audio" {
 			audioMsg := GetAudioFromMessage(v.Message)
 			data, err := client.Download(ctx, audioMsg)
 			if err == nil {
 				transcribed, _ := TranscribeAudio(data)
-				text = "[Voice]: " + transcribed
+				saveAITranscript(botID, v.Info.ID, transcribed)
 			}
-		} else if msgType == "text" {
-			text = v.Message.GetConversation()
-			if text == "" { text = v.Message.GetExtendedTextMessage().GetText() }
-		} else {
-			text = fmt.Sprintf("[%s message]", msgType)
 		}
-
-		if text == "" { return }
-
-		entry := fmt.Sprintf("%s: %s", senderName, text)
-		key := fmt.Sprintf(KeyChatHistory, botID, chatID)
-		rdb.RPush(ctx, key, entry)
-		rdb.LTrim(ctx, key, -50, -1)
 	}()
 }
 
@@ -193,7 +175,7 @@ func CheckAndHandleAutoReply(client *whatsmeow.Client, v *events.Message) bool {
 	}
 
 	if matchedTarget != "" {
-		fmt.Printf("\n🔔 [AI MATCH] Target: %s\n", matchedTarget)
+		msgLog(client, v).Info("🔔 AI target matched", "target", redactID(matchedTarget))
 		go processAIResponse(client, v, identifiers[0]) 
 		return true 
 	}
@@ -253,7 +235,8 @@ func processAIResponse(client *whatsmeow.Client, v *events.Message, senderName s
 		data, err := client.Download(ctx, audioMsg)
 		if err == nil {
 			userText, _ = TranscribeAudio(data)
-			fmt.Printf("📝 [TEXT] \"%s\"\n", userText)
+			msgLog(client, v).Info("📝 voice transcribed", "text", redactText(userText))
+			saveAITranscript(client.Store.ID.User, v.Info.ID, userText)
 		}
 	} else {
 		// 📝 TEXT: Standard Reading Delay
@@ -276,7 +259,8 @@ func processAIResponse(client *whatsmeow.Client, v *events.Message, senderName s
 	botID := strings.Split(rawBotID, ":")[0]
 	botID = strings.Split(botID, "@")[0]
 
-	aiResponse := generateCloneReply(botID, chatID, userText, senderName, msgType)
+	realChat, _ := realChatJID(v)
+	aiResponse := generateCloneReply(botID, realChat, userText, senderName, msgType)
 	if aiResponse == "" { return }
 
 	// --- F. TYPING (Keep it minimal for voice) ---
@@ -299,10 +283,10 @@ func processAIResponse(client *whatsmeow.Client, v *events.Message, senderName s
 
 	// --- G. SEND ---
 	client.SendChatPresence(ctx, v.Info.Chat, types.ChatPresencePaused, types.ChatPresenceMediaText)
-	sendCleanReply(client, v.Info.Chat, v.Info.ID, aiResponse)
-	
-	key := fmt.Sprintf(KeyChatHistory, botID, chatID)
-	rdb.RPush(ctx, key, "Me: "+aiResponse)
+	reply := cleanReplyMessage(v.Info.Chat, v.Info.ID, aiResponse)
+	if resp, err := client.SendMessage(ctx, v.Info.Chat, reply); err == nil {
+		recordSentMessage(client, realChat, resp, reply) // 💬 اگلی بار AI کو اپنا جواب بھی دکھے
+	}
 	rdb.Set(ctx, fmt.Sprintf(KeyLastActivity, chatID), time.Now().Unix(), 0)
 }
 
@@ -357,9 +341,12 @@ func waitAndCheckOwner(ctx context.Context, chatID string, seconds int) bool {
 // 🔥 CUSTOM API CALL
 func CallCustomAPI(prompt string) string {
 	safePrompt := url.QueryEscape(prompt)
-	fullURL := fmt.Sprintf("%s?message=%s", CustomAPIURL, safePrompt)
+	fullURL := fmt.Sprintf("%s?message=%s", Config().APIs.CustomAI, safePrompt)
 	resp, err := http.Get(fullURL)
-	if err != nil { return "" }
+	if err != nil {
+		mAIErrors.Inc("custom")
+		return ""
+	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil { return "" }
@@ -371,13 +358,12 @@ func CallCustomAPI(prompt string) string {
 }
 
 // 🧬 CLONE ENGINE (STRICT LANGUAGE RULES)
-func generateCloneReply(botID, chatID, currentMsg, senderName, inputType string) string {
+func generateCloneReply(botID string, chat types.JID, currentMsg, senderName, inputType string) string {
 	ctx := context.Background()
 	selectedModel, _ := rdb.Get(ctx, KeySelectedModel).Result()
 	if selectedModel == "" { selectedModel = "1" }
 
-	historyList, _ := rdb.LRange(ctx, fmt.Sprintf(KeyChatHistory, botID, chatID), 0, -1).Result()
-	history := strings.Join(historyList, "\n")
+	history := strings.Join(aiChatHistory(botID, chat, aiHistoryLimit), "\n")
 
 	voiceInstruction := ""
 	if inputType == "audio" {
@@ -425,16 +411,20 @@ ME:`, senderName, voiceInstruction, history, inputType, currentMsg)
 		if err != nil { continue }
 		resp, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", genai.Text(fullPrompt), nil)
 		if err == nil { return resp.Text() }
+		mAIErrors.Inc("gemini")
 	}
 	return ""
 }
 
-func sendCleanReply(client *whatsmeow.Client, chat types.JID, replyToID string, text string) {
-	msg := &waProto.Message{
+func cleanReplyMessage(chat types.JID, replyToID string, text string) *waProto.Message {
+	return &waProto.Message{
 		ExtendedTextMessage: &waProto.ExtendedTextMessage{
 			Text: proto.String(text),
 			ContextInfo: &waProto.ContextInfo{StanzaID: proto.String(replyToID), Participant: proto.String(chat.String())},
 		},
 	}
-	client.SendMessage(context.Background(), chat, msg)
+}
+
+func sendCleanReply(client *whatsmeow.Client, chat types.JID, replyToID string, text string) {
+	client.SendMessage(context.Background(), chat, cleanReplyMessage(chat, replyToID, text))
 }
\ No newline at end of file
@@ -9,8 +9,7 @@ import (
 	"regexp"
 	"strconv" // ✅ یہ مسنگ تھا، اب ایڈ کر دیا
 	"strings"
-	"sync"
-	// "time" // ❌ ٹائم استعمال نہیں ہو رہا تھا، ہٹا دیا
+	"time"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types/events"
@@ -28,9 +27,6 @@ type BookResult struct {
 	MirrorURL string
 }
 
-var bookCache = make(map[string][]BookResult)
-var bookMutex sync.Mutex
-
 // --- HANDLER ---
 func handleLibgen(client *whatsmeow.Client, v *events.Message, input string) {
 	if input == "" { return }
@@ -40,9 +36,10 @@ func handleLibgen(client *whatsmeow.Client, v *events.Message, input string) {
 	// 1️⃣ اگر نمبر ہے تو ڈاؤن لوڈ
 	if isNumber(input) {
 		index, _ := strconv.Atoi(input) // ✅ Strconv اب کام کرے گا
-		bookMutex.Lock()
-		books, exists := bookCache[senderJID]
-		bookMutex.Unlock()
+		var books []BookResult
+		botID, chatID, senderID := convScope(client, v)
+		conv := GetConversation(botID, chatID, senderID, FlowBook)
+		exists := conv != nil && conv.Decode(&books)
 
 		if exists && index > 0 && index <= len(books) {
 			book := books[index-1]
@@ -58,6 +55,15 @@ func handleLibgen(client *whatsmeow.Client, v *events.Message, input string) {
 	go searchLibgen(client, v, input, senderJID)
 }
 
+// 📖 نمبر والا جواب (FlowBook) — باقی میسج آگے جانے دیں
+func handleLibgenReply(client *whatsmeow.Client, v *events.Message, conv *Conversation, text string) bool {
+	if !isNumber(text) {
+		return false
+	}
+	go handleLibgen(client, v, text)
+	return true
+}
+
 // --- 🕵️ SCRAPER ---
 func searchLibgen(client *whatsmeow.Client, v *events.Message, query string, senderJID string) {
 	baseURL := "https://libgen.is/search.php"
@@ -110,9 +116,8 @@ func searchLibgen(client *whatsmeow.Client, v *events.Message, query string, sen
 
 	msgText += "👇 *Reply with a number to download.*"
 
-	bookMutex.Lock()
-	bookCache[senderJID] = results
-	bookMutex.Unlock()
+	// 💬 سیشن (10 منٹ) — نمبر والا جواب handleLibgenReply تک جائے گا
+	StartConversation(client, v, FlowBook, "", 10*time.Minute, results)
 
 	client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
 		ExtendedTextMessage: &waProto.ExtendedTextMessage{
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// =========================================================
+// ⚙️ PER-BOT SETTINGS (autoread / autoreact / autostatus ...)
+// پہلے ایک ہی BotData سب بوٹس کا تھا — ایک اونر .autoread کرے تو سب پر لگ جاتا۔
+// اب ہر بوٹ کی اپنی کی "bot_settings:<botID>"؛ پہلی بار ضرورت پر settingsStore سے لوڈ۔
+// پرانی "bot_global_settings" ویلیوز اب صرف ڈیفالٹ ہیں: جس بوٹ کی اپنی کی نہیں
+// اسے یہی ملتی ہیں اور اسی وقت اس کی کی میں محفوظ ہو جاتی ہیں (مائیگریشن)۔
+// =========================================================
+
+const (
+	botSettingsKeyPrefix    = "bot_settings:"
+	legacyGlobalSettingsKey = "bot_global_settings"
+)
+
+var (
+	botSettings   = make(map[string]*BotData) // botID → سیٹنگز (RAM کیش)
+	botSettingsMu sync.RWMutex
+)
+
+// loadGlobalSettings: پرانی مشترکہ سیٹنگز → نئے بوٹس کے ڈیفالٹ
+func loadGlobalSettings() {
+	if settingsStore == nil {
+		return
+	}
+	val, err := settingsStore.Get(ctx, legacyGlobalSettingsKey)
+	if err == nil {
+		dataMutex.Lock()
+		json.Unmarshal([]byte(val), &data)
+		dataMutex.Unlock()
+		logger.Info("✅ legacy global settings loaded as per-bot defaults")
+	}
+}
+
+func settingsBotID(client *whatsmeow.Client) string {
+	if client == nil || client.Store.ID == nil {
+		return ""
+	}
+	return getCleanID(client.Store.ID.User)
+}
+
+// loadBotSettings: لاک کے اندر بلائیں۔ اسٹور ایرر (Redis/Postgres ڈاؤن) پر ڈیفالٹس
+// ایرر کے ساتھ — کیش نہیں ہوتے تاکہ اگلی کال دوبارہ کوشش کرے
+func loadBotSettings(botID string) (*BotData, error) {
+	if s, ok := botSettings[botID]; ok {
+		return s, nil
+	}
+
+	s := &BotData{}
+	migrate := false
+	if settingsStore != nil {
+		val, err := settingsStore.Get(ctx, botSettingsKeyPrefix+botID)
+		switch {
+		case err == nil:
+			if json.Unmarshal([]byte(val), s) != nil {
+				*s = BotData{}
+			}
+		case errors.Is(err, ErrNotFound):
+			migrate = true
+		default:
+			dataMutex.RLock()
+			*s = data
+			s.StatusTargets = append([]string(nil), data.StatusTargets...)
+			dataMutex.RUnlock()
+			s.ID = botID
+			return s, err
+		}
+	}
+	if migrate || settingsStore == nil {
+		dataMutex.RLock()
+		*s = data
+		s.StatusTargets = append([]string(nil), data.StatusTargets...)
+		dataMutex.RUnlock()
+	}
+	s.ID = botID
+	botSettings[botID] = s
+
+	if migrate {
+		saveBotSettings(s)
+		botLog(botID).Info("⚙️ bot settings created from global defaults")
+	}
+	return s, nil
+}
+
+func saveBotSettings(s *BotData) {
+	if settingsStore == nil {
+		return
+	}
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	if err := settingsStore.Set(ctx, botSettingsKeyPrefix+s.ID, string(raw)); err != nil {
+		botLog(s.ID).Warn("⚠️ bot settings save failed", "err", err)
+	}
+}
+
+// GetBotSettings: ہر میسج پر پڑھا جاتا ہے — کاپی واپس
+func GetBotSettings(botID string) BotData {
+	botSettingsMu.RLock()
+	s, ok := botSettings[botID]
+	if ok {
+		out := *s
+		botSettingsMu.RUnlock()
+		return out
+	}
+	botSettingsMu.RUnlock()
+
+	botSettingsMu.Lock()
+	defer botSettingsMu.Unlock()
+	s, err := loadBotSettings(botID)
+	if err != nil {
+		botLog(botID).Debug("⚠️ bot settings unavailable, using defaults", "err", err) // ہر میسج پر — Warn نہیں
+	}
+	return *s
+}
+
+// UpdateBotSettings: تبدیلی + Redis میں محفوظ؛ نئی حالت واپس
+func UpdateBotSettings(botID string, fn func(s *BotData)) BotData {
+	botSettingsMu.Lock()
+	s, err := loadBotSettings(botID)
+	if err != nil {
+		// محفوظ سیٹنگز پڑھی نہیں جا سکیں — ڈیفالٹس لکھ کر انہیں مٹانا نہیں
+		fn(s)
+		out := *s
+		botSettingsMu.Unlock()
+		botLog(botID).Error("❌ bot settings not saved: store unavailable", "err", err)
+		return out
+	}
+	fn(s)
+	saveBotSettings(s)
+	out := *s
+	botSettingsMu.Unlock()
+
+	publishSettingsChange(botID, "")
+	return out
+}
+
+// forgetBotSettings: Redis میں باہر سے بدلی ہو (مثلاً سیشن امپورٹ) تو اگلی بار دوبارہ لوڈ
+func forgetBotSettings(botID string) {
+	botSettingsMu.Lock()
+	delete(botSettings, botID)
+	botSettingsMu.Unlock()
+}
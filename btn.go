@@ -231,12 +231,13 @@ func sendMultiButton(client *whatsmeow.Client, evt *events.Message, title, body,
 	}
 
 	// Send
-	fmt.Printf("📤 Sending to %s...\n", evt.Info.Chat.String())
+	blog := msgLog(client, evt)
+	blog.Debug("📤 sending buttons")
 	resp, err := client.SendMessage(context.Background(), evt.Info.Chat, msg)
 	if err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
+		blog.Warn("❌ buttons send failed", "err", err)
 	} else {
-		fmt.Printf("✅ Success! ID: %s\n", resp.ID)
+		blog.Debug("✅ buttons sent", "id", resp.ID)
 	}
 }
 
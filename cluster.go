@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+)
+
+// =========================================================
+// 🧩 CLUSTER (کئی انسٹینسز، ایک Postgres اسٹور)
+// ہر بوٹ کی Redis lease "bot_lease:<botID>" = انسٹینس ID (TTL کے ساتھ)۔
+// جس کے پاس lease ہو وہی بوٹ چلاتا ہے۔ انسٹینس مرے تو lease ختم → دوسرا اٹھا لیتا ہے۔
+// نیا انسٹینس آئے تو زیادہ بوجھ والے اپنا اضافی حصہ چھوڑ دیتے ہیں (rebalance)۔
+// cluster.enabled=false پر سب کچھ پہلے جیسا (ایک ہی پروسیس سب بوٹس)۔
+// =========================================================
+
+const (
+	leaseKeyPrefix   = "bot_lease:"
+	clusterMembers   = "cluster:instances" // ZSET: instance → آخری heartbeat (ms)
+	leaseTTL         = 30 * time.Second
+	clusterTick      = 10 * time.Second
+	clusterShedLimit = 1 // ایک tick میں زیادہ سے زیادہ اتنے بوٹس چھوڑیں (جھٹکا نہ لگے)
+)
+
+// صرف تب renew / release جب lease ابھی بھی ہماری ہو
+var (
+	leaseRenewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	leaseReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+var (
+	instanceIDOnce sync.Once
+	instanceIDVal  string
+
+	heldLeases   = make(map[string]time.Time) // اس انسٹینس کی leases → آخری کامیاب claim / renew
+	heldLeasesMu sync.Mutex
+)
+
+func clusterEnabled() bool {
+	return Config().Cluster.Enabled && rdb != nil
+}
+
+// InstanceID: config → RAILWAY_REPLICA_ID → hostname (+pid)
+func InstanceID() string {
+	instanceIDOnce.Do(func() {
+		id := Config().Cluster.InstanceID
+		if id == "" {
+			id = os.Getenv("RAILWAY_REPLICA_ID")
+		}
+		if id == "" {
+			host, _ := os.Hostname()
+			if host == "" {
+				host = "bot"
+			}
+			id = host + "-" + strconv.Itoa(os.Getpid())
+		}
+		instanceIDVal = id
+	})
+	return instanceIDVal
+}
+
+// claimBotLease: خالی ہو یا پہلے سے ہماری ہو تو true
+func claimBotLease(botID string) bool {
+	if !clusterEnabled() {
+		return true
+	}
+	key := leaseKeyPrefix + botID
+	ok, err := rdb.SetNX(ctx, key, InstanceID(), leaseTTL).Result()
+	if err != nil {
+		logger.Warn("🧩 lease claim failed", "bot", redactID(botID), "err", err)
+		return false
+	}
+	if !ok {
+		owner, _ := rdb.Get(ctx, key).Result()
+		if owner != InstanceID() {
+			return false
+		}
+		rdb.PExpire(ctx, key, leaseTTL)
+	}
+	heldLeasesMu.Lock()
+	heldLeases[botID] = time.Now()
+	heldLeasesMu.Unlock()
+	return true
+}
+
+// takeOverBotLease: نیا پیئر / امپورٹ یہیں ہوا — پرانا مالک اگلے renew پر بوٹ چھوڑ دے گا
+func takeOverBotLease(botID string) {
+	if !clusterEnabled() {
+		return
+	}
+	rdb.Set(ctx, leaseKeyPrefix+botID, InstanceID(), leaseTTL)
+	heldLeasesMu.Lock()
+	heldLeases[botID] = time.Now()
+	heldLeasesMu.Unlock()
+}
+
+func releaseBotLease(botID string) {
+	heldLeasesMu.Lock()
+	_, held := heldLeases[botID]
+	delete(heldLeases, botID)
+	heldLeasesMu.Unlock()
+	if held && clusterEnabled() {
+		leaseReleaseScript.Run(ctx, rdb, []string{leaseKeyPrefix + botID}, InstanceID())
+	}
+}
+
+func releaseAllLeases() {
+	heldLeasesMu.Lock()
+	ids := make([]string, 0, len(heldLeases))
+	for id := range heldLeases {
+		ids = append(ids, id)
+	}
+	heldLeasesMu.Unlock()
+	for _, id := range ids {
+		releaseBotLease(id)
+	}
+}
+
+// leaveCluster: شٹ ڈاؤن پر — دوسرے انسٹینس TTL کا انتظار کیے بغیر بوٹس اٹھا لیں
+func leaveCluster() {
+	releaseAllLeases()
+	if clusterEnabled() {
+		rdb.ZRem(ctx, clusterMembers, InstanceID())
+	}
+}
+
+// instanceAlive: کیا یہ انسٹینس ابھی cluster:instances میں زندہ ہے؟
+// (وہی حد جس پر clusterStep مردہ انسٹینس ہٹاتا ہے؛ Redis ایرر پر زندہ مانیں)
+func instanceAlive(id string) bool {
+	if id == InstanceID() {
+		return true
+	}
+	score, err := rdb.ZScore(ctx, clusterMembers, id).Result()
+	if err == redis.Nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return time.Since(time.UnixMilli(int64(score))) < 3*leaseTTL
+}
+
+// BotHosts: botID → میزبان انسٹینس (API کے لیے)
+func BotHosts(botIDs []string) map[string]string {
+	out := make(map[string]string, len(botIDs))
+	if !clusterEnabled() {
+		clientsMutex.RLock()
+		for _, id := range botIDs {
+			if _, ok := activeClients[id]; ok {
+				out[id] = InstanceID()
+			}
+		}
+		clientsMutex.RUnlock()
+		return out
+	}
+	if len(botIDs) == 0 {
+		return out
+	}
+	keys := make([]string, len(botIDs))
+	for i, id := range botIDs {
+		keys[i] = leaseKeyPrefix + id
+	}
+	vals, err := rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return out
+	}
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			out[botIDs[i]] = s
+		}
+	}
+	return out
+}
+
+// stopLocalBot: lease کھو گئی یا rebalance — سیشن DB میں رہتا ہے، صرف یہاں بند
+func stopLocalBot(botID, reason string) {
+	clientsMutex.Lock()
+	c, ok := activeClients[botID]
+	delete(activeClients, botID)
+	clientsMutex.Unlock()
+	stopReconnect(botID)
+	if ok && c != nil {
+		c.Disconnect()
+	}
+	dropBotCaches(botID) // دوسرا انسٹینس سیٹنگز بدلے تو یہاں پرانی نہ رہیں
+	botLog(botID).Info("🧩 bot handed off", "reason", reason, "instance", InstanceID())
+}
+
+// StartCluster: StartAllBots کی جگہ — leases کے ذریعے اپنا حصہ اٹھائیں
+func StartCluster(container *sqlstore.Container) {
+	logger.Info("🧩 cluster mode", "instance", InstanceID(), "lease_ttl", leaseTTL)
+	startSettingsSync()
+	clusterStep(container)
+	go func() {
+		ticker := time.NewTicker(clusterTick)
+		defer ticker.Stop()
+		for range ticker.C {
+			clusterStep(container)
+		}
+	}()
+}
+
+func clusterStep(container *sqlstore.Container) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("🧩 cluster step panic", "err", fmt.Sprint(r))
+		}
+	}()
+	now := time.Now()
+
+	// 1) heartbeat + مردہ انسٹینس ہٹائیں
+	rdb.ZAdd(ctx, clusterMembers, redis.Z{Score: float64(now.UnixMilli()), Member: InstanceID()})
+	rdb.ZRemRangeByScore(ctx, clusterMembers, "-inf", strconv.FormatInt(now.Add(-3*leaseTTL).UnixMilli(), 10))
+	live, _ := rdb.ZCard(ctx, clusterMembers).Result()
+	if live < 1 {
+		live = 1
+	}
+
+	// 2) اپنی leases renew؛ جو چھن گئیں ان کے بوٹس بند
+	heldLeasesMu.Lock()
+	mine := make([]string, 0, len(heldLeases))
+	renewed := make(map[string]time.Time, len(heldLeases))
+	for id, at := range heldLeases {
+		mine = append(mine, id)
+		renewed[id] = at
+	}
+	heldLeasesMu.Unlock()
+	for _, id := range mine {
+		n, err := leaseRenewScript.Run(ctx, rdb, []string{leaseKeyPrefix + id}, InstanceID(), leaseTTL.Milliseconds()).Int()
+		if err != nil {
+			// Redis مسئلہ — lease کی TTL گزرنے سے پہلے (اگلا tick بہت دیر ہو گا) بوٹ بند،
+			// ورنہ دوسرا انسٹینس اٹھا لے گا اور ایک بوٹ دو جگہ چلے گا
+			if time.Since(renewed[id]) < leaseTTL-clusterTick {
+				continue
+			}
+			logger.Warn("🧩 lease renew failing past TTL", "bot", redactID(id), "err", err)
+			n = 0
+		}
+		if n == 0 {
+			heldLeasesMu.Lock()
+			delete(heldLeases, id)
+			heldLeasesMu.Unlock()
+			stopLocalBot(id, "lease lost")
+			continue
+		}
+		heldLeasesMu.Lock()
+		if _, still := heldLeases[id]; still {
+			heldLeases[id] = time.Now()
+		}
+		heldLeasesMu.Unlock()
+	}
+
+	// 3) DB میں موجود بوٹس اور منصفانہ حصہ
+	devices, err := container.GetAllDevices(context.Background())
+	if err != nil {
+		return
+	}
+	byBot := make(map[string]*store.Device)
+	for _, dev := range devices {
+		id := getCleanID(dev.ID.User)
+		if _, seen := byBot[id]; !seen {
+			byBot[id] = dev
+		}
+	}
+	// سیشن DB سے ہٹ گیا (کسی اور انسٹینس سے ڈیلیٹ) — یہاں بھی بند
+	for _, id := range mine {
+		if _, ok := byBot[id]; !ok {
+			stopLocalBot(id, "session deleted")
+			releaseBotLease(id)
+		}
+	}
+	target := (len(byBot) + int(live) - 1) / int(live)
+
+	heldLeasesMu.Lock()
+	owned := len(heldLeases)
+	heldLeasesMu.Unlock()
+
+	// 4) rebalance: حصے سے زیادہ ہیں تو کچھ چھوڑ دیں تاکہ نیا انسٹینس اٹھا سکے
+	if owned > target {
+		shed := owned - target
+		if shed > clusterShedLimit {
+			shed = clusterShedLimit
+		}
+		heldLeasesMu.Lock()
+		var drop []string
+		for id := range heldLeases {
+			if len(drop) == shed {
+				break
+			}
+			drop = append(drop, id)
+		}
+		heldLeasesMu.Unlock()
+		for _, id := range drop {
+			stopLocalBot(id, "rebalance")
+			releaseBotLease(id)
+		}
+		return
+	}
+
+	// 5) بغیر مالک کے بوٹس اٹھائیں (failover / نئے سیشن)
+	ids := make([]string, 0, len(byBot))
+	for id := range byBot {
+		ids = append(ids, id)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	for _, id := range ids {
+		if owned >= target {
+			break
+		}
+		heldLeasesMu.Lock()
+		_, held := heldLeases[id]
+		heldLeasesMu.Unlock()
+		if held || isBotOnHold(id) {
+			continue
+		}
+		if !claimBotLease(id) {
+			continue
+		}
+		owned++
+		botLog(id).Info("🧩 bot acquired", "instance", InstanceID())
+		go ConnectNewSession(byBot[id])
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// =========================================================
+// 📜 ALL BOT COMMANDS
+// نئی کمانڈ یہاں رجسٹر کریں، سوئچ یا مینو میں ہاتھ سے ڈالنے کی ضرورت نہیں
+// =========================================================
+
+func init() {
+	registerGeneralCommands()
+	registerDownloadCommands()
+	registerMusicCommands()
+	registerSocialCommands()
+	registerToolCommands()
+	registerEditingCommands()
+	registerSafetyCommands()
+	registerAdminCommands()
+	registerPrivateCommands()
+	registerOwnerCommands()
+}
+
+// 🌸 GENERAL
+func registerGeneralCommands() {
+	RegisterCommand(&Command{Name: "menu", Aliases: []string{"help", "list"}, Category: CatGeneral, React: "📂", Desc: "Show This Menu", Handler: plainCmd(sendMenu)})
+	RegisterCommand(&Command{Name: "ping", Category: CatGeneral, Cooldown: 10 * time.Second, React: "⚡", Desc: "Bot Speed", Handler: plainCmd(sendPing)})
+	RegisterCommand(&Command{Name: "id", Category: CatGeneral, React: "🆔", Desc: "Chat & User ID", Handler: plainCmd(sendID)})
+	RegisterCommand(&Command{Name: "owner", Category: CatGeneral, React: "👑", Desc: "Owner Info", Handler: plainCmd(sendOwner)})
+	RegisterCommand(&Command{Name: "cancel", Aliases: []string{"stop"}, Category: CatGeneral, Desc: "Cancel Pending Menu/Job", Handler: handleCancelCmd})
+	RegisterCommand(&Command{Name: "jobs", Aliases: []string{"queue"}, Category: CatGeneral, React: "🏭", Desc: "My Queue / Cancel", Handler: handleJobsCmd})
+	RegisterCommand(&Command{Name: "data", Category: CatGeneral, React: "📂", Hidden: true, Handler: func(c *CommandContext) {
+		replyMessage(c.Client, c.Msg, "╔════════════════╗\n║ 📂 DATA STATUS\n╠════════════════╣\n║ ✅ System Active\n╚════════════════╝")
+	}})
+}
+
+// 🍭 DOWNLOADS
+func registerDownloadCommands() {
+	RegisterCommand(&Command{Name: "dl", Aliases: []string{"direct"}, Category: CatDownload, React: "🔗", Desc: "Direct File/Link", Handler: queryCmd(handleDirect)})
+	RegisterCommand(&Command{Name: "movie", Aliases: []string{"film"}, Category: CatDownload, React: "📸", Desc: "Movie Archive", Handler: func(c *CommandContext) {
+		handleArchive(c.Client, c.Msg, c.FullArgs, "movie")
+	}})
+	RegisterCommand(&Command{Name: "book", Aliases: []string{"libgen", "pdf"}, Category: CatDownload, React: "📒", Desc: "Download Books", Handler: queryCmd(handleLibgen)})
+	RegisterCommand(&Command{Name: "mega", Category: CatDownload, React: "📥", Desc: "Mega.nz DL", Handler: queryCmd(handleMega)})
+	RegisterCommand(&Command{Name: "yt", Cooldown: 10 * time.Second, Aliases: []string{"ytmp4", "ytmp3", "ytv", "yta", "youtube"}, Category: CatDownload, React: "🎬", NeedArgs: true, Usage: "yt [YouTube Link]", Desc: "YouTube Video", Handler: func(c *CommandContext) {
+		if strings.Contains(strings.ToLower(c.FullArgs), "youtu") {
+			handleYTDownloadMenu(c.Client, c.Msg, c.FullArgs)
+		} else {
+			replyMessage(c.Client, c.Msg, "❌ Please provide a valid YouTube link.")
+		}
+	}})
+	RegisterCommand(&Command{Name: "yts", Category: CatDownload, React: "🔍", Desc: "YT Search", Handler: queryCmd(handleYTS)})
+	RegisterCommand(&Command{Name: "dm", Aliases: []string{"dailymotion"}, Category: CatDownload, React: "📺", Desc: "DailyMotion", Handler: queryCmd(handleDailyMotion)})
+	RegisterCommand(&Command{Name: "vimeo", Category: CatDownload, React: "📼", Desc: "Vimeo Pro", Handler: queryCmd(handleVimeo)})
+	RegisterCommand(&Command{Name: "rumble", Category: CatDownload, React: "🥊", Desc: "Rumble", Handler: queryCmd(handleRumble)})
+	RegisterCommand(&Command{Name: "ted", Category: CatDownload, React: "🎓", Desc: "TED Talks", Handler: queryCmd(handleTed)})
+	RegisterCommand(&Command{Name: "twitch", Category: CatDownload, React: "🎮", Desc: "Twitch Clips", Handler: queryCmd(handleTwitch)})
+	RegisterCommand(&Command{Name: "bilibili", Category: CatDownload, React: "💮", Desc: "Anime DL", Handler: queryCmd(handleBilibili)})
+	RegisterCommand(&Command{Name: "archive", Aliases: []string{"ia"}, Category: CatDownload, React: "🏛️", Desc: "Internet Archive", Handler: func(c *CommandContext) {
+		handleArchive(c.Client, c.Msg, c.FullArgs, "universal")
+	}})
+	RegisterCommand(&Command{Name: "douyin", Category: CatDownload, React: "🐉", Desc: "Douyin Video", Handler: queryCmd(handleDouyin)})
+	RegisterCommand(&Command{Name: "kwai", Category: CatDownload, React: "🎞️", Desc: "Kwai Video", Handler: queryCmd(handleKwai)})
+	RegisterCommand(&Command{Name: "bitchute", Category: CatDownload, React: "🛑", Desc: "BitChute", Handler: queryCmd(handleBitChute)})
+	RegisterCommand(&Command{Name: "steam", Category: CatDownload, React: "🎮", Desc: "Steam Media", Handler: queryCmd(handleSteam)})
+	RegisterCommand(&Command{Name: "git", Aliases: []string{"github"}, Category: CatDownload, React: "🐱", Desc: "GitHub Repo", Handler: queryCmd(handleGithub)})
+}
+
+// 🧸 MUSIC
+func registerMusicCommands() {
+	RegisterCommand(&Command{Name: "spotify", Category: CatMusic, React: "💚", Desc: "Spotify Song", Handler: queryCmd(handleSpotify)})
+	RegisterCommand(&Command{Name: "sc", Aliases: []string{"soundcloud"}, Category: CatMusic, React: "☁️", Desc: "SoundCloud", Handler: queryCmd(handleSoundCloud)})
+	RegisterCommand(&Command{Name: "apple", Aliases: []string{"applemusic"}, Category: CatMusic, React: "🍎", Desc: "Apple Music", Handler: queryCmd(handleAppleMusic)})
+	RegisterCommand(&Command{Name: "deezer", Category: CatMusic, React: "🎼", Desc: "Deezer HQ", Handler: queryCmd(handleDeezer)})
+	RegisterCommand(&Command{Name: "bandcamp", Category: CatMusic, React: "⛺", Desc: "Indie Songs", Handler: queryCmd(handleBandcamp)})
+	RegisterCommand(&Command{Name: "tidal", Category: CatMusic, React: "🌊", Desc: "Tidal Music", Handler: queryCmd(handleTidal)})
+	RegisterCommand(&Command{Name: "mixcloud", Category: CatMusic, React: "🎧", Desc: "Mixcloud Sets", Handler: queryCmd(handleMixcloud)})
+	RegisterCommand(&Command{Name: "napster", Category: CatMusic, React: "🐱", Desc: "Napster", Handler: queryCmd(handleNapster)})
+}
+
+// 🎀 SOCIAL MEDIA
+func registerSocialCommands() {
+	RegisterCommand(&Command{Name: "tt", Aliases: []string{"tiktok"}, Category: CatSocial, React: "🎵", Desc: "TikTok (No WM)", Handler: queryCmd(handleTikTok)})
+	RegisterCommand(&Command{Name: "ig", Aliases: []string{"insta", "instagram"}, Category: CatSocial, React: "📸", Desc: "Instagram Reel", Handler: queryCmd(handleInstagram)})
+	RegisterCommand(&Command{Name: "fb", Aliases: []string{"facebook"}, Category: CatSocial, React: "💙", Desc: "Facebook Video", Handler: queryCmd(handleFacebook)})
+	RegisterCommand(&Command{Name: "pin", Aliases: []string{"pinterest"}, Category: CatSocial, React: "📌", Desc: "Pinterest", Handler: queryCmd(handlePinterest)})
+	RegisterCommand(&Command{Name: "snap", Aliases: []string{"snapchat"}, Category: CatSocial, React: "👻", Desc: "Snapchat", Handler: queryCmd(handleSnapchat)})
+	RegisterCommand(&Command{Name: "tw", Aliases: []string{"x", "twitter"}, Category: CatSocial, React: "🐦", Desc: "X / Twitter", Handler: queryCmd(handleTwitter)})
+	RegisterCommand(&Command{Name: "threads", Category: CatSocial, React: "🧵", Desc: "Threads", Handler: queryCmd(handleThreads)})
+	RegisterCommand(&Command{Name: "reddit", Category: CatSocial, React: "👽", Desc: "Reddit Post", Handler: queryCmd(handleReddit)})
+	RegisterCommand(&Command{Name: "tts", Category: CatSocial, Desc: "TikTok Search", Handler: queryCmd(handleTTSearch)})
+	RegisterCommand(&Command{Name: "ttauto", Category: CatSocial, Desc: "TikTok Auto Status", Handler: argsCmd(handleTTAuto)})
+	RegisterCommand(&Command{Name: "ttautoset", Category: CatSocial, Desc: "Auto Status Tags", Handler: argsCmd(handleTTAutoSet)})
+	RegisterCommand(&Command{Name: "imgur", Category: CatSocial, React: "🖼️", Desc: "Imgur", Handler: queryCmd(handleImgur)})
+	RegisterCommand(&Command{Name: "giphy", Category: CatSocial, React: "👾", Desc: "Giphy GIF", Handler: queryCmd(handleGiphy)})
+	RegisterCommand(&Command{Name: "flickr", Category: CatSocial, React: "📷", Desc: "Flickr Photo", Handler: queryCmd(handleFlickr)})
+	RegisterCommand(&Command{Name: "9gag", Category: CatSocial, React: "🤣", Desc: "9GAG Post", Handler: queryCmd(handle9Gag)})
+	RegisterCommand(&Command{Name: "ifunny", Category: CatSocial, React: "🤡", Desc: "iFunny Post", Handler: queryCmd(handleIfunny)})
+	RegisterCommand(&Command{Name: "status", Category: CatSocial, React: "💾", Desc: "Save Status", Handler: argsCmd(HandleStatusCmd)})
+}
+
+// ✨ MAGIC TOOLS
+func registerToolCommands() {
+	RegisterCommand(&Command{Name: "ai", Aliases: []string{"ask", "gpt"}, Category: CatTools, React: "🧠", Desc: "Gemini Chat", Handler: func(c *CommandContext) {
+		handleAI(c.Client, c.Msg, c.FullArgs, c.Cmd)
+	}})
+	RegisterCommand(&Command{Name: "autoai", Category: CatTools, Role: RoleOwner, React: "🧠", Desc: "Auto AI Reply", Handler: argsCmd(HandleAutoAICmd)})
+	RegisterCommand(&Command{Name: "img", Aliases: []string{"imagine", "draw"}, Category: CatTools, Cost: 3, Cooldown: 15 * time.Second, React: "🎨", Desc: "Create Images", Handler: queryCmd(handleImagine)})
+	RegisterCommand(&Command{Name: "remini", Aliases: []string{"upscale", "hd"}, Category: CatTools, Cost: 3, Cooldown: 15 * time.Second, React: "✨", Desc: "Enhance Photo", Handler: plainCmd(handleRemini)})
+	RegisterCommand(&Command{Name: "removebg", Aliases: []string{"rbg"}, Category: CatTools, Cost: 3, Cooldown: 15 * time.Second, React: "✂️", Desc: "Remove BG", Handler: plainCmd(handleRemoveBG)})
+	RegisterCommand(&Command{Name: "tr", Aliases: []string{"translate"}, Category: CatTools, Cost: 1, React: "🌍", Desc: "Translate Text", Handler: argsCmd(handleTranslate)})
+	RegisterCommand(&Command{Name: "speed", Aliases: []string{"speedtest"}, Category: CatTools, Cost: 3, Cooldown: time.Minute, React: "🚀", Desc: "Speed Test", Handler: plainCmd(handleSpeedTest)})
+	RegisterCommand(&Command{Name: "ss", Aliases: []string{"screenshot"}, Category: CatTools, Cost: 3, React: "📸", Desc: "Screenshot", Handler: queryCmd(handleScreenshot)})
+	RegisterCommand(&Command{Name: "google", Aliases: []string{"search"}, Category: CatTools, React: "🔍", Desc: "Search Web", Handler: queryCmd(handleGoogle)})
+	RegisterCommand(&Command{Name: "weather", Category: CatTools, React: "🌦️", Desc: "Weather", Handler: queryCmd(handleWeather)})
+}
+
+// 🎨 EDITING ZONE
+func registerEditingCommands() {
+	RegisterCommand(&Command{Name: "sticker", Aliases: []string{"s"}, Category: CatEditing, React: "🎨", Desc: "Make Sticker", Handler: plainCmd(handleToSticker)})
+	RegisterCommand(&Command{Name: "toimg", Category: CatEditing, React: "🖼️", Desc: "Sticker to Img", Handler: plainCmd(handleToImg)})
+	RegisterCommand(&Command{Name: "togif", Category: CatEditing, React: "🎞️", Desc: "Sticker to Gif", Handler: func(c *CommandContext) {
+		handleToMedia(c.Client, c.Msg, true)
+	}})
+	RegisterCommand(&Command{Name: "tovideo", Category: CatEditing, React: "🎥", Desc: "Sticker to Vid", Handler: func(c *CommandContext) {
+		handleToMedia(c.Client, c.Msg, false)
+	}})
+	RegisterCommand(&Command{Name: "tourl", Category: CatEditing, React: "🔗", Desc: "Media to URL", Handler: plainCmd(handleToURL)})
+	RegisterCommand(&Command{Name: "toptt", Aliases: []string{"voice"}, Category: CatEditing, React: "🎙️", Desc: "Text to Audio", Handler: plainCmd(handleToPTT)})
+	RegisterCommand(&Command{Name: "setvoice", Category: CatEditing, Desc: "Voice Changer", Handler: argsCmd(HandleVoiceCommand)})
+	RegisterCommand(&Command{Name: "fancy", Aliases: []string{"style"}, Category: CatEditing, React: "✍️", Desc: "Fancy Fonts", Handler: queryCmd(handleFancy)})
+}
+
+// 🛡️ GROUP SAFETY
+func registerSafetyCommands() {
+	for _, sec := range []struct{ name, react, desc string }{
+		{"antilink", "🛡️", "Ban Links"},
+		{"antipic", "🖼️", "Ban Images"},
+		{"antivideo", "🎥", "Ban Videos"},
+		{"antisticker", "🚫", "Ban Stickers"},
+	} {
+		secType := sec.name
+		RegisterCommand(&Command{Name: sec.name, Category: CatSafety, Role: RoleAdmin, GroupOnly: true, React: sec.react, Desc: sec.desc, Handler: func(c *CommandContext) {
+			startSecuritySetup(c.Client, c.Msg, c.Args, secType)
+		}})
+	}
+	RegisterCommand(&Command{Name: "mode", Category: CatSafety, Role: RoleOwner, React: "🔄", Desc: "Admin/Public", Handler: argsCmd(handleMode)})
+	RegisterCommand(&Command{Name: "perm", Aliases: []string{"perms"}, Category: CatSafety, Role: RoleAdmin, GroupOnly: true, React: "🔐", Desc: "Command Permissions", Handler: handlePermCmd})
+	RegisterCommand(&Command{Name: "welcome", Aliases: []string{"wel"}, Category: CatSafety, Role: RoleAdmin, GroupOnly: true, React: "👋", Usage: "welcome on | off", Desc: "Auto Welcome", Handler: handleWelcomeCmd})
+}
+
+// 🏰 ADMIN POWER
+func registerAdminCommands() {
+	RegisterCommand(&Command{Name: "kick", Category: CatAdmin, Role: RoleAdmin, GroupOnly: true, React: "👢", Desc: "Kick User", Handler: argsCmd(handleKick)})
+	RegisterCommand(&Command{Name: "add", Category: CatAdmin, Role: RoleAdmin, GroupOnly: true, React: "➕", Desc: "Add User", Handler: argsCmd(handleAdd)})
+	RegisterCommand(&Command{Name: "promote", Category: CatAdmin, Role: RoleAdmin, GroupOnly: true, React: "⬆️", Desc: "Make Admin", Handler: argsCmd(handlePromote)})
+	RegisterCommand(&Command{Name: "demote", Category: CatAdmin, Role: RoleAdmin, GroupOnly: true, React: "⬇️", Desc: "Remove Admin", Handler: argsCmd(handleDemote)})
+	RegisterCommand(&Command{Name: "tagall", Category: CatAdmin, Cooldown: 30 * time.Second, Role: RoleAdmin, GroupOnly: true, React: "📣", Desc: "Tag Everyone", Handler: argsCmd(handleTagAll)})
+	RegisterCommand(&Command{Name: "hidetag", Category: CatAdmin, Cooldown: 30 * time.Second, Role: RoleAdmin, GroupOnly: true, React: "🔔", Desc: "Ghost Tag", Handler: argsCmd(handleHideTag)})
+	RegisterCommand(&Command{Name: "group", Category: CatAdmin, Role: RoleAdmin, GroupOnly: true, React: "👥", Desc: "Open/Close", Handler: argsCmd(handleGroup)})
+	RegisterCommand(&Command{Name: "del", Aliases: []string{"delete"}, Category: CatAdmin, Role: RoleAdmin, GroupOnly: true, React: "🗑️", Desc: "Delete Msg", Handler: plainCmd(handleDelete)})
+	RegisterCommand(&Command{Name: "vv", Category: CatAdmin, React: "🫣", Desc: "Anti ViewOnce", Handler: plainCmd(handleVV)})
+	RegisterCommand(&Command{Name: "antidelete", Category: CatAdmin, Role: RoleOwner, React: "🛡️", Desc: "Anti Delete", Handler: argsCmd(HandleAntiDeleteCommand)})
+}
+
+// 🔒 PRIVATE TOOLS
+func registerPrivateCommands() {
+	RegisterCommand(&Command{Name: "code", Aliases: []string{"otp"}, Category: CatPrivate, React: "📩", Desc: "Get OTP Code", Handler: argsCmd(HandleGetOTP)})
+	RegisterCommand(&Command{Name: "num", Aliases: []string{"getnum"}, Category: CatPrivate, React: "🔢", Desc: "Get Number", Handler: argsCmd(HandleGetNumber)})
+	RegisterCommand(&Command{Name: "nset", Category: CatPrivate, React: "⚙️", Desc: "Number Settings", Handler: argsCmd(HandleNSet)})
+	RegisterCommand(&Command{Name: "tcs", Category: CatPrivate, React: "🚚", Desc: "Track Parcel", Handler: func(c *CommandContext) {
+		// یہاں ہم پورا میسج بھیج رہے ہیں کیونکہ TCS خود پارس کرتا ہے
+		go HandleTCSCommand(c.Client, c.Msg, c.Body)
+	}})
+	RegisterCommand(&Command{Name: "sd", Category: CatPrivate, Role: RoleOwner, React: "💀", Desc: "Session Delete", Handler: argsCmd(handleSessionDelete)})
+}
+
+// 👑 MY KINGDOM (Owner)
+func registerOwnerCommands() {
+	RegisterCommand(&Command{Name: "setprefix", Category: CatOwner, Role: RoleOwner, React: "🔧", NeedArgs: true, Usage: "setprefix !", Desc: "Change Prefix", Handler: func(c *CommandContext) {
+		updatePrefixDB(c.BotID, c.FullArgs)
+		replyMessage(c.Client, c.Msg, fmt.Sprintf("✅ Prefix updated to [%s]", c.FullArgs))
+	}})
+	RegisterCommand(&Command{Name: "alwaysonline", Category: CatOwner, Role: RoleOwner, React: "🟢", Desc: "Always On", Handler: plainCmd(toggleAlwaysOnline)})
+	RegisterCommand(&Command{Name: "autoread", Category: CatOwner, Role: RoleOwner, React: "👁️", Desc: "Auto Seen", Handler: plainCmd(toggleAutoRead)})
+	RegisterCommand(&Command{Name: "autoreact", Category: CatOwner, Role: RoleOwner, React: "❤️", Desc: "Auto Like", Handler: plainCmd(toggleAutoReact)})
+	RegisterCommand(&Command{Name: "autostatus", Category: CatOwner, Role: RoleOwner, React: "📺", Desc: "Status View", Handler: plainCmd(toggleAutoStatus)})
+	RegisterCommand(&Command{Name: "statusreact", Category: CatOwner, Role: RoleOwner, React: "🔥", Desc: "Status Like", Handler: plainCmd(toggleStatusReact)})
+	RegisterCommand(&Command{Name: "addstatus", Category: CatOwner, Role: RoleOwner, React: "📝", Desc: "Add Status Target", Handler: argsCmd(handleAddStatus)})
+	RegisterCommand(&Command{Name: "delstatus", Category: CatOwner, Role: RoleOwner, React: "🗑️", Desc: "Remove Target", Handler: argsCmd(handleDelStatus)})
+	RegisterCommand(&Command{Name: "liststatus", Category: CatOwner, Role: RoleOwner, React: "📜", Desc: "Status Targets", Handler: plainCmd(handleListStatus)})
+	RegisterCommand(&Command{Name: "readallstatus", Category: CatOwner, Role: RoleOwner, React: "✅", Desc: "Read All Status", Handler: plainCmd(handleReadAllStatus)})
+	RegisterCommand(&Command{Name: "antidm", Category: CatOwner, Role: RoleOwner, React: "🛡️", NeedArgs: true, Usage: "antidm on | off", Desc: "Block Unsaved DMs", Handler: handleAntiDMCmd})
+	RegisterCommand(&Command{Name: "antibug", Category: CatOwner, Role: RoleOwner, React: "🛡️", Desc: "Anti Bug Shield", Handler: plainCmd(handleAntiBug)})
+	RegisterCommand(&Command{Name: "weblogin", Aliases: []string{"viewer"}, Category: CatOwner, Role: RoleOwner, React: "🔐", Desc: "History Viewer Login", Handler: handleWebLoginCmd})
+	RegisterCommand(&Command{Name: "purgechat", Category: CatOwner, Role: RoleOwner, React: "🧹", NeedArgs: true, Usage: "purgechat 923xxxxxxxxx | group-id@g.us | here | status", Desc: "Delete Chat History", Handler: handlePurgeChatCmd})
+	RegisterCommand(&Command{Name: "retention", Category: CatOwner, Role: RoleOwner, React: "🧹", Desc: "History Retention", Handler: handleRetentionCmd})
+	RegisterCommand(&Command{Name: "listbots", Category: CatOwner, React: "🤖", Desc: "Active Bots", Handler: plainCmd(sendBotsList)})
+	RegisterCommand(&Command{Name: "stats", Aliases: []string{"server", "dashboard"}, Category: CatOwner, React: "📊", Desc: "System Power", Handler: plainCmd(handleServerStats)})
+	RegisterCommand(&Command{Name: "send", Category: CatOwner, Role: RoleOwner, React: "📤", Hidden: true, Handler: argsCmd(handleSendBug)})
+}
+
+// ✅ WELCOME TOGGLE
+func handleWelcomeCmd(c *CommandContext) {
+	s := getGroupSettings(c.BotID, c.ChatID)
+	if c.FullArgs == "on" || c.FullArgs == "enable" {
+		s.Welcome = true
+		replyMessage(c.Client, c.Msg, "✅ *Welcome Messages:* ON")
+	} else if c.FullArgs == "off" || c.FullArgs == "disable" {
+		s.Welcome = false
+		replyMessage(c.Client, c.Msg, "❌ *Welcome Messages:* OFF")
+	} else {
+		replyMessage(c.Client, c.Msg, "⚠️ Usage: "+c.Prefix+"welcome on | off")
+		return
+	}
+	saveGroupSettings(c.BotID, s)
+}
+
+// 🛡️ ANTI-DM TOGGLE (ہر بوٹ کی اپنی سیٹنگ)
+func handleAntiDMCmd(c *CommandContext) {
+	action := strings.ToLower(c.Args[0])
+
+	if action == "on" || action == "enable" {
+		setAntiDM(c.BotID, true)
+		replyMessage(c.Client, c.Msg, "✅ *Anti-DM ON:* Unsaved numbers will be blocked automatically for *this bot only*.")
+	} else if action == "off" || action == "disable" {
+		setAntiDM(c.BotID, false)
+		replyMessage(c.Client, c.Msg, "❌ *Anti-DM OFF:* Anyone can DM this bot now.")
+	} else {
+		replyMessage(c.Client, c.Msg, "⚠️ *Usage:* "+c.Prefix+"antidm on | off")
+	}
+}
+
+// setAntiDM: کمانڈ اور REST API دونوں یہی استعمال کرتے ہیں
+func setAntiDM(botID string, on bool) {
+	antiDMMutex.Lock()
+	antiDMState[botID] = on
+	antiDMMutex.Unlock()
+
+	// 💾 سیٹنگز اسٹور میں محفوظ کریں تاکہ سرور ری سٹارٹ ہونے پر سیٹنگ ضائع نہ ہو
+	if settingsStore != nil {
+		val := "off"
+		if on {
+			val = "on"
+		}
+		if settingsStore.Set(context.Background(), "antidm:"+botID, val) == nil {
+			publishSettingsChange(botID, "")
+		}
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"os"
 	"time"
 	"sync"
-    "strconv"
-    "encoding/json"
 
     "go.mau.fi/whatsmeow"
     "go.mau.fi/whatsmeow/appstate"
@@ -22,18 +20,6 @@ import (
 
 )
 
-var RestrictedGroups = map[string]bool{
-    "120363365896020486@g.us": true,
-    "120363424633566154@g.us": true, 
-}
-
-var replyChannels = make(map[string]chan string)
-var replyMutex sync.RWMutex
-
-var AuthorizedBots = map[string]bool{
-    "923277635849": true,
-    "923275596764": true,
-}
 // =========================================================
 
 func handler(botClient *whatsmeow.Client, evt interface{}) {
@@ -66,37 +52,23 @@ func handler(botClient *whatsmeow.Client, evt interface{}) {
 		// ==========================================
 		// 🟢 LID BYPASS LOGIC (یہاں سے اصلی نمبر نکلے گا)
 		// ==========================================
-		realSender := v.Info.Sender.ToNonAD()
-		if v.Info.Sender.Server == types.HiddenUserServer && !v.Info.SenderAlt.IsEmpty() {
-			realSender = v.Info.SenderAlt.ToNonAD()
-		}
+		realChat, realSender := realChatJID(v)
 
-		realChat := v.Info.Chat.ToNonAD()
-		if !v.Info.IsGroup {
-			if v.Info.IsFromMe {
-				if v.Info.Chat.Server == types.HiddenUserServer && !v.Info.RecipientAlt.IsEmpty() {
-					realChat = v.Info.RecipientAlt.ToNonAD()
-				}
-			} else {
-				realChat = realSender
-			}
+		// 🟢 RAW INFO (صرف LOG_LEVEL=debug پر، redact کے ساتھ)
+		if debugEnabled() {
+			mlog := msgLog(botClient, v)
+			mlog.Debug("routing", "real_sender", redactID(realSender.String()), "real_chat", redactID(realChat.String()))
+			logRawMessage(mlog, v, "handler")
 		}
 
-		// 🟢 RAW PRINTING (تاکہ آپ کو پیچھے سے آنے والا را ڈیٹا نظر آئے)
-		fmt.Println("\n================ MESSAGE RAW INFO ================")
-		fmt.Printf("Original LID Sender : %s\n", v.Info.Sender.String())
-		fmt.Printf("Extracted Real JID  : %s\n", realSender.String())
-		infoJSON, _ := json.MarshalIndent(v.Info, "", "  ")
-		fmt.Printf("Routing Payload:\n%s\n", string(infoJSON))
-		fmt.Println("==================================================\n")
-
-		// ✅ Save Message to Mongo (اب یہ سیدھا اصلی نمبر لے کر جائے گا!)
+		// ✅ Save Message to History Store (اصلی نمبر + اصل پروٹوبف)
 		go func() {
-			saveMessageToMongo(
+			saveChatHistory(
 				botClient,
 				botID,
 				realChat.String(),
 				realSender,
+				v.Info.ID,
 				v.Message,
 				v.Info.IsFromMe,
 				uint64(v.Info.Timestamp.Unix()),
@@ -107,6 +79,10 @@ func handler(botClient *whatsmeow.Client, evt interface{}) {
 		if v.Info.Chat.String() == "status@broadcast" {
 			return
 		}
+		if !v.Info.IsFromMe {
+			mMessagesReceived.Inc(botID)
+			wsHub.countNewMessage(botID, canonicalChatID(realChat.String()))
+		}
 
 		// Process Commands
 		if isRecent {
@@ -167,8 +143,13 @@ func handler(botClient *whatsmeow.Client, evt interface{}) {
 						ts = *webMsg.MessageTimestamp
 					}
 
-					// ✅ Save Call for History (7 Variables)
-					saveMessageToMongo(botClient, botID, chatID, senderJID, webMsg.Message, isFromMe, ts)
+					msgID := ""
+					if webMsg.Key != nil {
+						msgID = webMsg.Key.GetID()
+					}
+
+					// ✅ Save Call for History
+					saveChatHistory(botClient, botID, chatID, senderJID, msgID, webMsg.Message, isFromMe, ts)
 				}
 			}
 		}()
@@ -176,53 +157,24 @@ func handler(botClient *whatsmeow.Client, evt interface{}) {
 	case *events.Connected:
 		if botClient.Store != nil && botClient.Store.ID != nil {
 			fmt.Printf("🟢 [ONLINE] Bot %s connected!\n", botClient.Store.ID.User)
+			emitBotEvent(botClient.Store.ID.User, EvtConnected, nil)
 		}
-	}
-}
 
+	case *events.Disconnected:
+		// ری کنیکٹ session_supervisor.go سنبھالتا ہے
+		if botClient.Store != nil && botClient.Store.ID != nil {
+			emitBotEvent(botClient.Store.ID.User, EvtDisconnected, nil)
+		}
 
-func isKnownCommand(text string) bool {
-	commands := []string{
-		"menu", "help", "list", "ping", "id", "owner", "data", "listbots",
-		"alwaysonline", "autoread", "autoreact", "autostatus", "statusreact",
-		"addstatus", "delstatus", "liststatus", "readallstatus", "setprefix", "mode",
-		"antilink", "antipic", "antivideo", "antisticker",
-		"kick", "add", "promote", "demote", "tagall", "hidetag", "group", "del", "delete",
-		"tiktok", "tt", "fb", "facebook", "insta", "ig", "pin", "pinterest", "ytmp3", "ytmp4",
-		"sticker", "s", "toimg", "tovideo", "removebg", "remini", "tourl", "weather", "translate", "tr", "vv",
-	}
-
-	lowerText := strings.ToLower(strings.TrimSpace(text))
-	for _, cmd := range commands {
-		if strings.HasPrefix(lowerText, cmd) {
-			return true
+	case *events.LoggedOut:
+		if botClient.Store != nil && botClient.Store.ID != nil {
+			emitBotEvent(botClient.Store.ID.User, EvtLoggedOut, map[string]any{"on_connect": v.OnConnect, "reason": v.Reason.String()})
 		}
 	}
-	return false
 }
 
 
 
-// ⚡ PERMISSION CHECK FUNCTION (UPDATED)
-func canExecute(client *whatsmeow.Client, v *events.Message, cmd string) bool {
-	// 1. Owner Check
-	if isOwner(client, v.Info.Sender) { return true }
-	
-	// 2. Private Chat Check (Always Allowed unless blacklisted)
-	if !v.Info.IsGroup { return true }
-
-	// 3. Group Checks (Need Bot ID)
-	rawBotID := client.Store.ID.User
-	botID := getCleanID(rawBotID)
-	
-	s := getGroupSettings(botID, v.Info.Chat.String())
-	
-	if s.Mode == "private" { return false }
-	if s.Mode == "admin" { return isAdmin(client, v.Info.Chat, v.Info.Sender) }
-	
-	return true
-}
-
 func processMessage(client *whatsmeow.Client, v *events.Message) {
 	// 🛡️ 1. Panic Recovery
 	defer func() {
@@ -279,7 +231,7 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 			totalJunk += strings.Count(bodyClean, char)
 		}
 		if totalJunk > 50 {
-			fmt.Printf("🛡️ MALICIOUS BUG DETECTED in DM! From: %s | Cleaning...\n", v.Info.Sender.User)
+			msgLog(client, v).Warn("🛡️ malicious bug detected in DM, cleaning", "junk_chars", totalJunk)
 			client.RevokeMessage(context.Background(), v.Info.Chat, v.Info.ID)
 			return
 		}
@@ -287,17 +239,15 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 
 	// 🟢 Variables Extraction
 	chatID := v.Info.Chat.String()
-	senderID := v.Info.Sender.ToNonAD().String()
 
 	// ⚡ 5. Prefix Check (Fast RAM Access)
 	prefix := getPrefix(botID)
 	isCommand := strings.HasPrefix(bodyClean, prefix)
 
-	// 🔥 GLOBAL SETTINGS PRE-FETCH (RAM ACCESS)
-	dataMutex.RLock()
-	doRead := data.AutoRead
-	doReact := data.AutoReact
-	dataMutex.RUnlock()
+	// 🔥 PER-BOT SETTINGS PRE-FETCH (RAM ACCESS)
+	settings := GetBotSettings(botID)
+	doRead := settings.AutoRead
+	doReact := settings.AutoReact
 
 
 	// =========================================================================
@@ -310,30 +260,16 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 			}
 		}()
 
-		// 🛑 REPLY INTERCEPTOR (یہ نیا کوڈ ہے جو آپ کے ڈاؤنلوڈر کو جواب پہنچائے گا)
-		replyMutex.RLock()
-		ch, waiting := replyChannels[senderID]
-		replyMutex.RUnlock()
-
-		if waiting {
-			if bodyClean != "" {
-				// جواب چینل میں بھیجیں اور فنکشن یہیں روک دیں
-				ch <- bodyClean
-				
-				replyMutex.Lock()
-				delete(replyChannels, senderID) // چینل صاف کریں
-				replyMutex.Unlock()
-				return
-			}
+		// 🛑 REPLY INTERCEPTOR (WaitForUserReply میں رکے فنکشن کو جواب پہنچائیں)
+		if deliverAwaitedReply(client, v, bodyClean, isCommand) {
+			return
 		}
 		// 🛑 INTERCEPTOR END
 
 		// 📺 A. Status Handling
 		if v.Info.Chat.String() == "status@broadcast" {
-			dataMutex.RLock()
-			shouldView := data.AutoStatus
-			shouldReact := data.StatusReact
-			dataMutex.RUnlock()
+			shouldView := settings.AutoStatus
+			shouldReact := settings.StatusReact
 
 			if shouldView {
 				client.MarkRead(context.Background(), []types.MessageID{v.Info.ID}, v.Info.Timestamp, v.Info.Chat, v.Info.Sender)
@@ -381,79 +317,9 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 			}()
 		}
 
-		// 🔍 C. SESSION CHECKS (Reply Handling - The Critical Part)
-		extMsg := v.Message.GetExtendedTextMessage()
-		
-		if extMsg != nil && extMsg.ContextInfo != nil && extMsg.ContextInfo.StanzaID != nil {
-			qID := extMsg.ContextInfo.GetStanzaID()
-
-			// a. Setup Wizard
-			if _, ok := setupMap[qID]; ok {
-				handleSetupResponse(client, v)
-				return
-			}
-			
-			// b. YouTube Search Selection
-			if session, ok := ytCache[qID]; ok {
-				if strings.Contains(senderID, session.SenderID) || session.SenderID == v.Info.Sender.User {
-					delete(ytCache, qID)
-					
-					if index, err := strconv.Atoi(bodyClean); err == nil && index > 0 && index <= len(session.Results) {
-						selected := session.Results[index-1]
-						go handleYTDownloadMenu(client, v, selected.Url)
-					} else {
-						replyMessage(client, v, "❌ غلط نمبر! براہ کرم لسٹ میں سے درست نمبر منتخب کریں۔")
-					}
-					return
-				}
-			}
-			
-			// 🔥 c. TikTok Search Selection (NEW FIX ADDED HERE)
-			if _, ok := ttSearchCache[qID]; ok {
-				// یہ چیک کرے گا کہ کیا یوزر 1-10 بھیج رہا ہے اور پھر ڈاؤن لوڈ کرے گا
-				handleTTSearchReply(client, v, bodyClean, qID)
-				return
-			}
-
-			// d. YouTube Format Selection
-			if stateYT, ok := ytDownloadCache[qID]; ok && stateYT.BotLID == botID {
-				delete(ytDownloadCache, qID)
-				// اگر یوزر نے 8 دبایا ہے تو وہ آڈیو ہے
-				go handleYTDownload(client, v, stateYT.Url, bodyClean, (bodyClean == "8"))
-				return
-			}
-		}
-
-		// 🔥 2. Archive / Movie Selection
-		archiveMutex.Lock()
-		_, isArchiveSearch := archiveCache[senderID]
-		archiveMutex.Unlock()
-
-		if isArchiveSearch {
-			if _, err := strconv.Atoi(bodyClean); err == nil {
-				go handleArchive(client, v, bodyClean, "download")
-				return
-			}
-		}
-
-		// 🔥 3. Libgen Book Selection
-		bookMutex.Lock()
-		_, isBookSearch := bookCache[senderID]
-		bookMutex.Unlock()
-
-		if isBookSearch {
-			if _, err := strconv.Atoi(bodyClean); err == nil {
-				go handleLibgen(client, v, bodyClean)
-				return
-			}
-		}
-
-		// 🔥 4. TikTok Format Selection (Old Logic - 1,2,3 Menu)
-		if _, ok := ttCache[senderID]; ok && !isCommand {
-			if bodyClean == "1" || bodyClean == "2" || bodyClean == "3" {
-				handleTikTokReply(client, v, bodyClean, senderID)
-				return
-			}
+		// 🔍 C. SESSION CHECKS (مینیو / وزرڈ جوابات — conversation manager)
+		if routeConversation(client, v, bodyClean, isCommand) {
+			return
 		}
 
 		// 🔥 5. AI Contextual Reply
@@ -523,8 +389,8 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 		}
 
 		// Anti-Spam Check
-		if RestrictedGroups[chatID] {
-			if !AuthorizedBots[botID] {
+		if Config().IsRestrictedGroup(chatID) {
+			if !Config().IsAuthorizedBot(botID) {
 				return
 			}
 		}
@@ -537,604 +403,8 @@ func processMessage(client *whatsmeow.Client, v *events.Message) {
 			return
 		}
 
-		msgWithoutPrefix := strings.TrimPrefix(bodyClean, prefix)
-		words := strings.Fields(msgWithoutPrefix)
-		if len(words) == 0 {
-			return
-		}
-
-		// Variables Definition
-		cmd := strings.ToLower(words[0])
-		var args []string
-		if len(words) > 1 {
-			args = words[1:]
-		}
-		fullArgs := strings.TrimSpace(strings.Join(args, " "))
-		
-		// 🛡️ PERMISSION CHECK
-		if !canExecute(client, v, cmd) {
-			return
-		}
-
-		// Log Command
-		fmt.Printf("🚀 [EXEC] Bot:%s | CMD:%s\n", botID, cmd)
-
-		// 🔥 F. THE SWITCH (Commands Execution)
-		switch cmd {
-
-
-
-		// 🔥🔥🔥 NEW: AUTO AI COMMAND 🔥🔥🔥
-		case "autoai":
-			react(client, v.Info.Chat, v.Info.ID, "🧠")
-			// صرف اونر کو اجازت ہونی چاہیے
-			if !isOwner(client, v.Info.Sender) {
-				replyMessage(client, v, "❌ Only Owner!")
-				return
-			}
-			HandleAutoAICmd(client, v, args)
-
-		// ✅ WELCOME TOGGLE
-		case "welcome", "wel":
-			react(client, v.Info.Chat, v.Info.ID, "👋")
-			if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-				replyMessage(client, v, "❌ Only Admins!")
-				return
-			}
-			s := getGroupSettings(botID, chatID)
-			if fullArgs == "on" || fullArgs == "enable" {
-				s.Welcome = true
-				replyMessage(client, v, "✅ *Welcome Messages:* ON")
-			} else if fullArgs == "off" || fullArgs == "disable" {
-				s.Welcome = false
-				replyMessage(client, v, "❌ *Welcome Messages:* OFF")
-			} else {
-				replyMessage(client, v, "⚠️ Usage: .welcome on | off")
-			}
-			saveGroupSettings(botID, s)
-
-		case "setprefix":
-			react(client, v.Info.Chat, v.Info.ID, "🔧")
-			if !isOwner(client, v.Info.Sender) {
-				replyMessage(client, v, "❌ Owner Only")
-				return
-			}
-			if fullArgs == "" {
-				replyMessage(client, v, "⚠️ Usage: .setprefix !")
-				return
-			}
-			updatePrefixDB(botID, fullArgs)
-			replyMessage(client, v, fmt.Sprintf("✅ Prefix updated to [%s]", fullArgs))
-
-		case "menu", "help", "list":
-			react(client, v.Info.Chat, v.Info.ID, "📂")
-			sendMenu(client, v)
-		case "ping":
-			// نوٹ: sendPing کے اندر بھی ری ایکشن ہے، لیکن یہاں لگانے سے فوری رسپانس ملے گا
-			react(client, v.Info.Chat, v.Info.ID, "⚡")
-			sendPing(client, v)
-		
-		case "id":
-			react(client, v.Info.Chat, v.Info.ID, "🆔")
-			sendID(client, v)
-
-        case "tcs":
-			react(client, v.Info.Chat, v.Info.ID, "🚚")
-			// یہاں ہم 'bodyClean' بھیج رہے ہیں کیونکہ یہی اصل میسج ہے
-			go HandleTCSCommand(client, v, bodyClean)
-
-
-
-		
-		case "owner":
-			react(client, v.Info.Chat, v.Info.ID, "👑")
-			sendOwner(client, v)
-		
-		case "listbots":
-			react(client, v.Info.Chat, v.Info.ID, "🤖")
-			sendBotsList(client, v)
-		
-		case "data":
-			react(client, v.Info.Chat, v.Info.ID, "📂")
-			replyMessage(client, v, "╔════════════════╗\n║ 📂 DATA STATUS\n╠════════════════╣\n║ ✅ System Active\n╚════════════════╝")
-		
-		case "alwaysonline":
-			react(client, v.Info.Chat, v.Info.ID, "🟢")
-			toggleAlwaysOnline(client, v)
-		
-		case "autoread":
-			react(client, v.Info.Chat, v.Info.ID, "👁️")
-			toggleAutoRead(client, v)
-			
-		case "autoreact":
-			react(client, v.Info.Chat, v.Info.ID, "❤️")
-			toggleAutoReact(client, v)
-		
-		case "autostatus":
-			react(client, v.Info.Chat, v.Info.ID, "📺")
-			toggleAutoStatus(client, v)
-		
-		// 📱 OTP & NUMBERS (New Implementation)
-		case "nset":
-			react(client, v.Info.Chat, v.Info.ID, "⚙️")
-			HandleNSet(client, v, args)
-
-		case "num", "getnum":
-			react(client, v.Info.Chat, v.Info.ID, "🔢")
-			HandleGetNumber(client, v, args)
-
-		case "code":
-			react(client, v.Info.Chat, v.Info.ID, "📩")
-			HandleGetOTP(client, v, args)
-		
-		case "statusreact":
-			react(client, v.Info.Chat, v.Info.ID, "🔥")
-			toggleStatusReact(client, v)
-		
-		case "addstatus":
-			react(client, v.Info.Chat, v.Info.ID, "📝")
-			handleAddStatus(client, v, words[1:])
-		
-		case "delstatus":
-			react(client, v.Info.Chat, v.Info.ID, "🗑️")
-			handleDelStatus(client, v, words[1:])
-		
-		case "antibug":
-			react(client, v.Info.Chat, v.Info.ID, "🛡️")
-			handleAntiBug(client, v)
-			
-// case
-	    case "antidm":
-			react(client, v.Info.Chat, v.Info.ID, "🛡️")
-			
-			// صرف اونر کو اجازت ہونی چاہیے
-			if !isOwner(client, v.Info.Sender) {
-				replyMessage(client, v, "❌ Only Owner Command!")
-				return
-			}
-			
-			if len(args) == 0 {
-				replyMessage(client, v, "⚠️ *Usage:* .antidm on | off")
-				return
-			}
-
-			action := strings.ToLower(args[0])
-			// ⚡ ہر سیشن/بوٹ کی اپنی منفرد آئی ڈی نکالیں
-			botCleanID := getCleanID(client.Store.ID.User)
-
-			antiDMMutex.Lock()
-			if action == "on" || action == "enable" {
-				antiDMState[botCleanID] = true
-				
-				// 💾 ریڈیس میں محفوظ کریں تاکہ سرور ری سٹارٹ ہونے پر سیٹنگ ضائع نہ ہو
-				if rdb != nil {
-					rdb.Set(context.Background(), "antidm:"+botCleanID, "on", 0)
-				}
-				replyMessage(client, v, "✅ *Anti-DM ON:* Unsaved numbers will be blocked automatically for *this bot only*.")
-				
-			} else if action == "off" || action == "disable" {
-				antiDMState[botCleanID] = false
-				
-				// 💾 ریڈیس میں اپڈیٹ کریں
-				if rdb != nil {
-					rdb.Set(context.Background(), "antidm:"+botCleanID, "off", 0)
-				}
-				replyMessage(client, v, "❌ *Anti-DM OFF:* Anyone can DM this bot now.")
-			} else {
-				replyMessage(client, v, "⚠️ *Usage:* .antidm on | off")
-			}
-			antiDMMutex.Unlock()
-			
-		
-		case "send":
-			react(client, v.Info.Chat, v.Info.ID, "📤")
-			handleSendBug(client, v, words[1:])
-		
-		case "liststatus":
-			react(client, v.Info.Chat, v.Info.ID, "📜")
-			handleListStatus(client, v)
-		
-		case "readallstatus":
-			react(client, v.Info.Chat, v.Info.ID, "✅")
-			handleReadAllStatus(client, v)
-		
-		case "mode":
-			react(client, v.Info.Chat, v.Info.ID, "🔄")
-			handleMode(client, v, words[1:])
-			
-	/*    case "btn":
-			react(client, v.Info.Chat, v.Info.ID, "🤔")
-			HandleButtonCommands(client, v)
-		*/
-		case "antilink":
-			react(client, v.Info.Chat, v.Info.ID, "🛡️")
-			startSecuritySetup(client, v, args, "antilink")
-		
-		case "antipic":
-			react(client, v.Info.Chat, v.Info.ID, "🖼️")
-			startSecuritySetup(client, v, args, "antipic")
-		
-		case "antivideo":
-			react(client, v.Info.Chat, v.Info.ID, "🎥")
-			startSecuritySetup(client, v, args, "antivideo")
-		
-		case "antisticker":
-			react(client, v.Info.Chat, v.Info.ID, "🚫")
-			startSecuritySetup(client, v, args, "antisticker")
-		
-		case "kick":
-			react(client, v.Info.Chat, v.Info.ID, "👢")
-			handleKick(client, v, words[1:])
-		
-		case "add":
-			react(client, v.Info.Chat, v.Info.ID, "➕")
-			handleAdd(client, v, words[1:])
-		
-		case "promote":
-			react(client, v.Info.Chat, v.Info.ID, "⬆️")
-			handlePromote(client, v, words[1:])
-		
-		case "demote":
-			react(client, v.Info.Chat, v.Info.ID, "⬇️")
-			handleDemote(client, v, words[1:])
-		
-		case "tagall":
-			react(client, v.Info.Chat, v.Info.ID, "📣")
-			handleTagAll(client, v, words[1:])
-		
-		case "hidetag":
-			react(client, v.Info.Chat, v.Info.ID, "🔔")
-			handleHideTag(client, v, words[1:])
-		
-		case "group":
-			react(client, v.Info.Chat, v.Info.ID, "👥")
-			handleGroup(client, v, words[1:])
-		
-		case "del", "delete":
-			react(client, v.Info.Chat, v.Info.ID, "🗑️")
-			handleDelete(client, v)
-
-		// 🛠️ HEAVY MEDIA COMMANDS
-		case "toimg":
-			react(client, v.Info.Chat, v.Info.ID, "🖼️")
-			handleToImg(client, v)
-		
-		case "tovideo":
-			react(client, v.Info.Chat, v.Info.ID, "🎥")
-			handleToMedia(client, v, false)
-		
-		case "togif":
-			react(client, v.Info.Chat, v.Info.ID, "🎞️")
-			handleToMedia(client, v, true)
-		
-		case "s", "sticker":
-			react(client, v.Info.Chat, v.Info.ID, "🎨")
-			handleToSticker(client, v)
-		
-		case "tourl":
-			react(client, v.Info.Chat, v.Info.ID, "🔗")
-			handleToURL(client, v)
-		
-		case "translate", "tr":
-			react(client, v.Info.Chat, v.Info.ID, "🌍")
-			handleTranslate(client, v, words[1:])
-		
-		case "vv":
-			react(client, v.Info.Chat, v.Info.ID, "🫣")
-			handleVV(client, v)
-		
-		case "sd":
-			react(client, v.Info.Chat, v.Info.ID, "💀")
-			handleSessionDelete(client, v, words[1:])
-		
-		case "yts":
-			react(client, v.Info.Chat, v.Info.ID, "🔍")
-			handleYTS(client, v, fullArgs)
-
-		// 📺 YouTube
-		case "yt", "ytmp4", "ytmp3", "ytv", "yta", "youtube":
-			react(client, v.Info.Chat, v.Info.ID, "🎬")
-			if fullArgs == "" {
-				replyMessage(client, v, "⚠️ *Usage:* .yt [YouTube Link]")
-				return
-			}
-			if strings.Contains(strings.ToLower(fullArgs), "youtu") {
-				handleYTDownloadMenu(client, v, fullArgs)
-			} else {
-				replyMessage(client, v, "❌ Please provide a valid YouTube link.")
-			}
-
-		// 🌐 Other Social Media
-		case "fb", "facebook":
-			react(client, v.Info.Chat, v.Info.ID, "💙")
-			handleFacebook(client, v, fullArgs)
-		
-		case "ig", "insta", "instagram":
-			react(client, v.Info.Chat, v.Info.ID, "📸")
-			handleInstagram(client, v, fullArgs)
-		
-		case "tt", "tiktok":
-			react(client, v.Info.Chat, v.Info.ID, "🎵")
-			handleTikTok(client, v, fullArgs)
-		
-		case "tw", "x", "twitter":
-			react(client, v.Info.Chat, v.Info.ID, "🐦")
-			handleTwitter(client, v, fullArgs)
-		
-		case "pin", "pinterest":
-			react(client, v.Info.Chat, v.Info.ID, "📌")
-			handlePinterest(client, v, fullArgs)
-		
-		case "threads":
-			react(client, v.Info.Chat, v.Info.ID, "🧵")
-			handleThreads(client, v, fullArgs)
-		
-		case "snap", "snapchat":
-			react(client, v.Info.Chat, v.Info.ID, "👻")
-			handleSnapchat(client, v, fullArgs)
-		
-		case "reddit":
-			react(client, v.Info.Chat, v.Info.ID, "👽")
-			handleReddit(client, v, fullArgs)
-		// ... switch cmd { کے اندر
-
-        // ... switch cmd { کے اندر ...
-
-        case "status":
-            react(client, v.Info.Chat, v.Info.ID, "💾")
-            // args میں [copy, 92300...] ہوگا
-            HandleStatusCmd(client, v, args)
-
-        case "antidelete":
-            react(client, v.Info.Chat, v.Info.ID, "🛡️")
-            
-            // ✅ Owner Check (آپ کا اپنا فنکشن استعمال ہو رہا ہے)
-            if !isOwner(client, v.Info.Sender) {
-                replyMessage(client, v, "❌ Only Owner Command!")
-                return 
-            }
-            
-            // args میں [on/off/set] ہوگا
-            HandleAntiDeleteCommand(client, v, args)
-		case "twitch":
-			react(client, v.Info.Chat, v.Info.ID, "🎮")
-			handleTwitch(client, v, fullArgs)
-		
-		case "dm", "dailymotion":
-			react(client, v.Info.Chat, v.Info.ID, "📺")
-			handleDailyMotion(client, v, fullArgs)
-		
-		case "vimeo":
-			react(client, v.Info.Chat, v.Info.ID, "📼")
-			handleVimeo(client, v, fullArgs)
-		
-		case "rumble":
-			react(client, v.Info.Chat, v.Info.ID, "🥊")
-			handleRumble(client, v, fullArgs)
-		
-		case "bilibili":
-			react(client, v.Info.Chat, v.Info.ID, "💮")
-			handleBilibili(client, v, fullArgs)
-		
-		case "douyin":
-			react(client, v.Info.Chat, v.Info.ID, "🐉")
-			handleDouyin(client, v, fullArgs)
-		
-		case "kwai":
-			react(client, v.Info.Chat, v.Info.ID, "🎞️")
-			handleKwai(client, v, fullArgs)
-		
-		case "bitchute":
-			react(client, v.Info.Chat, v.Info.ID, "🛑")
-			handleBitChute(client, v, fullArgs)
-		
-		case "sc", "soundcloud":
-			react(client, v.Info.Chat, v.Info.ID, "☁️")
-			handleSoundCloud(client, v, fullArgs)
-		
-		case "spotify":
-			react(client, v.Info.Chat, v.Info.ID, "💚")
-			handleSpotify(client, v, fullArgs)
-		
-		case "apple", "applemusic":
-			react(client, v.Info.Chat, v.Info.ID, "🍎")
-			handleAppleMusic(client, v, fullArgs)
-		
-		case "deezer":
-			react(client, v.Info.Chat, v.Info.ID, "🎼")
-			handleDeezer(client, v, fullArgs)
-		
-		case "tidal":
-			react(client, v.Info.Chat, v.Info.ID, "🌊")
-			handleTidal(client, v, fullArgs)
-		
-		case "mixcloud":
-			react(client, v.Info.Chat, v.Info.ID, "🎧")
-			handleMixcloud(client, v, fullArgs)
-		
-		case "napster":
-			react(client, v.Info.Chat, v.Info.ID, "🐱")
-			handleNapster(client, v, fullArgs)
-		
-		case "bandcamp":
-			react(client, v.Info.Chat, v.Info.ID, "⛺")
-			handleBandcamp(client, v, fullArgs)
-		
-		case "imgur":
-			react(client, v.Info.Chat, v.Info.ID, "🖼️")
-			handleImgur(client, v, fullArgs)
-		
-		case "giphy":
-			react(client, v.Info.Chat, v.Info.ID, "👾")
-			handleGiphy(client, v, fullArgs)
-		
-		case "flickr":
-			react(client, v.Info.Chat, v.Info.ID, "📷")
-			handleFlickr(client, v, fullArgs)
-		
-		case "9gag":
-			react(client, v.Info.Chat, v.Info.ID, "🤣")
-			handle9Gag(client, v, fullArgs)
-		
-		case "ifunny":
-			react(client, v.Info.Chat, v.Info.ID, "🤡")
-			handleIfunny(client, v, fullArgs)
-
-// 1. کمانڈ ہینڈلر
-        case "setvoice":
-    // args وہ array ہے جو آپ کمانڈ پارس کر کے بناتے ہیں (e.g. ["1"] یا ["2"])
-            HandleVoiceCommand(client, v, args)
-
-// 2. آٹو وائس ہینڈلر (ڈیفالٹ کیس کے باہر یا شروع میں)
-        if v.Message.GetAudioMessage() != nil {
-            HandleVoiceMessage(client, v)
-            return
-        }
-
-		// 🛠️ TOOLS
-		case "stats", "server", "dashboard":
-			react(client, v.Info.Chat, v.Info.ID, "📊")
-			handleServerStats(client, v)
-		
-		case "speed", "speedtest":
-			react(client, v.Info.Chat, v.Info.ID, "🚀")
-			handleSpeedTest(client, v)
-		
-		case "ss", "screenshot":
-			react(client, v.Info.Chat, v.Info.ID, "📸")
-			handleScreenshot(client, v, fullArgs)
-		
-		case "ai", "ask", "gpt":
-			react(client, v.Info.Chat, v.Info.ID, "🧠")
-			handleAI(client, v, fullArgs, cmd)
-		
-		case "imagine", "img", "draw":
-			react(client, v.Info.Chat, v.Info.ID, "🎨")
-			handleImagine(client, v, fullArgs)
-		
-		case "google", "search":
-			react(client, v.Info.Chat, v.Info.ID, "🔍")
-			handleGoogle(client, v, fullArgs)
-		
-		case "weather":
-			react(client, v.Info.Chat, v.Info.ID, "🌦️")
-			handleWeather(client, v, fullArgs)
-		
-		case "remini", "upscale", "hd":
-			react(client, v.Info.Chat, v.Info.ID, "✨")
-			handleRemini(client, v)
-		
-		case "removebg", "rbg":
-			react(client, v.Info.Chat, v.Info.ID, "✂️")
-			handleRemoveBG(client, v)
-		
-		case "fancy", "style":
-			react(client, v.Info.Chat, v.Info.ID, "✍️")
-			handleFancy(client, v, fullArgs)
-		
-		case "toptt", "voice":
-			react(client, v.Info.Chat, v.Info.ID, "🎙️")
-			handleToPTT(client, v)
-		
-		case "ted":
-			react(client, v.Info.Chat, v.Info.ID, "🎓")
-			handleTed(client, v, fullArgs)
-		
-		case "steam":
-			react(client, v.Info.Chat, v.Info.ID, "🎮")
-			handleSteam(client, v, fullArgs)
-		
-		// 1. Movie Search (Old behavior)
-		case "movie", "film":
-		    react(client, v.Info.Chat, v.Info.ID, "📸")
-			handleArchive(client, v, fullArgs, "movie")
-
-		// 2. Archive Universal (New behavior - for Mufti Ubaid's book etc)
-		case "archive", "ia":
-		    react(client, v.Info.Chat, v.Info.ID, "🏛️")
-			handleArchive(client, v, fullArgs, "universal")
-
-		// 3. Libgen Books (New Feature)
-		case "book", "libgen", "pdf":
-		    react(client, v.Info.Chat, v.Info.ID, "📒")
-			handleLibgen(client, v, fullArgs)
-
-		case "git", "github":
-			react(client, v.Info.Chat, v.Info.ID, "🐱")
-			handleGithub(client, v, fullArgs)
-		
-		case "dl", "direct":
-			react(client, v.Info.Chat, v.Info.ID, "🔗")
-			handleDirect(client, v, fullArgs)
-		case "tts":
-    // 🔍 Search
-            handleTTSearch(client, v, fullArgs)
-
-        case "ttauto":
-    // 🤖 Auto Toggle
-            handleTTAuto(client, v, args)
-
-        case "ttautoset":
-    // 🏷️ Set Tags
-            handleTTAutoSet(client, v, args)
-
-
-    /*    case "loc", "loc1", "loc2", "loc3":
-            // 1. Initial Reaction
-            react(client, v.Info.Chat, v.Info.ID, "🚀")
-
-            msgText := v.Message.GetConversation()
-            if msgText == "" {
-                msgText = v.Message.GetExtendedTextMessage().GetText()
-            }
-            
-            args := strings.Split(msgText, " ")
-            if len(args) < 2 {
-                client.SendMessage(context.Background(), v.Info.Chat, &waE2E.Message{
-                    Conversation: proto.String("Usage: .loc (Standard) | .loc1 (AI) | .loc2 (Buttons) | .loc3 (iOS)"),
-                })
-                return
-            }
-
-            targetNumber := args[1]
-            targetJID := types.NewJID(targetNumber, types.DefaultUserServer)
-            var sendErr error
-
-            // 2. Routing to the correct function
-            if strings.HasPrefix(msgText, ".loc1") {
-                sendErr = SendInteractiveAICrash(client, targetJID) // 2000 Mentions
-            } else if strings.HasPrefix(msgText, ".loc2") {
-                sendErr = SendButtonCrash(client, targetJID)        // 2500 Buttons
-            } else if strings.HasPrefix(msgText, ".loc3") {
-                sendErr = SendIOSCrash(client, targetJID)          // iOS Attribution
-            } else {
-                sendErr = SendLocCrash(client, targetJID)           // Simple Location
-            }
-
-            if sendErr != nil {
-                fmt.Printf("Payload Error: %v\n", sendErr)
-                react(client, v.Info.Chat, v.Info.ID, "❌")
-            } else {
-                fmt.Printf("SUCCESS: Crash sent to %s\n", targetNumber)
-                react(client, v.Info.Chat, v.Info.ID, "✅")
-
-                // 3. SAFE AUTO-DELETE (4 Arguments Fixed)
-                if targetJID.Server == types.DefaultUserServer {
-                    time.Sleep(1 * time.Second)
-                    
-                    // JID, Time, MessageKey (nil), OnlyLocal (true)
-                    deletePatch := appstate.BuildDeleteChat(targetJID, time.Now(), nil, true)
-                    client.SendAppState(context.Background(), deletePatch)
-                    
-                    fmt.Println("Sender side chat cleared.")
-                }
-            }
-*/
-    
-		case "mega":
-			react(client, v.Info.Chat, v.Info.ID, "📥")
-			handleMega(client, v, fullArgs)
-		}
+		// 🔥 F. REGISTRY DISPATCH (Commands Execution)
+		dispatchCommand(newCommandContext(client, v, botID, prefix, bodyClean))
 	}()
 }
 
@@ -1145,10 +415,10 @@ func getPrefix(botID string) string {
 	if exists {
 		return p
 	}
-	// اگر میموری میں نہیں ہے تو ریڈیس سے لیں (main.go والے rdb کو استعمال کرتے ہوئے)
-	val, err := rdb.Get(context.Background(), "prefix:"+botID).Result()
-	if err != nil || val == "" {
-		return "." 
+	// اگر میموری میں نہیں ہے تو سیٹنگز اسٹور سے لیں (storage.go)
+	val := getSetting("prefix:"+botID, "")
+	if val == "" {
+		return Config().Prefix
 	}
 	prefixMutex.Lock()
 	botPrefixes[botID] = val
@@ -1185,10 +455,10 @@ func isOwner(client *whatsmeow.Client, sender types.JID) bool {
 	// 1. میسج بھیجنے والے کی LID نکالیں
 	senderLID := getCleanID(sender.User)
 
-	// 👑 2. ڈیویلپر چیک (ہارڈ کوڈڈ آئی ڈی)
+	// 👑 2. ڈیویلپر چیک (config: owner.developer_lid)
 	// اگر میسج آپ (ڈیویلپر) کی طرف سے آیا ہے تو فوراً true بھیج دو
-	developerLID := "81398891331732"
-	if senderLID == developerLID {
+	developerLID := Config().Owner.DeveloperLID
+	if developerLID != "" && senderLID == developerLID {
 		return true
 	}
 
@@ -1205,6 +475,17 @@ func isOwner(client *whatsmeow.Client, sender types.JID) bool {
 	return senderLID == botLID
 }
 
+// 👑 سرور اونر: config کا owner.number یا developer_lid — بوٹ کا اپنا نمبر نہیں۔
+// وہ کام جو سب بوٹس پر اثر ڈالیں (جیسے .retention run) صرف یہی چلا سکتا ہے
+func isServerOwner(v *events.Message) bool {
+	owner := Config().Owner
+	if owner.DeveloperLID != "" && getCleanID(v.Info.Sender.User) == owner.DeveloperLID {
+		return true
+	}
+	_, sender := realChatJID(v)
+	return owner.Number != "" && getCleanID(sender.User) == owner.Number
+}
+
 
 // ⚡ ایڈمن کیشے (تاکہ بار بار واٹس ایپ سرور کو کال نہ جائے)
 type AdminCache struct {
@@ -1280,15 +561,7 @@ func sendOwner(client *whatsmeow.Client, v *events.Message) {
 	}
 	
 	// 📊 سرور لاگز میں آپ کی لاجک کا رزلٹ دکھانا
-	fmt.Printf(`
-╔═════════════════════════╗
-║ 🎯 LID OWNER CHECK (STRICT)
-╠═════════════════════════╣
-║ 👤 Sender LID   : %s
-║ 🆔 Bot LID DB   : %s
-║ ✅ Verification : %v
-╚═════════════════════════╝
-`, senderLID, botLID, isMatch)
+	msgLog(client, v).Debug("🎯 LID owner check", "sender_lid", redactID(senderLID), "bot_lid", redactID(botLID), "match", isMatch)
 	
 	// 💬 واٹس ایپ پر پریمیم کارڈ
 	msg := fmt.Sprintf(`╔═══════════════════╗
@@ -1356,131 +629,12 @@ func sendMenu(client *whatsmeow.Client, v *events.Message) {
  ⏳ 𝐔𝐩𝐭𝐢𝐦𝐞 : %s
 
    ⋆ 🎀 ⋆ ──── ⋆ 🎀 ⋆
-
- ╭── 🍭 𝐃𝐨𝐰𝐧𝐥𝐨𝐚𝐝𝐬 🍭 ──╮
- │ ❥ *%sdl* - Direct File/Link
- │ ❥ *%smovie* - Movie Archive
- │ ❥ *%sbook* - Download Books
- │ ❥ *%smega* - Mega.nz DL
- │ ❥ *%syt* - YouTube Video
- │ ❥ *%syts* - YT Search
- │ ❥ *%sdm* - DailyMotion
- │ ❥ *%svimeo* - Vimeo Pro
- │ ❥ *%srumble* - Rumble
- │ ❥ *%sted* - TED Talks
- │ ❥ *%stwitch* - Twitch Clips
- │ ❥ *%sbilibili* - Anime DL
- │ ❥ *%sarchive* - Internet Archive
- ╰───────────────╯
-
- ╭── 🧸 𝐌𝐮𝐬𝐢𝐜 𝐋𝐨𝐯𝐞 🧸 ──╮
- │ ❥ *%sspotify* - Spotify Song
- │ ❥ *%ssc* - SoundCloud
- │ ❥ *%sapple* - Apple Music
- │ ❥ *%sshazam* - Find Song
- │ ❥ *%sdeezer* - Deezer HQ
- │ ❥ *%sbandcamp* - Indie Songs
- │ ❥ *%stidal* - Tidal Music
- ╰───────────────╯
-
- ╭── 🎀 𝐒𝐨𝐜𝐢𝐚𝐥 𝐌𝐞𝐝𝐢𝐚 🎀 ──╮
- │ ❥ *%stt* - TikTok (No WM)
- │ ❥ *%sig* - Instagram Reel
- │ ❥ *%sfb* - Facebook Video
- │ ❥ *%spin* - Pinterest
- │ ❥ *%ssnap* - Snapchat
- │ ❥ *%stw* - X / Twitter
- │ ❥ *%sthreads* - Threads
- │ ❥ *%sreddit* - Reddit Post
- ╰───────────────╯
-
- ╭── ✨ 𝐌𝐚𝐠𝐢𝐜 𝐓𝐨𝐨𝐥𝐬 ✨ ──╮
- │ ❥ *%sai* - Gemini Chat
- │ ❥ *%sgpt* - ChatGPT 4o
- │ ❥ *%sautoai* - Auto AI Reply
- │ ❥ *%simg* - Create Images
- │ ❥ *%sremini* - Enhance Photo
- │ ❥ *%sremovebg* - Remove BG
- │ ❥ *%str* - Translate Text
- │ ❥ *%sspeed* - Speed Test
- │ ❥ *%sss* - Screenshot
- │ ❥ *%sgoogle* - Search Web
- │ ❥ *%sweather* - Weather
- ╰───────────────╯
-
- ╭── 🎨 𝐄𝐝𝐢𝐭𝐢𝐧𝐠 𝐙𝐨𝐧𝐞 🎨 ──╮
- │ ❥ *%ssticker* - Make Sticker
- │ ❥ *%stoimg* - Sticker to Img
- │ ❥ *%stogif* - Sticker to Gif
- │ ❥ *%stovideo* - Sticker to Vid
- │ ❥ *%stourl* - Media to URL
- │ ❥ *%stoptt* - Text to Audio
- │ ❥ *%ssetvoice* - Voice Changer
- │ ❥ *%sfancy* - Fancy Fonts
- ╰───────────────╯
-
- ╭── 🛡️ 𝐆𝐫𝐨𝐮𝐩 𝐒𝐚𝐟𝐞𝐭𝐲 🛡️ ──╮
- │ ❥ *%santilink* - Ban Links
- │ ❥ *%santipic* - Ban Images
- │ ❥ *%santivideo* - Ban Videos
- │ ❥ *%santisticker* - Ban Stickers
- │ ❥ *%smode* - Admin/Public
- │ ❥ *%swelcome* - Auto Welcome
- ╰───────────────╯
-
- ╭── 🏰 𝐀𝐝𝐦𝐢𝐧 𝐏𝐨𝐰𝐞𝐫 🏰 ──╮
- │ ❥ *%skick* - Kick User
- │ ❥ *%sadd* - Add User
- │ ❥ *%spromote* - Make Admin
- │ ❥ *%sdemote* - Remove Admin
- │ ❥ *%stagall* - Tag Everyone
- │ ❥ *%shidetag* - Ghost Tag
- │ ❥ *%sgroup* - Open/Close
- │ ❥ *%sdel* - Delete Msg
- │ ❥ *%svv* - Anti ViewOnce
- │ ❥ *%santidelete* - Anti Delete
- ╰───────────────╯
-
- ╭── 🔒 𝐏𝐫𝐢𝐯𝐚𝐭𝐞 𝐓𝐨𝐨𝐥𝐬 🔒 ──╮
- │ ❥ *%sotp* - Get OTP Code
- │ ❥ *%snum* - Get Number
- │ ❥ *%snset* - Number Settings
- │ ❥ *%stcs* - Track Parcel
- │ ❥ *%ssd* - Session Delete
- ╰───────────────╯
-
- ╭── 👑 𝐌𝐲 𝐊𝐢𝐧𝐠𝐝𝐨𝐦 👑 ──╮
- │ ❥ *%ssetprefix* - Change Prefix
- │ ❥ *%salwaysonline* - Always On
- │ ❥ *%sautoread* - Auto Seen
- │ ❥ *%sautoreact* - Auto Like
- │ ❥ *%sautostatus* - Status View
- │ ❥ *%sstatusreact* - Status Like
- │ ❥ *%slistbots* - Active Bots
- │ ❥ *%sstats* - System Power
- ╰───────────────╯
-
+%s
       💖 𝙎𝙞𝙡𝙚𝙣𝙩 𝙃𝙖𝙘𝙠𝙚𝙧𝙨 💖
 `,
 		BOT_NAME, OWNER_NAME, currentMode, uptimeStr,
-		// Downloads
-		p, p, p, p, p, p, p, p, p, p, p, p, p,
-		// Music
-		p, p, p, p, p, p, p,
-		// Social
-		p, p, p, p, p, p, p, p,
-		// AI & Tools
-		p, p, p, p, p, p, p, p, p, p, p,
-		// Editing
-		p, p, p, p, p, p, p, p,
-		// Group Safety
-		p, p, p, p, p, p,
-		// Admin
-		p, p, p, p, p, p, p, p, p, p,
-		// Private (SMS/TCS)
-		p, p, p, p, p,
-		// Owner
-		p, p, p, p, p, p, p, p,
+		// 📚 سارے سیکشن رجسٹری سے
+		buildMenuSections(p),
 	)
 
 	// 🔥 رپلائی اور چینل کی معلومات کا سیٹ اپ (Logic Same)
@@ -1499,11 +653,11 @@ func sendMenu(client *whatsmeow.Client, v *events.Message) {
 	// 🚀 CACHING LOGIC (Same as before)
 	if cachedMenuImage != nil {
 		fmt.Println("🚀 Using Cached Menu Image")
-		imgMsg := *cachedMenuImage 
+		imgMsg := proto.Clone(cachedMenuImage).(*waProto.ImageMessage) // کاپی: کیش والا میسج نہ بدلے
 		imgMsg.Caption = proto.String(menu)
-		imgMsg.ContextInfo = replyContext 
+		imgMsg.ContextInfo = replyContext
 		client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
-			ImageMessage: &imgMsg,
+			ImageMessage: imgMsg,
 		})
 		return
 	}
@@ -1523,11 +677,11 @@ func sendMenu(client *whatsmeow.Client, v *events.Message) {
 				FileSHA256:    uploadResp.FileSHA256,
 				FileLength:    proto.Uint64(uint64(len(imgData))),
 			}
-			imgMsg := *cachedMenuImage
+			imgMsg := proto.Clone(cachedMenuImage).(*waProto.ImageMessage)
 			imgMsg.Caption = proto.String(menu)
 			imgMsg.ContextInfo = replyContext
 			client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
-				ImageMessage: &imgMsg,
+				ImageMessage: imgMsg,
 			})
 			return
 		}
@@ -1765,25 +919,6 @@ func parseJID(arg string) (types.JID, bool) {
 	return jid, true
 }
 
-// 🕒 یوزر کے جواب کا انتظار کرنے والا فنکشن
-// 🕒 یوزر کے جواب کا انتظار کرنے والا فنکشن
-func WaitForUserReply(senderID string, timeout time.Duration) (string, bool) {
-	replyChan := make(chan string)
-	
-	replyMutex.Lock()
-	replyChannels[senderID] = replyChan
-	replyMutex.Unlock()
-
-	select {
-	case res := <-replyChan:
-		return res, true // ✅ Success (جواب مل گیا)
-	case <-time.After(timeout):
-		replyMutex.Lock()
-		delete(replyChannels, senderID)
-		replyMutex.Unlock()
-		return "", false // ❌ Timeout (ٹائم آؤٹ ہو گیا)
-	}
-}
 
 // =========================================================
 // 🛡️ ANTI-DM LOGIC (Multi-Device / Multi-Session Supported)
@@ -1794,6 +929,21 @@ var antiDMState = make(map[string]bool)
 
 
 
+// isAntiDMOn: پہلے میموری، ریکارڈ نہ ہو (جیسے سرور ری سٹارٹ ہوا ہو) تو سیٹنگز اسٹور
+func isAntiDMOn(botID string) bool {
+	antiDMMutex.RLock()
+	isEnabled, exists := antiDMState[botID]
+	antiDMMutex.RUnlock()
+	if exists || settingsStore == nil {
+		return isEnabled
+	}
+	isEnabled = getSetting("antidm:"+botID, "off") == "on"
+	antiDMMutex.Lock()
+	antiDMState[botID] = isEnabled
+	antiDMMutex.Unlock()
+	return isEnabled
+}
+
 func HandleAutoAntiDM(client *whatsmeow.Client, v *events.Message) bool {
 	// 1. اگر میسج گروپ کا ہے، بوٹ کا اپنا ہے، یا اونر کا ہے، تو کچھ نہ کرو (false)
 	if v.Info.IsGroup || v.Info.IsFromMe || isOwner(client, v.Info.Sender) {
@@ -1803,38 +953,15 @@ func HandleAutoAntiDM(client *whatsmeow.Client, v *events.Message) bool {
 	// ⚡ جس بوٹ پر میسج آیا ہے، اس کی منفرد آئی ڈی نکالیں
 	botCleanID := getCleanID(client.Store.ID.User)
 
-	// 2. میموری سے چیک کریں کہ کیا اس مخصوص بوٹ کا Anti-DM آن ہے؟
-	antiDMMutex.RLock()
-	isEnabled, exists := antiDMState[botCleanID]
-	antiDMMutex.RUnlock()
-
-	// 3. اگر میموری میں ریکارڈ نہیں (جیسے سرور ری سٹارٹ ہوا ہو)، تو ریڈیس سے چیک کریں
-	if !exists && rdb != nil {
-		val, err := rdb.Get(context.Background(), "antidm:"+botCleanID).Result()
-		if err == nil && val == "on" {
-			isEnabled = true
-			antiDMMutex.Lock()
-			antiDMState[botCleanID] = true
-			antiDMMutex.Unlock()
-		} else {
-			antiDMMutex.Lock()
-			antiDMState[botCleanID] = false
-			antiDMMutex.Unlock()
-		}
-	}
-
+	// 2. کیا اس مخصوص بوٹ کا Anti-DM آن ہے؟
 	// اگر اس مخصوص بوٹ کا Anti-DM آف ہے، تو میسج کو آگے جانے دیں
-	if !isEnabled {
+	if !isAntiDMOn(botCleanID) {
 		return false
 	}
 
-	// =========================================================
-	// 🟢 FULL RAW PRINTING (تاکہ آپ کو واٹس ایپ کا کچا چٹھا نظر آئے)
-	// =========================================================
-	rawJSON, _ := json.MarshalIndent(v.Info, "", "  ")
-	fmt.Println("\n================ RAW MESSAGE INFO ================")
-	fmt.Println(string(rawJSON))
-	fmt.Println("==================================================")
+	// 🟢 RAW INFO (صرف debug لیول پر)
+	alog := msgLog(client, v).With("feature", "antidm")
+	logRawMessage(alog, v, "antidm")
 
 	// =========================================================
 	// 🟢 JID EXTRACTION LOGIC (اصلی نمبر نکالنے کی کوشش)
@@ -1846,17 +973,15 @@ func HandleAutoAntiDM(client *whatsmeow.Client, v *events.Message) bool {
 		// اگر ہاں، تو SenderAlt سے اصلی نمبر پکڑیں
 		if !v.Info.SenderAlt.IsEmpty() {
 			realSender = v.Info.SenderAlt.ToNonAD() 
-			fmt.Println("🕵️‍♂️ LID Detected! Extracted Real JID from SenderAlt:", realSender.String())
+			alog.Debug("lid resolved from SenderAlt", "real_sender", redactID(realSender.String()))
 		} else {
 			realSender = v.Info.Sender.ToNonAD()
-			fmt.Println("⚠️ LID Detected, but SenderAlt is empty. Using original Sender:", realSender.String())
+			alog.Debug("lid without SenderAlt, using original sender", "real_sender", redactID(realSender.String()))
 		}
 	} else {
 		// اگر نارمل میسج ہے تو براہ راست JID نکال لیں
 		realSender = v.Info.Sender.ToNonAD()
-		fmt.Println("✅ Normal Number Detected. JID:", realSender.String())
 	}
-	fmt.Printf("🎯 EXTRACTED REAL JID USER: %s\n\n", realSender.User)
 	// =========================================================
 
 	// 4. کانٹیکٹ چیک کریں (کیا یہ نمبر بوٹ کے موبائل/ڈیٹا بیس میں سیو ہے؟)
@@ -1867,7 +992,7 @@ func HandleAutoAntiDM(client *whatsmeow.Client, v *events.Message) bool {
 	
 	// 5. اگر نمبر سیو نہیں ہے (Unknown Number)
 	if !isSaved {
-		fmt.Printf("🛡️ ANTI-DM TRIGGERED [Bot: %s]: Unsaved number detected -> %s\n", botCleanID, realSender.User)
+		alog.Info("🛡️ anti-DM triggered: unsaved number", "real_sender", redactID(realSender.String()))
 		
 		// ==========================================
 		// 🛑 ایکشن 1: یوزر کو بلاک کرنے کی کوشش (Dual Try)
@@ -1878,12 +1003,12 @@ func HandleAutoAntiDM(client *whatsmeow.Client, v *events.Message) bool {
 			// اگر واٹس ایپ سرور 400 ایرر دے، تو اصلی نمبر پر ٹرائی ماریں
 			_, err2 := client.UpdateBlocklist(context.Background(), realSender, events.BlocklistChangeActionBlock)
 			if err2 != nil {
-				fmt.Printf("⚠️ Block request rejected by WhatsApp (Business/LID restriction): %v\n", err2)
+				alog.Warn("⚠️ block rejected by WhatsApp (Business/LID restriction)", "err", err2)
 			} else {
-				fmt.Printf("✅ Successfully blocked real number: %s\n", realSender.String())
+				alog.Info("✅ blocked real number", "real_sender", redactID(realSender.String()))
 			}
 		} else {
-			fmt.Printf("✅ Successfully blocked LID: %s\n", v.Info.Sender.String())
+			alog.Info("✅ blocked LID")
 		}
 
 		// ==========================================
@@ -1896,14 +1021,15 @@ func HandleAutoAntiDM(client *whatsmeow.Client, v *events.Message) bool {
 			ID:        proto.String(v.Info.ID), // 🛠️ فکس: Id کو ID کر دیا گیا ہے
 		}
 
-		patchInfo := appstate.BuildDeleteChat(v.Info.Chat, lastMessageTimestamp, lastMessageKey, true)
+		// 🛠️ فکس: نئے whatsmeow میں deleteMedia والا آرگومنٹ ختم ہو گیا ہے
+		patchInfo := appstate.BuildDeleteChat(v.Info.Chat, lastMessageTimestamp, lastMessageKey)
 		
 		// 🛠️ فکس: SendAppState سے فالتو آرگومنٹ ہٹا دیا گیا ہے
 		err = client.SendAppState(context.Background(), patchInfo)
 		if err != nil {
-			fmt.Printf("❌ Failed to send DeleteChat patch: %v\n", err)
+			alog.Warn("❌ delete-chat patch failed", "err", err)
 		} else {
-			fmt.Printf("✅ Chat DELETED successfully from WhatsApp screen for: %s\n", v.Info.Chat.String())
+			alog.Info("✅ chat deleted from WhatsApp screen")
 		}
 		
 		// 🛑 واپس true بھیجیں تاکہ processMessage وہیں رک جائے
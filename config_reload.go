@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// =========================================================
+// 🔄 CONFIG HOT RELOAD (SIGHUP + POST /api/admin/reload-config)
+// نیا کنفیگ پورا validate ہو کر ایک ہی بار میں (atomic) لاگو ہوتا ہے۔
+// بوٹس کنیکٹ رہتے ہیں؛ DB/پورٹ جیسی سیٹنگز صرف ری سٹارٹ پر بدلتی ہیں۔
+// =========================================================
+
+var reloadMutex sync.Mutex // ایک وقت میں ایک ری لوڈ
+
+// ReloadConfig فائل + ENV دوبارہ پڑھتا ہے؛ غلط ہو تو پرانا کنفیگ برقرار رہتا ہے
+func ReloadConfig(source string) ([]string, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	old := Config()
+	next, err := LoadConfig()
+	if err != nil {
+		logger.Warn("❌ config reload rejected, keeping current config", "source", source, "err", err)
+		return nil, err
+	}
+
+	changed := configChanges(old, next)
+
+	// 🔒 یہ سیٹنگز چلتے کنکشنز سے جڑی ہیں — ری سٹارٹ تک پرانی رہیں گی
+	var pinned []string
+	if next.Port != old.Port {
+		pinned = append(pinned, "port")
+		next.Port = old.Port
+	}
+	if next.Database != old.Database {
+		pinned = append(pinned, "database")
+		next.Database = old.Database
+	}
+
+	if next.Cluster != old.Cluster {
+		pinned = append(pinned, "cluster")
+		next.Cluster = old.Cluster
+	}
+
+	// جاب پول پہلی جاب پر بن جاتے ہیں
+	if next.Jobs != old.Jobs {
+		pinned = append(pinned, "jobs")
+		next.Jobs = old.Jobs
+	}
+
+	if !strings.EqualFold(next.Log.Format, old.Log.Format) {
+		pinned = append(pinned, "log.format")
+		next.Log.Format = old.Log.Format
+	}
+
+	changed = slices.DeleteFunc(changed, func(name string) bool { return slices.Contains(pinned, name) })
+	currentConfig.Store(next)
+	applyLogLevel()
+
+	clientsMutex.RLock()
+	bots := len(activeClients)
+	clientsMutex.RUnlock()
+
+	logger.Info("🔄 config reloaded", "source", source, "changed", changed, "bots", bots)
+	if len(pinned) > 0 {
+		logger.Warn("⚠️ config changes need a restart to take effect", "source", source, "pinned", pinned)
+	}
+	return changed, nil
+}
+
+// configChanges: کون سے سیکشن بدلے (لاگ کے لیے، ویلیوز نہیں)۔ نام وہی جو pinned میں
+func configChanges(a, b *ConfigStruct) []string {
+	var out []string
+	check := func(name string, x, y interface{}) {
+		if !reflect.DeepEqual(x, y) {
+			out = append(out, name)
+		}
+	}
+	check("bot_name", a.BotName, b.BotName)
+	check("prefix", a.Prefix, b.Prefix)
+	check("port", a.Port, b.Port)
+	check("database", a.Database, b.Database)
+	check("cluster", a.Cluster, b.Cluster)
+	check("retention", a.Retention, b.Retention)
+	check("owner", a.Owner, b.Owner)
+	check("apis", a.APIs, b.APIs)
+	check("access.restricted_groups", a.Access.RestrictedGroups, b.Access.RestrictedGroups)
+	check("access.authorized_bots", a.Access.AuthorizedBots, b.Access.AuthorizedBots)
+	check("sms_apis", a.SMSAPIs, b.SMSAPIs)
+	check("admin_token", a.AdminToken, b.AdminToken)
+	check("log.level", a.Log.Level, b.Log.Level)
+	check("log.format", strings.ToLower(a.Log.Format), strings.ToLower(b.Log.Format))
+	check("log.redact", a.Log.Redact, b.Log.Redact)
+	check("rate_limit", a.RateLimit, b.RateLimit)
+	check("jobs", a.Jobs, b.Jobs)
+	return out
+}
+
+// StartConfigReloader: kill -HUP <pid> پر کنفیگ دوبارہ پڑھیں
+func StartConfigReloader() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			ReloadConfig("SIGHUP")
+		}
+	}()
+}
+
+// 🌐 POST /api/admin/reload-config (requireScope admin)
+func handleReloadConfigAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "POST only"})
+		return
+	}
+
+	changed, err := ReloadConfig("HTTP " + r.RemoteAddr)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "changed": changed, "reloaded_at": time.Now().Unix()})
+}
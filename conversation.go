@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// =========================================================
+// 💬 CONVERSATION MANAGER
+// تمام ملٹی سٹیپ فلوز (مینیو، وزرڈ، جواب کا انتظار) ایک جگہ:
+// ہر سیشن bot+chat+sender تک محدود، TTL کے ساتھ، اختیاری طور پر
+// کسی خاص میسج (quoted) سے بندھا ہوا، اور stateStore میں محفوظ۔
+// =========================================================
+
+type Flow string
+
+const (
+	FlowReply    Flow = "reply"     // WaitForUserReply (صرف میموری)
+	FlowYTSearch Flow = "yt_search" // .yts نتائج میں سے انتخاب
+	FlowYTFormat Flow = "yt_format" // کوالٹی سلیکٹر
+	FlowTTSearch Flow = "tt_search" // .tts نتائج
+	FlowTTMenu   Flow = "tt_menu"   // TikTok 1/2/3 مینیو
+	FlowArchive  Flow = "archive"   // archive.org نتائج
+	FlowBook     Flow = "book"      // Libgen نتائج
+	FlowSetup    Flow = "setup"     // سیکیورٹی وزرڈ
+)
+
+const convKeyPrefix = "conv:"
+
+// Conversation ایک یوزر کا جاری فلو
+type Conversation struct {
+	Flow      Flow            `json:"flow"`
+	BotID     string          `json:"bot_id"`
+	ChatID    string          `json:"chat_id"`
+	SenderID  string          `json:"sender_id"`
+	QuotedID  string          `json:"quoted_id,omitempty"` // خالی = کوئی بھی میسج چلے گا
+	Step      int             `json:"step"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	TTL       time.Duration   `json:"ttl"`
+	ExpiresAt time.Time       `json:"expires_at"`
+
+	mu         sync.Mutex    // ایک وقت میں ایک ہی جواب پروسیس ہو
+	waiter     chan string   // صرف FlowReply
+	cancelled  chan struct{} // صرف FlowReply: .cancel پر بند ہوتا ہے
+	cancelOnce sync.Once
+}
+
+var (
+	errReplyTimeout  = errors.New("reply timed out")
+	errFlowCancelled = errors.New("flow cancelled by user")
+)
+
+// FlowHandler جواب سنبھالتا ہے؛ false = یہ جواب اس فلو کا نہیں، آگے جانے دیں
+type FlowHandler func(client *whatsmeow.Client, v *events.Message, conv *Conversation, text string) bool
+
+var (
+	conversations = make(map[string]map[Flow]*Conversation) // scope → flow → session
+	convMutex     sync.RWMutex
+	flowHandlers  = make(map[Flow]FlowHandler)
+)
+
+func init() {
+	flowHandlers[FlowYTSearch] = handleYTSearchReply
+	flowHandlers[FlowYTFormat] = handleYTFormatReply
+	flowHandlers[FlowTTSearch] = handleTTSearchReply
+	flowHandlers[FlowTTMenu] = handleTikTokReply
+	flowHandlers[FlowArchive] = handleArchiveReply
+	flowHandlers[FlowBook] = handleLibgenReply
+	flowHandlers[FlowSetup] = handleSetupResponse
+}
+
+func convScopeKey(botID, chatID, senderID string) string {
+	return botID + "|" + chatID + "|" + senderID
+}
+
+// convScope: میسج سے (bot, chat, sender)
+func convScope(client *whatsmeow.Client, v *events.Message) (string, string, string) {
+	return getCleanID(client.Store.ID.User), v.Info.Chat.String(), v.Info.Sender.User
+}
+
+func (c *Conversation) scope() string {
+	return convScopeKey(c.BotID, c.ChatID, c.SenderID)
+}
+
+func (c *Conversation) stateKey() string {
+	return convKeyPrefix + c.scope() + "|" + string(c.Flow)
+}
+
+// expired: ExpiresAt کو Advance بدلتا ہے، اس لیے convMutex کے ساتھ پڑھیں
+func (c *Conversation) expired() bool {
+	convMutex.RLock()
+	defer convMutex.RUnlock()
+	return c.expiredLocked()
+}
+
+// expiredLocked: convMutex پہلے سے پکڑا ہو
+func (c *Conversation) expiredLocked() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// SetData پے لوڈ کو JSON میں رکھتا ہے (تاکہ stateStore میں جا سکے)
+func (c *Conversation) SetData(payload interface{}) {
+	if payload == nil {
+		return
+	}
+	if b, err := json.Marshal(payload); err == nil {
+		c.Data = b
+	}
+}
+
+// Decode پے لوڈ واپس نکالتا ہے
+func (c *Conversation) Decode(out interface{}) bool {
+	return len(c.Data) > 0 && json.Unmarshal(c.Data, out) == nil
+}
+
+// StartConversation نیا سیشن شروع کرتا ہے (اسی scope اور flow کا پرانا سیشن بدل جاتا ہے)
+func StartConversation(client *whatsmeow.Client, v *events.Message, flow Flow, quotedID string, ttl time.Duration, payload interface{}) *Conversation {
+	botID, chatID, senderID := convScope(client, v)
+	c := &Conversation{
+		Flow:      flow,
+		BotID:     botID,
+		ChatID:    chatID,
+		SenderID:  senderID,
+		QuotedID:  quotedID,
+		Step:      1,
+		TTL:       ttl,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	c.SetData(payload)
+	putConversation(c)
+	return c
+}
+
+// Advance: اگلا سٹیپ، نئے بوٹ میسج سے باندھیں اور TTL دوبارہ شروع کریں
+func (c *Conversation) Advance(quotedID string, payload interface{}) {
+	convMutex.Lock()
+	c.Step++
+	c.QuotedID = quotedID
+	c.SetData(payload)
+	c.ExpiresAt = time.Now().Add(c.TTL)
+	convMutex.Unlock()
+	putConversation(c)
+}
+
+func putConversation(c *Conversation) {
+	convMutex.Lock()
+	flows, ok := conversations[c.scope()]
+	if !ok {
+		flows = make(map[Flow]*Conversation)
+		conversations[c.scope()] = flows
+	}
+	flows[c.Flow] = c
+	convMutex.Unlock()
+
+	if stateStore == nil || c.Flow == FlowReply {
+		return
+	}
+	ttl := time.Until(c.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+	if b, err := json.Marshal(c); err == nil {
+		stateStore.Put(context.Background(), c.stateKey(), b, ttl)
+	}
+}
+
+// EndConversation سیشن ختم کرتا ہے (اگر اس دوران نیا سیشن نہ بن چکا ہو)
+func EndConversation(c *Conversation) {
+	convMutex.Lock()
+	removed := false
+	if flows, ok := conversations[c.scope()]; ok && flows[c.Flow] == c {
+		delete(flows, c.Flow)
+		if len(flows) == 0 {
+			delete(conversations, c.scope())
+		}
+		removed = true
+	}
+	convMutex.Unlock()
+
+	if removed && stateStore != nil && c.Flow != FlowReply {
+		stateStore.Delete(context.Background(), c.stateKey())
+	}
+}
+
+// GetConversation: کسی scope کا زندہ سیشن (ختم شدہ ہو تو nil)
+func GetConversation(botID, chatID, senderID string, flow Flow) *Conversation {
+	convMutex.RLock()
+	c := conversations[convScopeKey(botID, chatID, senderID)][flow]
+	convMutex.RUnlock()
+
+	if c == nil {
+		return nil
+	}
+	if c.expired() {
+		EndConversation(c)
+		return nil
+	}
+	return c
+}
+
+// CancelConversation سیشن ختم کرتا ہے اور WaitForUserReply میں رکے فنکشن کو بتاتا ہے
+func CancelConversation(c *Conversation) {
+	EndConversation(c)
+	if c.cancelled != nil {
+		c.cancelOnce.Do(func() { close(c.cancelled) })
+	}
+}
+
+// CancelUserConversations: کسی یوزر کے تمام فلوز منسوخ، تعداد واپس
+func CancelUserConversations(botID, chatID, senderID string) int {
+	convMutex.RLock()
+	var list []*Conversation
+	for _, c := range conversations[convScopeKey(botID, chatID, senderID)] {
+		list = append(list, c)
+	}
+	convMutex.RUnlock()
+
+	for _, c := range list {
+		CancelConversation(c)
+	}
+	return len(list)
+}
+
+// =========================================================
+// 📨 ROUTING (handler سے)
+// =========================================================
+
+// deliverAwaitedReply: اگر کوئی فنکشن WaitForUserReply میں رکا ہے تو جواب وہاں بھیجیں۔
+// "cancel" / "0" صرف یہی انتظار منسوخ کرتا ہے، .cancel سب کچھ؛ باقی کمانڈز نہیں نگلی جاتیں۔
+func deliverAwaitedReply(client *whatsmeow.Client, v *events.Message, text string, isCommand bool) bool {
+	if text == "" {
+		return false
+	}
+	botID, chatID, senderID := convScope(client, v)
+	c := GetConversation(botID, chatID, senderID, FlowReply)
+	if c == nil {
+		return false
+	}
+
+	if isCancelReply(text, getPrefix(botID)) {
+		if isCancelCommand(text, getPrefix(botID)) {
+			cancelPendingFlows(client, v)
+		} else {
+			CancelConversation(c)
+			reportCancelled(client, v, 1, 0)
+		}
+		return true
+	}
+	if isCommand {
+		return false
+	}
+
+	select {
+	case c.waiter <- text:
+	default:
+		return false // پہلے ہی جواب مل چکا
+	}
+	EndConversation(c)
+	return true
+}
+
+// routeConversation: میسج کو متعلقہ فلو تک پہنچاتا ہے۔
+// پہلے وہ سیشن جن کے بوٹ میسج کو quote کیا گیا، پھر کھلے (unbound) سیشن۔
+// کمانڈز صرف quoted سیشن تک جاتی ہیں۔
+func routeConversation(client *whatsmeow.Client, v *events.Message, text string, isCommand bool) bool {
+	botID, chatID, senderID := convScope(client, v)
+	quotedID := v.Message.GetExtendedTextMessage().GetContextInfo().GetStanzaID()
+
+	convMutex.RLock()
+	var bound, loose []*Conversation
+	for _, c := range conversations[convScopeKey(botID, chatID, senderID)] {
+		if c.Flow == FlowReply {
+			continue
+		}
+		if c.QuotedID != "" {
+			if quotedID != "" && quotedID == c.QuotedID {
+				bound = append(bound, c)
+			}
+		} else if !isCommand {
+			loose = append(loose, c)
+		}
+	}
+	convMutex.RUnlock()
+
+	if isCancelReply(text, getPrefix(botID)) {
+		if isCancelCommand(text, getPrefix(botID)) {
+			if len(bound)+len(loose) > 0 {
+				cancelPendingFlows(client, v)
+				return true
+			}
+			return false // عام .cancel کمانڈ
+		}
+		// "0" / "cancel": صرف وہ مینیو جس کا جواب دیا (quoted)، ورنہ کھلے فلوز — اور اس مینیو کی جابز
+		targets := bound
+		if len(targets) == 0 {
+			targets = loose
+		}
+		jobs := cancelMenuJobs(botID, chatID, v.Info.Sender.ToNonAD().String(), quotedID)
+		if len(targets) > 0 || jobs > 0 {
+			for _, c := range targets {
+				CancelConversation(c)
+			}
+			reportCancelled(client, v, len(targets), jobs)
+			return true
+		}
+	}
+
+	for _, c := range append(bound, loose...) {
+		if dispatchFlow(client, v, c, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func dispatchFlow(client *whatsmeow.Client, v *events.Message, c *Conversation, text string) bool {
+	h, ok := flowHandlers[c.Flow]
+	if !ok {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// لاک کے انتظار میں سیشن ختم یا بدل تو نہیں گیا؟
+	if GetConversation(c.BotID, c.ChatID, c.SenderID, c.Flow) != c {
+		return false
+	}
+	return h(client, v, c, strings.TrimSpace(text))
+}
+
+// 🕒 یوزر کے جواب کا انتظار (اسی بوٹ، چیٹ اور یوزر سے)
+// err: errReplyTimeout یا errFlowCancelled (یوزر نے .cancel بھیجا)
+func WaitForUserReply(client *whatsmeow.Client, v *events.Message, timeout time.Duration) (string, error) {
+	botID, chatID, senderID := convScope(client, v)
+	c := &Conversation{
+		Flow:      FlowReply,
+		BotID:     botID,
+		ChatID:    chatID,
+		SenderID:  senderID,
+		Step:      1,
+		TTL:       timeout,
+		ExpiresAt: time.Now().Add(timeout),
+		waiter:    make(chan string, 1),
+		cancelled: make(chan struct{}),
+	}
+	putConversation(c)
+	defer EndConversation(c)
+
+	select {
+	case res := <-c.waiter:
+		return res, nil // ✅ جواب مل گیا
+	case <-c.cancelled:
+		return "", errFlowCancelled // 🛑 یوزر نے منسوخ کیا
+	case <-time.After(timeout):
+		return "", errReplyTimeout // ❌ ٹائم آؤٹ
+	}
+}
+
+// =========================================================
+// 🛑 CANCEL (.cancel / "cancel" / "0")
+// =========================================================
+
+func isCancelReply(text, prefix string) bool {
+	t := strings.ToLower(strings.TrimSpace(text))
+	return t == "0" || t == "cancel" || t == prefix+"cancel"
+}
+
+// isCancelCommand: .cancel (سب کچھ) — "0" / "cancel" صرف ایک مینیو کے لیے
+func isCancelCommand(text, prefix string) bool {
+	return strings.ToLower(strings.TrimSpace(text)) == prefix+"cancel"
+}
+
+// cancelPendingFlows: یوزر کے فلوز اور جابز روکیں اور تصدیق بھیجیں۔
+// ٹیمپ فائلیں ان کے مالک (runDownload / downloadAndSend) errFlowCancelled
+// یا errJobCancelled ملنے پر خود صاف کرتے ہیں۔
+func cancelPendingFlows(client *whatsmeow.Client, v *events.Message) {
+	botID, chatID, senderID := convScope(client, v)
+	flows := CancelUserConversations(botID, chatID, senderID)
+	jobs := cancelUserJobs(botID, chatID, v.Info.Sender.ToNonAD().String())
+
+	if flows == 0 && jobs == 0 {
+		replyMessage(client, v, "ℹ️ Nothing to cancel.")
+		return
+	}
+	reportCancelled(client, v, flows, jobs)
+}
+
+func reportCancelled(client *whatsmeow.Client, v *events.Message, flows, jobs int) {
+	botID, chatID, senderID := convScope(client, v)
+	botLog(botID).Info("🛑 cancelled", "chat", redactID(chatID), "sender", redactID(senderID), "flows", flows, "jobs", jobs)
+	react(client, v.Info.Chat, v.Info.ID, "🛑")
+	replyMessage(client, v, fmt.Sprintf("╔════════════════╗\n║ 🛑 CANCELLED\n╠════════════════╣\n║ Pending menus: %d\n║ Jobs stopped: %d\n╚════════════════╝", flows, jobs))
+}
+
+// ⚙️ .cancel COMMAND
+func handleCancelCmd(c *CommandContext) {
+	cancelPendingFlows(c.Client, c.Msg)
+}
+
+// =========================================================
+// 💾 BOOT + CLEANUP
+// =========================================================
+
+// LoadConversations: ری سٹارٹ سے پہلے کے زندہ سیشن stateStore سے واپس لائیں
+func LoadConversations() {
+	if stateStore == nil {
+		return
+	}
+	count := 0
+	saved, err := stateStore.Scan(context.Background(), convKeyPrefix)
+	if err != nil {
+		fmt.Printf("⚠️ [CONV] Could not load conversations: %v\n", err)
+		return
+	}
+	for _, val := range saved {
+		c := &Conversation{}
+		if json.Unmarshal(val, c) != nil || c.Flow == FlowReply || c.expired() {
+			continue
+		}
+		convMutex.Lock()
+		flows, ok := conversations[c.scope()]
+		if !ok {
+			flows = make(map[Flow]*Conversation)
+			conversations[c.scope()] = flows
+		}
+		flows[c.Flow] = c
+		convMutex.Unlock()
+		count++
+	}
+	if count > 0 {
+		fmt.Printf("💬 [CONV] Restored %d active conversation(s) from state store\n", count)
+	}
+}
+
+// StartConversationJanitor: ختم شدہ سیشن میموری سے ہٹائیں (اسٹور خود TTL سے صاف کرتا ہے)
+func StartConversationJanitor() {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			convMutex.Lock()
+			for scope, flows := range conversations {
+				for f, c := range flows {
+					if c.expiredLocked() && c.Flow != FlowReply {
+						delete(flows, f)
+					}
+				}
+				if len(flows) == 0 {
+					delete(conversations, scope)
+				}
+			}
+			convMutex.Unlock()
+		}
+	}()
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,8 +28,6 @@ import (
 
 // اگر types.go میں TTState موجود ہے تو اسے یہاں سے ہٹا دیں
 
-var ttCache = make(map[string]TTState)
-
 // 💎 پریمیم کارڈ میکر (ہیلپر)
 func sendPremiumCard(client *whatsmeow.Client, v *events.Message, title, site, info string) {
 	card := fmt.Sprintf(`╔══════════════════════╗
@@ -56,6 +55,11 @@ type DLResult struct {
 const MaxWhatsAppSizeMB = 1500.0
 
 func downloadAndSend(client *whatsmeow.Client, v *events.Message, ytUrl, mode string, optionalFormat ...string) {
+	runDownload(client, v, ytUrl, mode, 0)
+}
+
+// runDownload: اصل ڈاؤنلوڈ (retries = ری سٹارٹ کے بعد کتنی بار دوبارہ چلی)
+func runDownload(client *whatsmeow.Client, v *events.Message, ytUrl, mode string, retries int) {
 	// 🧹 0️⃣ DISK CLEANUP (AUTO-WIPE)
 	// ہر بار کمانڈ چلنے پر یہ چیک کرے گا کہ کوئی بھی پرانی فائل (جو 5 منٹ سے زیادہ پرانی ہو) اسے اڑا دے۔
 	go func() {
@@ -75,76 +79,89 @@ func downloadAndSend(client *whatsmeow.Client, v *events.Message, ytUrl, mode st
 	react(client, v.Info.Chat, v.Info.ID, "⬇️")
 	statusMsgID := replyMessage(client, v, "⏳ *Downloading Media...* Please wait.")
 
-	// 2️⃣ ٹائٹل فیچ کریں
-	cmdTitle := exec.Command("yt-dlp", "--get-title", "--no-playlist", ytUrl)
-	titleOut, _ := cmdTitle.Output()
-
 	cleanTitle := "Media_File"
-	if len(titleOut) > 0 {
-		cleanTitle = strings.TrimSpace(string(titleOut))
-		// نام صاف کریں تاکہ ایرر نہ آئے
-		cleanTitle = strings.Map(func(r rune) rune {
-			if strings.ContainsRune(`/\?%*:|"<>`, r) {
-				return '-'
-			}
-			return r
-		}, cleanTitle)
-	}
-
 	tempFileName := fmt.Sprintf("temp_%d.mp4", time.Now().UnixNano())
-	
-	// 🔥 Playability Fix: زبردستی H.264 فارمیٹ (جو واٹس ایپ پر 100٪ چلتا ہے)
-	formatArg := "bestvideo[ext=mp4][vcodec^=avc]+bestaudio[ext=m4a]/best[ext=mp4]/best"
 	if mode == "audio" {
 		tempFileName = strings.Replace(tempFileName, ".mp4", ".mp3", 1)
-		formatArg = "bestaudio" // آڈیو کے لیے الگ
 	}
 
-	// ==========================================================
-	// 🛡️ SECURITY UPDATE: Bypass YouTube 403 Forbidden
-	// ==========================================================
-	args := []string{
-		"--no-playlist", 
-		"-f", formatArg, 
-		"--merge-output-format", "mp4",
-		"--force-ipv4", 
-		
-		// 👇 یہ لائنز یوٹیوب کو دھوکہ دینے کے لیے ہیں کہ یہ موبائل ایپ ہے
-		"--extractor-args", "youtube:player_client=android",
-		"--user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-		
-		"-o", tempFileName, 
-		ytUrl,
-	}
+	// 🏭 ڈاؤنلوڈ جاب پول میں چلے گا (ایک وقت میں محدود yt-dlp)
+	// 💾 ریکارڈ Redis میں جاتا ہے تاکہ ری سٹارٹ پر دوبارہ شروع ہو سکے
+	job := newMediaJob(client, v, JobDownload, ytUrl)
+	job.Record.URL = ytUrl
+	job.Record.Mode = mode
+	job.Record.Retries = retries
+	job.Record.Resumable = true
+	job.Record.TempFiles = []string{tempFileName}
+
+	err := RunJob(job, func(ctx context.Context) error {
+		// 2️⃣ ٹائٹل فیچ کریں
+		cmdTitle := exec.CommandContext(ctx, "yt-dlp", "--get-title", "--no-playlist", ytUrl)
+		titleOut, _ := cmdTitle.Output()
+
+		if len(titleOut) > 0 {
+			cleanTitle = strings.TrimSpace(string(titleOut))
+			// نام صاف کریں تاکہ ایرر نہ آئے
+			cleanTitle = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(`/\?%*:|"<>`, r) {
+					return '-'
+				}
+				return r
+			}, cleanTitle)
+		}
 
-	if mode == "audio" {
-		args = []string{
-			"--no-playlist", 
-			"-f", "bestaudio", 
-			"--extract-audio", 
-			"--audio-format", "mp3", 
-			
-			// 👇 آڈیو کے لیے بھی وہی سیکیورٹی پیچ
+		// 🔥 Playability Fix: زبردستی H.264 فارمیٹ (جو واٹس ایپ پر 100٪ چلتا ہے)
+		formatArg := "bestvideo[ext=mp4][vcodec^=avc]+bestaudio[ext=m4a]/best[ext=mp4]/best"
+
+		// ==========================================================
+		// 🛡️ SECURITY UPDATE: Bypass YouTube 403 Forbidden
+		// ==========================================================
+		args := []string{
+			"--no-playlist",
+			"-f", formatArg,
+			"--merge-output-format", "mp4",
 			"--force-ipv4",
+
+			// 👇 یہ لائنز یوٹیوب کو دھوکہ دینے کے لیے ہیں کہ یہ موبائل ایپ ہے
 			"--extractor-args", "youtube:player_client=android",
 			"--user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-			
-			"-o", tempFileName, 
+
+			"-o", tempFileName,
 			ytUrl,
 		}
-	}
 
-	// 3️⃣ ڈاؤنلوڈ شروع
-	fmt.Printf("🛠️ [CMD] Downloading: %s\n", cleanTitle)
-	cmd := exec.Command("yt-dlp", args...)
-	cmd.Stderr = os.Stderr 
-	err := cmd.Run()
+		if mode == "audio" {
+			args = []string{
+				"--no-playlist",
+				"-f", "bestaudio",
+				"--extract-audio",
+				"--audio-format", "mp3",
+
+				// 👇 آڈیو کے لیے بھی وہی سیکیورٹی پیچ
+				"--force-ipv4",
+				"--extractor-args", "youtube:player_client=android",
+				"--user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+
+				"-o", tempFileName,
+				ytUrl,
+			}
+		}
+
+		// 3️⃣ ڈاؤنلوڈ شروع
+		fmt.Printf("🛠️ [CMD] Downloading: %s\n", cleanTitle)
+		cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
 
 	if err != nil {
 		fmt.Println("❌ Download Error:", err)
+		// 🧹 ادھوری فائلیں صاف کریں
+		os.Remove(tempFileName)
+		os.Remove(tempFileName + ".part")
 		client.SendMessage(context.Background(), v.Info.Chat, &waE2E.Message{
 			ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-				Text:      proto.String("❌ Download Failed!"),
+				Text:      proto.String(jobErrorText(err, "❌ Download Failed!")),
 				ContextInfo: &waE2E.ContextInfo{StanzaID: proto.String(statusMsgID)},
 			},
 		})
@@ -173,14 +190,20 @@ func downloadAndSend(client *whatsmeow.Client, v *events.Message, ytUrl, mode st
 
 1️⃣ Send to WhatsApp
 2️⃣ Upload to Jazz Drive  ☁️
+0️⃣ Cancel
 
 _(Default: WhatsApp)_`, cleanTitle, fileSizeMB)
 
 	replyMessage(client, v, card)
 
 	// یوزر کا جواب
-	senderID := v.Info.Sender.ToNonAD().String()
-	userChoice, success := WaitForUserReply(senderID, 300*time.Second)
+	userChoice, waitErr := WaitForUserReply(client, v, 300*time.Second)
+	if errors.Is(waitErr, errFlowCancelled) {
+		// 🛑 یوزر نے .cancel کیا — فائل صاف کریں (تصدیق cancelPendingFlows بھیج چکا)
+		os.Remove(finalPath)
+		return
+	}
+	success := waitErr == nil
 
 	// ====================================================
 	// 🚦 DECISION LOGIC
@@ -194,8 +217,13 @@ _(Default: WhatsApp)_`, cleanTitle, fileSizeMB)
 		if fileSizeMB > MaxWhatsAppSizeMB && mode != "audio" {
 			replyMessage(client, v, fmt.Sprintf("⚠️ *File is large (%.2f GB).* Wait A few minutes", fileSizeMB/1024))
 			
-			// 🔥 1.5GB Split Function Call
-			parts, err := splitVideoSmart(finalPath, MaxWhatsAppSizeMB) 
+			// 🔥 1.5GB Split Function Call (اسپلٹ جاب پول میں)
+			var parts []string
+			err := runMediaJob(client, v, JobSplit, cleanTitle, func(ctx context.Context) error {
+				var splitErr error
+				parts, splitErr = splitVideoSmart(ctx, finalPath, MaxWhatsAppSizeMB)
+				return splitErr
+			})
 			if err != nil {
 				replyMessage(client, v, "❌ Error splitting. Sending original (might fail).")
 				uploadToWhatsApp(client, v, DLResult{Path: finalPath, Title: cleanTitle, Size: fileSize, Mime: mode}, mode)
@@ -228,8 +256,12 @@ _(Default: WhatsApp)_`, cleanTitle, fileSizeMB)
 		// 1. Ask for Number
 		replyMessage(client, v, "📱 *Enter Jazz Number (03XXXXXXXXX):*\n_(You have 2 mins)_")
 
-		phone, ok := WaitForUserReply(senderID, 120*time.Second)
-		if !ok || phone == "" {
+		phone, phoneErr := WaitForUserReply(client, v, 120*time.Second)
+		if errors.Is(phoneErr, errFlowCancelled) {
+			os.Remove(finalPath)
+			return
+		}
+		if phoneErr != nil || phone == "" {
 			replyMessage(client, v, "❌ Timeout. Sending to WhatsApp instead.")
 			uploadToWhatsApp(client, v, DLResult{Path: finalPath, Title: cleanTitle, Size: fileSize, Mime: mode}, mode)
 			os.Remove(finalPath)
@@ -246,8 +278,12 @@ _(Default: WhatsApp)_`, cleanTitle, fileSizeMB)
 			// 🔥 RETRY LOOP (2 Attempts)
 			otpVerified := false
 			for attempt := 1; attempt <= 2; attempt++ {
-				otp, ok := WaitForUserReply(senderID, 120*time.Second)
-				if !ok || otp == "" {
+				otp, otpErr := WaitForUserReply(client, v, 120*time.Second)
+				if errors.Is(otpErr, errFlowCancelled) {
+					os.Remove(finalPath)
+					return
+				}
+				if otpErr != nil || otp == "" {
 					break // Timeout will go to fallback
 				}
 
@@ -302,9 +338,9 @@ _(Default: WhatsApp)_`, cleanTitle, fileSizeMB)
 
 // 🔥 SMART SPLIT FUNCTION (Time-based calculation for playability)
 // یہ فنکشن فائل سائز کی بجائے ٹائم کیلکولیٹ کر کے کاٹے گا تاکہ ویڈیو پلے ہو سکے
-func splitVideoSmart(inputPath string, targetMB float64) ([]string, error) {
+func splitVideoSmart(ctx context.Context, inputPath string, targetMB float64) ([]string, error) {
 	// 1. ویڈیو کی کل Duration (Seconds) حاصل کریں
-	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", inputPath)
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", inputPath)
 	out, err := cmd.Output()
 	if err != nil { return nil, err }
 	
@@ -328,7 +364,7 @@ func splitVideoSmart(inputPath string, targetMB float64) ([]string, error) {
 	// -reset_timestamps 1: یہ بہت ضروری ہے تاکہ ہر پارٹ شروع سے پلے ہو (00:00 سے)
 	outputPattern := strings.Replace(inputPath, ".mp4", "_part%03d.mp4", 1)
 	
-	splitCmd := exec.Command("ffmpeg", 
+	splitCmd := exec.CommandContext(ctx, "ffmpeg", 
 		"-i", inputPath, 
 		"-c", "copy",          // Re-encode نہیں کریں گے (Fastest)
 		"-map", "0", 
@@ -450,14 +486,13 @@ func handleTikTok(client *whatsmeow.Client, v *events.Message, urlStr string) {
 	getJson(apiUrl, &r)
 
 	if r.Code == 0 {
-		// کیش میں ڈیٹا محفوظ کریں
-		sender := v.Info.Sender.ToNonAD().String() // ✅ بہتر جے آئی ڈی ہینڈلنگ
-		ttCache[sender] = TTState{
+		// سیشن میں ڈیٹا محفوظ کریں (2 منٹ)
+		StartConversation(client, v, FlowTTMenu, "", 2*time.Minute, TTState{
 			PlayURL: r.Data.Play, 
 			MusicURL: r.Data.Music, 
 			Title: r.Data.Title, 
 			Size: int64(r.Data.Size),
-		}
+		})
 
 		// 👑 پریمیم ورٹیکل مینیو
 		menuText := fmt.Sprintf("📝 *Title:* %s\n\n", r.Data.Title)
@@ -473,8 +508,6 @@ func handleTikTok(client *whatsmeow.Client, v *events.Message, urlStr string) {
 	}
 }
 
-// ❌ پرانی لائن (جو ۳ پیرامیٹرز لے رہی تھی):
-// func handleTikTokReply(client *whatsmeow.Client, v *events.Message, input string)
 func sendAudio(client *whatsmeow.Client, v *events.Message, audioURL string) {
 	// 1️⃣ آڈیو ڈاؤن لوڈ کرنا
 	resp, err := http.Get(audioURL)
@@ -508,30 +541,22 @@ func sendAudio(client *whatsmeow.Client, v *events.Message, audioURL string) {
 		},
 	})
 }
-// ✅ نئی اور صحیح لائن (جس میں senderID شامل ہے):
-// ✅ فنکشن کے ہیڈر میں پیرامیٹرز بالکل صحیح ہیں
-func handleTikTokReply(client *whatsmeow.Client, v *events.Message, input string, senderID string) {
-	// 1️⃣ کیش سے ڈیٹا نکالیں
-	state, exists := ttCache[senderID]
-	if !exists { return }
-
-	// 🛠️ فکس ۱: یہاں 'senderID :=' نہیں کرنا، کیونکہ وہ اوپر پیرامیٹر میں موجود ہے
-	// اگر دوبارہ نکالنا بھی ہو تو صرف '=' استعمال کریں (بغیر سیمی کولن کے)
-	senderID = v.Info.Sender.ToNonAD().String() 
-
-	input = strings.TrimSpace(input)
+// 🎵 TikTok 1/2/3 مینیو کا جواب (FlowTTMenu)
+func handleTikTokReply(client *whatsmeow.Client, v *events.Message, conv *Conversation, input string) bool {
+	var state TTState
+	if !conv.Decode(&state) {
+		EndConversation(conv)
+		return false
+	}
 
 	switch input {
 	case "1":
 		react(client, v.Info.Chat, v.Info.ID, "🎬")
 		sendVideo(client, v, state.PlayURL, "✅ *TikTok Video Generated*")
-		delete(ttCache, senderID) 
 
 	case "2":
 		react(client, v.Info.Chat, v.Info.ID, "🎵")
-		// 🛠️ فکس ۲: یہاں 'v' مسنگ تھا، اب ۳ پیرامیٹرز پورے کر دیے ہیں
-		sendAudio(client, v, state.MusicURL)  
-		delete(ttCache, senderID)
+		sendAudio(client, v, state.MusicURL)
 
 	case "3":
 		infoMsg := fmt.Sprintf("╔═══════════════════╗\n"+
@@ -541,8 +566,12 @@ func handleTikTokReply(client *whatsmeow.Client, v *events.Message, input string
 			"║ 📊 Size: %.2f MB\n"+
 			"╚═══════════════════╝", state.Title, float64(state.Size)/(1024*1024))
 		replyMessage(client, v, infoMsg)
-		delete(ttCache, senderID)
+
+	default:
+		return false // مینیو کا جواب نہیں
 	}
+	EndConversation(conv)
+	return true
 }
 
 func handleTwitter(client *whatsmeow.Client, v *events.Message, url string) {
@@ -818,11 +847,25 @@ func handleYTS(client *whatsmeow.Client, v *events.Message, query string) {
 
 	if err == nil {
 		fmt.Printf("✅ [YTS SENT] Menu sent with %d results.\n", count)
-		ytCache[resp.ID] = YTSession{Results: results, SenderID: v.Info.Sender.User, BotLID: myID}
-		go func() { time.Sleep(2 * time.Minute); delete(ytCache, resp.ID) }()
+		StartConversation(client, v, FlowYTSearch, resp.ID, 2*time.Minute, YTSession{Results: results, SenderID: v.Info.Sender.User, BotLID: myID})
 	}
 }
 
+// 📍 .yts نتائج میں سے انتخاب (FlowYTSearch)
+func handleYTSearchReply(client *whatsmeow.Client, v *events.Message, conv *Conversation, text string) bool {
+	var session YTSession
+	conv.Decode(&session)
+	EndConversation(conv)
+
+	if index, err := strconv.Atoi(text); err == nil && index > 0 && index <= len(session.Results) {
+		selected := session.Results[index-1]
+		go handleYTDownloadMenu(client, v, selected.Url)
+	} else {
+		replyMessage(client, v, "❌ غلط نمبر! براہ کرم لسٹ میں سے درست نمبر منتخب کریں۔")
+	}
+	return true
+}
+
 
 
 func handleYTDownloadMenu(client *whatsmeow.Client, v *events.Message, ytUrl string) {
@@ -849,20 +892,27 @@ func handleYTDownloadMenu(client *whatsmeow.Client, v *events.Message, ytUrl str
 	})
 
 	if err == nil {
-		// 💾 کیشے سیو کریں
-		ytDownloadCache[resp.ID] = YTState{
+		// 💾 سیشن سیو کریں (1 منٹ، اسی مینیو کے ریپلائی پر)
+		StartConversation(client, v, FlowYTFormat, resp.ID, time.Minute, YTState{
 			Url:      ytUrl,
 			BotLID:   myID,
 			SenderID: senderLID,
-		}
-		fmt.Printf("📂 [YT-MENU] Cached ID: %s for Bot: %s\n", resp.ID, myID)
-		
-		// 1 منٹ بعد صفائی
-		go func() {
-			time.Sleep(1 * time.Minute)
-			delete(ytDownloadCache, resp.ID)
-		}()
+		})
+		fmt.Printf("📂 [YT-MENU] Session bound to: %s for Bot: %s\n", resp.ID, myID)
+	}
+}
+
+// 🎬 کوالٹی سلیکٹر کا جواب (FlowYTFormat)
+func handleYTFormatReply(client *whatsmeow.Client, v *events.Message, conv *Conversation, text string) bool {
+	var state YTState
+	if !conv.Decode(&state) {
+		EndConversation(conv)
+		return false
 	}
+	EndConversation(conv)
+	// اگر یوزر نے 8 دبایا ہے تو وہ آڈیو ہے
+	go handleYTDownload(client, v, state.Url, text, text == "8")
+	return true
 }
 
 
@@ -939,17 +989,22 @@ func handleDirect(client *whatsmeow.Client, v *events.Message, link string) {
 
 	// 2. Python Script چلائیں
 	// یہ بالکل yt-dlp والی ٹیکنیک ہے
-	cmd := exec.Command("python3", "browser_dl.py", link)
-	
-	// آؤٹ پٹ پکڑیں
-	output, err := cmd.CombinedOutput()
+	var output []byte
+	err := runMediaJob(client, v, JobDownload, link, func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, "python3", "browser_dl.py", link)
+
+		// آؤٹ پٹ پکڑیں
+		var runErr error
+		output, runErr = cmd.CombinedOutput()
+		return runErr
+	})
 	result := strings.TrimSpace(string(output))
 
 	// 3. ایرر چیک کریں
 	if err != nil {
 		// اگر پائتھون فیل ہوا تو لاگز پرنٹ کریں
 		fmt.Println("❌ Python Error Logs:", result)
-		replyMessage(client, v, "❌ Failed to download file via engine.")
+		replyMessage(client, v, jobErrorText(err, "❌ Failed to download file via engine."))
 		return
 	}
 
@@ -12,6 +12,7 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// 🔐 ایڈمن چیک اب کمانڈ رجسٹری (commandAccess + .perm رولز) میں ہوتا ہے
 func handleKick(client *whatsmeow.Client, v *events.Message, args []string) {
 	groupAction(client, v, args, "remove")
 }
@@ -29,16 +30,6 @@ func handleAdd(client *whatsmeow.Client, v *events.Message, args []string) {
 		return
 	}
 
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-		msg := `╔════════════════╗
-║ ❌ DENIED
-╠════════════════
-║ 🔒 Admin Only
-╚════════════════`
-		replyMessage(client, v, msg)
-		return
-	}
-
 	if len(args) == 0 {
 		msg := `╔════════════════╗
 ║ ⚠️ INVALID
@@ -88,16 +79,6 @@ func handleTagAll(client *whatsmeow.Client, v *events.Message, args []string) {
 		return
 	}
 
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-		msg := `╔════════════════╗
-║ ❌ DENIED
-╠════════════════
-║ 🔒 Admin Only
-╚════════════════`
-		replyMessage(client, v, msg)
-		return
-	}
-
 	info, _ := client.GetGroupInfo(context.Background(), v.Info.Chat)
 	mentions := []string{}
 	out := "╔════════════════╗\n"
@@ -141,16 +122,6 @@ func handleHideTag(client *whatsmeow.Client, v *events.Message, args []string) {
 		return
 	}
 
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-		msg := `╔════════════════╗
-║ ❌ DENIED
-╠════════════════
-║ 🔒 Admin Only
-╚════════════════`
-		replyMessage(client, v, msg)
-		return
-	}
-
 	info, _ := client.GetGroupInfo(context.Background(), v.Info.Chat)
 	mentions := []string{}
 	text := strings.Join(args, " ")
@@ -186,16 +157,6 @@ func handleGroup(client *whatsmeow.Client, v *events.Message, args []string) {
 		return
 	}
 
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-		msg := `╔════════════════╗
-║ ❌ DENIED
-╠════════════════
-║ 🔒 Admin Only
-╚════════════════`
-		replyMessage(client, v, msg)
-		return
-	}
-
 	if len(args) == 0 {
 		msg := `╔════════════════╗
 ║ ⚙️ SETTINGS
@@ -278,16 +239,6 @@ func handleDelete(client *whatsmeow.Client, v *events.Message) {
 		return
 	}
 
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-		msg := `╔════════════════╗
-║ ❌ DENIED
-╠════════════════
-║ 🔒 Admin Only
-╚════════════════`
-		replyMessage(client, v, msg)
-		return
-	}
-
 	if v.Message.ExtendedTextMessage == nil {
 		msg := `╔════════════════╗
 ║ ⚠️ INVALID
@@ -328,16 +279,6 @@ func groupAction(client *whatsmeow.Client, v *events.Message, args []string, act
 		return
 	}
 
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) && !isOwner(client, v.Info.Sender) {
-		msg := `╔════════════════╗
-║ ❌ DENIED
-╠════════════════
-║ 🔒 Admin Only
-╚════════════════`
-		replyMessage(client, v, msg)
-		return
-	}
-
 	var targetJID types.JID
 	if len(args) > 0 {
 		num := strings.TrimSpace(args[0])
@@ -20,15 +20,14 @@ import (
 //	"google.golang.org/protobuf/proto"
 )
 
-// آپ کی Railway API کا لنک
-const JazzAPIUrl = "https://jazz-drive-production.up.railway.app/api"
+// آپ کی Railway API کا لنک: config میں apis.jazz_drive
 
 // --- Helper Functions for Jazz Drive ---
 
 // 1. Send OTP
 func jazzGenOTP(userID, phone string) bool {
 	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(fmt.Sprintf("%s?id=%s&gen-otp=%s", JazzAPIUrl, userID, phone))
+	resp, err := client.Get(fmt.Sprintf("%s?id=%s&gen-otp=%s", Config().APIs.JazzDrive, userID, phone))
 	if err != nil {
 		fmt.Println("API Error:", err)
 		return false
@@ -43,7 +42,7 @@ func jazzGenOTP(userID, phone string) bool {
 // 2. Verify OTP
 func jazzVerifyOTP(userID, otp string) bool {
 	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(fmt.Sprintf("%s?id=%s&verify-otp=%s", JazzAPIUrl, userID, otp))
+	resp, err := client.Get(fmt.Sprintf("%s?id=%s&verify-otp=%s", Config().APIs.JazzDrive, userID, otp))
 	if err != nil {
 		return false
 	}
@@ -79,7 +78,7 @@ func jazzUploadFile(userID, filePath string) (string, error) {
 	}
 	writer.Close()
 
-	req, _ := http.NewRequest("POST", fmt.Sprintf("%s?id=%s", JazzAPIUrl, userID), body)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("%s?id=%s", Config().APIs.JazzDrive, userID), body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// 1 Hour Timeout for Upload
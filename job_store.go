@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// =========================================================
+// 💾 PERSISTENT JOB QUEUE (stateStore)
+// ہر میڈیا جاب کا ریکارڈ stateStore میں رہتا ہے تاکہ ری سٹارٹ کے بعد
+// ادھوری ڈاؤنلوڈز دوبارہ چلائی جا سکیں یا یوزر کو صاف بتا دیا جائے۔
+// =========================================================
+
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+
+	jobKeyPrefix   = "job:"
+	jobRecordTTL   = 24 * time.Hour
+	jobActiveTTL   = 6 * time.Hour // queued/running — انسٹینس مر جائے اور واپس نہ آئے تو ریکارڈ خود ختم
+	jobMaxRetries  = 2
+	jobResumeGrace = 2 * time.Minute // بوٹ کنیکٹ ہونے کا انتظار
+)
+
+// JobRecord: stateStore میں محفوظ جاب کی حالت
+type JobRecord struct {
+	ID        string   `json:"id"`
+	Type      JobType  `json:"type"`
+	Status    string   `json:"status"`
+	BotID     string   `json:"bot_id"`
+	ChatID    string   `json:"chat_id"`
+	SenderID  string   `json:"sender_id"`
+	MessageID string   `json:"message_id"`
+	Body      string   `json:"body,omitempty"` // اصل میسج (ری پلائی کوٹ کرنے کے لیے)
+	URL       string   `json:"url,omitempty"`
+	Mode      string   `json:"mode,omitempty"`
+	Resumable bool     `json:"resumable"`
+	Retries   int      `json:"retries"`
+	TempFiles []string `json:"temp_files,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Instance  string   `json:"instance,omitempty"` // آخری بار کس انسٹینس نے لکھا (کلسٹر ریکوری)
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+// saveJobRecord حالت بدل کر stateStore میں لکھتا ہے
+func saveJobRecord(r *JobRecord, status string, jobErr error) {
+	if r == nil || stateStore == nil {
+		return
+	}
+	r.Status = status
+	r.Instance = InstanceID()
+	r.UpdatedAt = time.Now().Unix()
+	if r.CreatedAt == 0 {
+		r.CreatedAt = r.UpdatedAt
+	}
+	if jobErr != nil {
+		r.Error = jobErr.Error()
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	// ادھوری جابز لمبی TTL کے ساتھ (ری سٹارٹ پر یہی اٹھائی جاتی ہیں)؛ جاب ٹائم آؤٹ سے کہیں زیادہ
+	ttl := jobActiveTTL
+	if status == JobDone || status == JobFailed {
+		ttl = jobRecordTTL
+	}
+	if err := stateStore.Put(context.Background(), jobKeyPrefix+r.ID, data, ttl); err != nil {
+		botLog(r.BotID).Warn("⚠️ job record save failed", "job", r.ID, "err", err)
+	}
+}
+
+func loadJobRecord(id string) (*JobRecord, error) {
+	val, err := stateStore.Get(context.Background(), jobKeyPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	var r JobRecord
+	if err := json.Unmarshal(val, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// newJobRecord میسج سے ریکارڈ بناتا ہے
+func newJobRecord(client *whatsmeow.Client, v *events.Message, t JobType) *JobRecord {
+	return &JobRecord{
+		Type:      t,
+		BotID:     getCleanID(client.Store.ID.User),
+		ChatID:    v.Info.Chat.String(),
+		SenderID:  v.Info.Sender.ToNonAD().String(),
+		MessageID: v.Info.ID,
+		Body:      getText(v.Message),
+	}
+}
+
+// =========================================================
+// 🔁 BOOT RECOVERY
+// =========================================================
+
+// ResumePersistedJobs پچھلے رن کی ادھوری جابز اٹھاتا ہے (StartAllBots سے)
+func ResumePersistedJobs() {
+	if stateStore == nil {
+		return
+	}
+
+	saved, err := stateStore.Scan(context.Background(), jobKeyPrefix)
+	if err != nil {
+		return
+	}
+	var pending []*JobRecord
+	for _, val := range saved {
+		var r JobRecord
+		if json.Unmarshal(val, &r) != nil || (r.Status != JobQueued && r.Status != JobRunning) {
+			continue
+		}
+		// 🧩 کسی اور زندہ انسٹینس کی جاب ابھی وہیں چل رہی ہے
+		if clusterEnabled() && r.Instance != "" && r.Instance != InstanceID() && instanceAlive(r.Instance) {
+			continue
+		}
+		pending = append(pending, &r)
+	}
+	if len(pending) == 0 {
+		return
+	}
+	logger.Info("🔁 unfinished jobs from last run", "count", len(pending))
+
+	for _, r := range pending {
+		go recoverJob(r)
+	}
+}
+
+// cleanOrphanTempFiles: بوٹس کنیکٹ ہونے سے پہلے کوئی جاب نہیں چل رہی، اس لیے تمام temp_ فائلیں یتیم ہیں
+func cleanOrphanTempFiles() {
+	files, _ := filepath.Glob("temp_*")
+	for _, f := range files {
+		if err := os.RemoveAll(f); err == nil {
+			logger.Info("🧹 removed orphaned temp file", "file", f)
+		}
+	}
+}
+
+func recoverJob(r *JobRecord) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			botLog(r.BotID).Error("⚠️ job recovery crashed", "job", r.ID, "panic", fmt.Sprint(rec))
+		}
+	}()
+
+	// 🧹 پچھلے رن کی ادھوری فائلیں ہٹائیں
+	for _, f := range r.TempFiles {
+		os.Remove(f)
+		os.Remove(f + ".part")
+	}
+
+	client := waitForBotClient(r.BotID, jobResumeGrace)
+	if client == nil && clusterEnabled() {
+		if host := BotHosts([]string{r.BotID})[r.BotID]; host != "" && host != InstanceID() {
+			return // 🧩 دوسرے انسٹینس کا بوٹ — وہی سنبھالے گا
+		}
+	}
+	if client == nil {
+		botLog(r.BotID).Warn("🔁 job dropped: bot not online", "job", r.ID)
+		saveJobRecord(r, JobFailed, fmt.Errorf("bot offline after restart"))
+		return
+	}
+
+	v, err := rebuildJobMessage(r)
+	if err != nil {
+		saveJobRecord(r, JobFailed, err)
+		return
+	}
+
+	if !r.Resumable || r.Retries >= jobMaxRetries {
+		saveJobRecord(r, JobFailed, fmt.Errorf("interrupted by restart"))
+		replyMessage(client, v, "⚠️ *Server restarted* while your request was being processed.\nPlease send it again. 🙏")
+		return
+	}
+
+	// ✅ دوبارہ چلائیں (نیا ریکارڈ، پرانا بند)
+	saveJobRecord(r, JobFailed, fmt.Errorf("interrupted by restart, resumed"))
+	botLog(r.BotID).Info("🔁 resuming job", "job", r.ID, "mode", r.Mode, "sender", redactID(r.SenderID), "retry", r.Retries+1)
+	replyMessage(client, v, fmt.Sprintf("🔁 *Server restarted* — resuming your download (retry %d/%d)...", r.Retries+1, jobMaxRetries))
+	runDownload(client, v, r.URL, r.Mode, r.Retries+1)
+}
+
+// waitForBotClient بوٹ کے کنیکٹ ہونے تک رکتا ہے
+func waitForBotClient(botID string, timeout time.Duration) *whatsmeow.Client {
+	deadline := time.Now().Add(timeout)
+	for {
+		clientsMutex.RLock()
+		c, ok := activeClients[botID]
+		clientsMutex.RUnlock()
+		if ok && c != nil && c.IsConnected() && c.Store.ID != nil {
+			return c
+		}
+		if time.Now().After(deadline) {
+			return nil
+		}
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// rebuildJobMessage محفوظ ریکارڈ سے ریپلائی کے لیے میسج بناتا ہے
+func rebuildJobMessage(r *JobRecord) (*events.Message, error) {
+	chat, err := types.ParseJID(r.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := types.ParseJID(r.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &events.Message{
+		Message: &waProto.Message{Conversation: proto.String(r.Body)},
+	}
+	v.Info.Chat = chat
+	v.Info.Sender = sender
+	v.Info.ID = r.MessageID
+	v.Info.IsGroup = chat.Server == types.GroupServer
+	v.Info.Timestamp = time.Unix(r.CreatedAt, 0)
+	return v, nil
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// =========================================================
+// 🏭 HEAVY JOB POOL (yt-dlp / ffmpeg)
+// ہر جاب ٹائپ کی اپنی حد ہے، باقی لائن میں انتظار کرتے ہیں۔
+// ہر جاب کا اپنا context ہے جو ٹائم آؤٹ یا کینسل پر پروسیس مار دیتا ہے
+// (exec.CommandContext کے ذریعے)۔
+// =========================================================
+
+type JobType string
+
+const (
+	JobDownload   JobType = "download"   // yt-dlp, megadl, browser_dl
+	JobConvert    JobType = "convert"    // sticker / gif / image ffmpeg
+	JobSplit      JobType = "split"      // بڑی ویڈیو کے ٹکڑے
+	JobAutoStatus JobType = "autostatus" // TikTok auto status batch
+)
+
+// allJobTypes: ہر قسم کا اپنا پول — حد اور ٹائم آؤٹ کنفیگ کے jobs سیکشن سے
+// (ENV: JOB_CONCURRENCY_<TYPE>, JOB_TIMEOUT_<TYPE> سیکنڈز میں)
+var allJobTypes = []JobType{JobDownload, JobConvert, JobSplit, JobAutoStatus}
+
+var (
+	errJobCancelled = errors.New("job cancelled")
+	errJobTimeout   = errors.New("job timed out")
+)
+
+// Job لائن میں لگا یا چلتا ہوا ایک کام
+type Job struct {
+	ID       string
+	Type     JobType
+	Label    string
+	BotID    string
+	ChatID   string
+	SenderID string
+	MenuID   string // جس بوٹ مینیو کے جواب میں جاب بنی ("0" اسی مینیو پر اسے روکتا ہے)
+	Created  time.Time
+	Started  time.Time // خالی = ابھی لائن میں ہے؛ دوسرے goroutine سے jobSnapshot کے ذریعے پڑھیں
+
+	Record *JobRecord // Redis میں محفوظ حالت (nil = محفوظ نہیں کرنا)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	notify func(pos int) // لائن میں جگہ بتانے کے لیے (nil ہو سکتا ہے)
+}
+
+type jobPool struct {
+	Type    JobType
+	Slots   chan struct{}
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	waiting []*Job
+	running map[string]*Job
+}
+
+var (
+	jobPools   = make(map[JobType]*jobPool)
+	jobPoolsMu sync.Mutex
+)
+
+func getJobPool(t JobType) *jobPool {
+	jobPoolsMu.Lock()
+	defer jobPoolsMu.Unlock()
+
+	if p, ok := jobPools[t]; ok {
+		return p
+	}
+
+	n, timeout := Config().Jobs.Pool(t)
+	p := &jobPool{Type: t, Slots: make(chan struct{}, n), Timeout: timeout, running: make(map[string]*Job)}
+	jobPools[t] = p
+	fmt.Printf("🏭 [JOBS] Pool '%s' ready (workers: %d, timeout: %s)\n", t, n, timeout)
+	return p
+}
+
+// newJobID: 128-bit رینڈم (hex) — کلسٹر کے انسٹینسز اور ری سٹارٹ کے بعد بھی نہیں ٹکراتا
+// (جاب، پیئرنگ اور send-queue IDs سب یہی)
+func newJobID() string {
+	return randomHex(16)
+}
+
+// RunJob جاب کو لائن میں لگاتا ہے، باری آنے پر چلاتا ہے اور ختم ہونے تک رکتا ہے۔
+// fn کو ملنے والا ctx ٹائم آؤٹ یا کینسل پر ختم ہو جاتا ہے۔
+func RunJob(j *Job, fn func(ctx context.Context) error) error {
+	p := getJobPool(j.Type)
+
+	if j.ID == "" {
+		j.ID = newJobID()
+	}
+	j.Created = time.Now()
+	j.ctx, j.cancel = context.WithCancel(context.Background())
+	defer j.cancel()
+
+	if j.Record != nil {
+		j.Record.ID = j.ID
+		saveJobRecord(j.Record, JobQueued, nil)
+	}
+
+	// 1. لائن میں لگیں
+	p.mu.Lock()
+	p.waiting = append(p.waiting, j)
+	pos := len(p.waiting)
+	busy := len(p.Slots) == cap(p.Slots)
+	p.mu.Unlock()
+
+	if busy && j.notify != nil {
+		j.notify(pos)
+	}
+
+	// 2. باری کا انتظار (یا کینسل)
+	select {
+	case p.Slots <- struct{}{}:
+	case <-j.ctx.Done():
+		p.removeWaiting(j)
+		mJobFailures.Inc(string(j.Type), "cancelled")
+		saveJobRecord(j.Record, JobFailed, errJobCancelled)
+		return errJobCancelled
+	}
+	defer func() { <-p.Slots }()
+
+	p.mu.Lock()
+	p.removeWaitingLocked(j)
+	j.Started = time.Now()
+	p.running[j.ID] = j
+	p.mu.Unlock()
+	saveJobRecord(j.Record, JobRunning, nil)
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.running, j.ID)
+		p.mu.Unlock()
+	}()
+
+	// 3. ٹائم آؤٹ کے ساتھ چلائیں
+	runCtx, cancel := context.WithTimeout(j.ctx, p.Timeout)
+	defer cancel()
+
+	jlog := botLog(j.BotID).With("job", j.ID, "job_type", string(j.Type), "chat", redactID(j.ChatID))
+	jlog.Info("🏭 job start", "label", j.Label)
+	err := fn(runCtx)
+
+	switch {
+	case errors.Is(j.ctx.Err(), context.Canceled):
+		err = errJobCancelled
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		err = errJobTimeout
+	}
+
+	mJobDuration.Since(j.Started, string(j.Type))
+	if err != nil {
+		mJobFailures.Inc(string(j.Type), jobFailReason(err))
+		jlog.Warn("🏭 job failed", "err", err, "took", time.Since(j.Started).Round(time.Second))
+		saveJobRecord(j.Record, JobFailed, err)
+	} else {
+		jlog.Info("🏭 job done", "took", time.Since(j.Started).Round(time.Second))
+		saveJobRecord(j.Record, JobDone, nil)
+	}
+	return err
+}
+
+// jobFailReason: /metrics لیبل (کم کارڈینیلٹی)
+func jobFailReason(err error) string {
+	switch {
+	case errors.Is(err, errJobCancelled):
+		return "cancelled"
+	case errors.Is(err, errJobTimeout):
+		return "timeout"
+	}
+	return "error"
+}
+
+func (p *jobPool) removeWaiting(j *Job) {
+	p.mu.Lock()
+	p.removeWaitingLocked(j)
+	p.mu.Unlock()
+}
+
+func (p *jobPool) removeWaitingLocked(j *Job) {
+	for i, w := range p.waiting {
+		if w == j {
+			p.waiting = append(p.waiting[:i], p.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// newMediaJob: میسج سے جاب بناتا ہے (Redis ریکارڈ کے ساتھ) اور لائن میں ہونے پر یوزر کو بتاتا ہے
+func newMediaJob(client *whatsmeow.Client, v *events.Message, t JobType, label string) *Job {
+	return &Job{
+		Type:     t,
+		Label:    label,
+		BotID:    getCleanID(client.Store.ID.User),
+		ChatID:   v.Info.Chat.String(),
+		SenderID: v.Info.Sender.ToNonAD().String(),
+		MenuID:   v.Message.GetExtendedTextMessage().GetContextInfo().GetStanzaID(),
+		Record:   newJobRecord(client, v, t),
+		notify: func(pos int) {
+			react(client, v.Info.Chat, v.Info.ID, "⏳")
+			replyMessage(client, v, fmt.Sprintf("⏳ *Server busy!* You are *#%d* in the %s queue.\n_Send .jobs cancel to leave the queue._", pos, t))
+		},
+	}
+}
+
+// runMediaJob: newMediaJob + RunJob
+func runMediaJob(client *whatsmeow.Client, v *events.Message, t JobType, label string, fn func(ctx context.Context) error) error {
+	return RunJob(newMediaJob(client, v, t, label), fn)
+}
+
+// jobErrorText: جاب کی ناکامی کا یوزر فرینڈلی پیغام
+func jobErrorText(err error, fallback string) string {
+	switch {
+	case errors.Is(err, errJobCancelled):
+		return "🛑 *Cancelled.*"
+	case errors.Is(err, errJobTimeout):
+		return "⌛ *Timed out.* The job took too long and was stopped."
+	}
+	return fallback
+}
+
+// userJobs: کسی یوزر کی تمام جابز (لائن + چلتی ہوئی)
+func userJobs(botID, chatID, senderID string) []*Job {
+	jobPoolsMu.Lock()
+	pools := make([]*jobPool, 0, len(jobPools))
+	for _, p := range jobPools {
+		pools = append(pools, p)
+	}
+	jobPoolsMu.Unlock()
+
+	var out []*Job
+	for _, p := range pools {
+		p.mu.Lock()
+		for _, j := range p.waiting {
+			if j.BotID == botID && j.ChatID == chatID && j.SenderID == senderID {
+				out = append(out, j)
+			}
+		}
+		for _, j := range p.running {
+			if j.BotID == botID && j.ChatID == chatID && j.SenderID == senderID {
+				out = append(out, j)
+			}
+		}
+		p.mu.Unlock()
+	}
+	return out
+}
+
+// cancelUserJobs یوزر کی سب جابز کینسل کرتا ہے، تعداد واپس کرتا ہے
+func cancelUserJobs(botID, chatID, senderID string) int {
+	jobs := userJobs(botID, chatID, senderID)
+	for _, j := range jobs {
+		j.cancel()
+	}
+	return len(jobs)
+}
+
+// cancelMenuJobs: صرف اس مینیو سے بنی جابز، تعداد واپس
+func cancelMenuJobs(botID, chatID, senderID, menuID string) int {
+	if menuID == "" {
+		return 0
+	}
+	n := 0
+	for _, j := range userJobs(botID, chatID, senderID) {
+		if j.MenuID == menuID {
+			j.cancel()
+			n++
+		}
+	}
+	return n
+}
+
+// jobSnapshot: شروع ہونے کا وقت اور لائن میں جگہ (0 = چل رہی ہے) — دونوں p.mu کے اندر
+func jobSnapshot(j *Job) (started time.Time, pos int) {
+	p := getJobPool(j.Type)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, w := range p.waiting {
+		if w == j {
+			return j.Started, i + 1
+		}
+	}
+	return j.Started, 0
+}
+
+// ⚙️ .jobs COMMAND
+func handleJobsCmd(c *CommandContext) {
+	if len(c.Args) > 0 && strings.ToLower(c.Args[0]) == "cancel" {
+		n := cancelUserJobs(c.BotID, c.ChatID, c.SenderID)
+		if n == 0 {
+			replyMessage(c.Client, c.Msg, "ℹ️ You have no running or queued jobs.")
+			return
+		}
+		replyMessage(c.Client, c.Msg, fmt.Sprintf("🛑 Cancelled *%d* job(s).", n))
+		return
+	}
+
+	jobs := userJobs(c.BotID, c.ChatID, c.SenderID)
+	if len(jobs) == 0 {
+		replyMessage(c.Client, c.Msg, "ℹ️ You have no running or queued jobs.")
+		return
+	}
+
+	out := "╔════════════════╗\n║ 🏭 YOUR JOBS\n╠════════════════╣\n"
+	for _, j := range jobs {
+		started, pos := jobSnapshot(j)
+		state := "⚙️ running " + time.Since(started).Round(time.Second).String()
+		if pos > 0 {
+			state = fmt.Sprintf("⏳ queued #%d", pos)
+		}
+		out += fmt.Sprintf("║ %s | %s\n║   %s\n", j.ID, j.Type, state)
+	}
+	out += "╚════════════════╝\n_" + c.Prefix + "jobs cancel to stop them_"
+	replyMessage(c.Client, c.Msg, out)
+}
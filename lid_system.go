@@ -82,7 +82,7 @@ func getSenderPhoneNumber(sender types.JID) string {
 func runLIDExtractor() error {
 	fmt.Println("\n╔═══════════════════════════════════════╗")
 	fmt.Println("║   🔍 RUNNING LID EXTRACTOR            ║")
-	fmt.Println("╚═══════════════════════════════════════╝\n")
+	fmt.Print("╚═══════════════════════════════════════╝\n\n")
 
 	// Check if Node.js is available
 	_, err := exec.LookPath("node")
@@ -164,48 +164,74 @@ func loadLIDData() error {
 }
 
 // ════════════════════════════════════════════════════════════════
-// 💾 REDIS INTEGRATION (REPLACED MONGODB)
+// 💾 STATE STORE INTEGRATION (storage.go — Redis / Postgres / memory)
 // ════════════════════════════════════════════════════════════════
 
-// Save LID to Redis
-func saveLIDToRedis(botInfo BotLIDInfo) error {
-	if rdb == nil {
-		return fmt.Errorf("redis not connected")
+const (
+	lidKeyPrefix  = "bot_lid:"
+	legacyLIDHash = "bot_lids_store" // پرانا Redis ہیش (ایک بار منتقل)
+)
+
+func lidStateKey(phone string) string { return lidKeyPrefix + phone }
+
+// Save LID to the state store
+func saveLID(botInfo BotLIDInfo) error {
+	if stateStore == nil {
+		return fmt.Errorf("state store not ready")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// ڈیٹا کو JSON میں بدلیں تاکہ ریڈیس میں محفوظ ہو سکے
+	// ڈیٹا کو JSON میں بدلیں تاکہ اسٹور میں محفوظ ہو سکے
 	botInfo.LastUpdated = time.Now()
 	jsonData, err := json.Marshal(botInfo)
 	if err != nil {
 		return fmt.Errorf("marshal failed: %v", err)
 	}
 
-	// ریڈیس ہیش (Hash) استعمال کریں تاکہ تمام LIDs ایک جگہ رہیں
-	err = rdb.HSet(ctx, "bot_lids_store", botInfo.Phone, jsonData).Err()
-	if err != nil {
-		return fmt.Errorf("redis hset failed: %v", err)
+	if err := stateStore.Put(ctx, lidStateKey(botInfo.Phone), jsonData, 0); err != nil {
+		return fmt.Errorf("state put failed: %v", err)
 	}
 
-	fmt.Printf("✅ Saved to Redis: %s → %s\n", botInfo.Phone, botInfo.LID)
+	fmt.Printf("✅ Saved LID: %s → %s\n", botInfo.Phone, botInfo.LID)
 	return nil
 }
 
-// Load all LIDs from Redis
-func loadLIDsFromRedis() error {
+// migrateLegacyLIDs: پرانے "bot_lids_store" ہیش سے الگ کیز میں (ایک بار)
+func migrateLegacyLIDs(ctx context.Context) {
 	if rdb == nil {
-		return fmt.Errorf("redis not connected")
+		return
+	}
+	data, err := rdb.HGetAll(ctx, legacyLIDHash).Result()
+	if err != nil || len(data) == 0 {
+		return
+	}
+	moved := 0
+	for phone, val := range data {
+		if stateStore.Put(ctx, lidStateKey(phone), []byte(val), 0) == nil {
+			moved++
+		}
+	}
+	if moved == len(data) {
+		rdb.Del(ctx, legacyLIDHash)
+	}
+	fmt.Printf("📦 Migrated %d LID(s) from legacy Redis hash\n", moved)
+}
+
+// Load all LIDs from the state store
+func loadStoredLIDs() error {
+	if stateStore == nil {
+		return fmt.Errorf("state store not ready")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// ریڈیس ہیش سے تمام ڈیٹا نکالیں
-	data, err := rdb.HGetAll(ctx, "bot_lids_store").Result()
+	migrateLegacyLIDs(ctx)
+	data, err := stateStore.Scan(ctx, lidKeyPrefix)
 	if err != nil {
-		return fmt.Errorf("redis hgetall failed: %v", err)
+		return fmt.Errorf("state scan failed: %v", err)
 	}
 
 	lidCacheMutex.Lock()
@@ -214,7 +240,7 @@ func loadLIDsFromRedis() error {
 	count := 0
 	for _, val := range data {
 		var botInfo BotLIDInfo
-		if err := json.Unmarshal([]byte(val), &botInfo); err != nil {
+		if err := json.Unmarshal(val, &botInfo); err != nil {
 			continue
 		}
 		lidCache[botInfo.Phone] = botInfo.LID
@@ -222,14 +248,14 @@ func loadLIDsFromRedis() error {
 	}
 
 	if count > 0 {
-		fmt.Printf("✅ Loaded %d LID(s) from Redis\n", count)
+		fmt.Printf("✅ Loaded %d LID(s) from state store\n", count)
 	}
 
 	return nil
 }
 
-// Sync LID data to Redis
-func syncLIDsToRedis() error {
+// Sync LID data to the state store
+func syncLIDsToStore() error {
 	// Load from JSON first
 	data, err := os.ReadFile(lidDataFile)
 	if err != nil {
@@ -241,10 +267,10 @@ func syncLIDsToRedis() error {
 		return err
 	}
 
-	// Save each to Redis
+	// Save each to the store
 	for _, botInfo := range lidDB.Bots {
-		if err := saveLIDToRedis(botInfo); err != nil {
-			fmt.Printf("⚠️ Failed to sync %s: %v\n", botInfo.Phone, err)
+		if err := saveLID(botInfo); err != nil {
+			logger.Warn("⚠️ LID sync failed", "bot", redactID(botInfo.Phone), "err", err)
 		}
 	}
 
@@ -278,7 +304,7 @@ func isOwnerByLID(client *whatsmeow.Client, sender types.JID) bool {
 	// Get bot's LID from cache
 	botLID := getLIDForPhone(botPhone)
 	if botLID == "" {
-		fmt.Printf("⚠️ No LID found for bot: %s\n", botPhone)
+		logger.Warn("⚠️ no LID found for bot", "bot", redactID(botPhone))
 		return false
 	}
 
@@ -291,13 +317,11 @@ func isOwnerByLID(client *whatsmeow.Client, sender types.JID) bool {
 	// Compare: sender's phone should match bot's LID
 	isMatch := (senderPhone == botLID)
 
-	fmt.Printf("\n🔐 OWNER VERIFICATION\n")
-	fmt.Printf("═══════════════════════════\n")
-	fmt.Printf("📱 Bot Phone: %s\n", botPhone)
-	fmt.Printf("🆔 Bot LID: %s\n", botLID)
-	fmt.Printf("👤 Sender: %s\n", senderPhone)
-	fmt.Printf("✅ Match: %v\n", isMatch)
-	fmt.Printf("═══════════════════════════\n\n")
+	logger.Debug("🔐 owner verification",
+		"bot_phone", redactID(botPhone),
+		"bot_lid", redactID(botLID),
+		"sender", redactID(senderPhone),
+		"match", isMatch)
 
 	return isMatch
 }
@@ -343,12 +367,12 @@ func sendOwnerStatus(client *whatsmeow.Client, v *events.Message) {
 func InitLIDSystem() {
 	fmt.Println("\n╔═══════════════════════════════════════╗")
 	fmt.Println("║   🔐 LID SYSTEM INITIALIZING          ║")
-	fmt.Println("╚═══════════════════════════════════════╝\n")
+	fmt.Print("╚═══════════════════════════════════════╝\n\n")
 
-	// Step 1: Try to load from Redis first
-	fmt.Println("📊 Checking Redis for existing LIDs...")
-	if err := loadLIDsFromRedis(); err != nil {
-		fmt.Printf("⚠️ Redis load failed: %v\n", err)
+	// Step 1: Try to load from the state store first
+	fmt.Println("📊 Checking state store for existing LIDs...")
+	if err := loadStoredLIDs(); err != nil {
+		fmt.Printf("⚠️ LID load failed: %v\n", err)
 	}
 
 	// Step 2: Run Node.js extractor
@@ -363,10 +387,10 @@ func InitLIDSystem() {
 		fmt.Printf("⚠️ Load error: %v\n", err)
 	}
 
-	// Step 4: Sync to Redis
-	if rdb != nil {
-		fmt.Println("💾 Syncing to Redis...")
-		if err := syncLIDsToRedis(); err != nil {
+	// Step 4: Sync to the state store
+	if stateStore != nil {
+		fmt.Println("💾 Syncing LIDs to state store...")
+		if err := syncLIDsToStore(); err != nil {
 			fmt.Printf("⚠️ Sync error: %v\n", err)
 		}
 	}
@@ -382,7 +406,7 @@ func InitLIDSystem() {
 	} else {
 		fmt.Println("║   ⚠️ NO LIDS FOUND (First run?)      ║")
 	}
-	fmt.Println("╚═══════════════════════════════════════╝\n")
+	fmt.Print("╚═══════════════════════════════════════╝\n\n")
 
 	// Display instructions if no LIDs
 	if count == 0 {
@@ -415,9 +439,9 @@ func OnNewPairing(client *whatsmeow.Client) {
 		return
 	}
 	
-	// Sync to Redis
-	if rdb != nil {
-		syncLIDsToRedis()
+	// Sync to the state store
+	if stateStore != nil {
+		syncLIDsToStore()
 	}
 	
 	botPhone := getBotPhoneNumber(client)
@@ -432,35 +456,9 @@ func OnNewPairing(client *whatsmeow.Client) {
 // 🔧 PERMISSION SYSTEM
 // ════════════════════════════════════════════════════════════════
 
-// Updated permission check using LID
+// Updated permission check using LID (اب رجسٹری والے canExecute پر چلتا ہے)
 func canExecuteCommand(client *whatsmeow.Client, v *events.Message, cmd string) bool {
-	// 1. Owner always has access
-	if isOwner(client, v.Info.Sender) {
-		return true
-	}
-
-	// 2. Private chats - allow all
-	if !v.Info.IsGroup {
-		return true
-	}
-
-	// 3. ✅ FIX: Bot ID نکالیں (کیونکہ سیٹنگز اسی کی بنیاد پر ملیں گی)
-	rawBotID := client.Store.ID.User
-	botID := getCleanID(rawBotID)
-
-	// 4. ✅ FIX: Settings منگواتے وقت botID پاس کریں
-	s := getGroupSettings(botID, v.Info.Chat.String())
-
-	// 5. Group Mode Checks
-	if s.Mode == "private" {
-		return false
-	}
-
-	if s.Mode == "admin" {
-		return isAdmin(client, v.Info.Chat, v.Info.Sender)
-	}
-
-	return true // public mode
+	return canExecute(client, v, cmd)
 }
 
 // Check if user is group admin
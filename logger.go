@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// =========================================================
+// 📝 STRUCTURED LOGGING (slog)
+// لیول اور فارمیٹ config (log.*) سے۔ ہر لائن میں bot / chat / cmd / job فیلڈز۔
+// redact آن ہو تو نمبر آدھے چھپ جاتے ہیں اور میسج کا متن لاگ میں نہیں آتا۔
+// =========================================================
+
+var (
+	logger   = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logLevel = new(slog.LevelVar)
+)
+
+func parseLogLevel(s string) (slog.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "", "info":
+		return slog.LevelInfo, true
+	case "warn", "warning":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	}
+	return slog.LevelInfo, false
+}
+
+// InitLogger: کنفیگ لوڈ ہونے کے بعد (فارمیٹ صرف یہاں، لیول ری لوڈ پر بھی)
+func InitLogger() {
+	applyLogLevel()
+	opts := &slog.HandlerOptions{Level: logLevel}
+	if strings.ToLower(Config().Log.Format) == "json" {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	} else {
+		logger = slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	slog.SetDefault(logger)
+}
+
+func applyLogLevel() {
+	lvl, _ := parseLogLevel(Config().Log.Level)
+	logLevel.Set(lvl)
+}
+
+func debugEnabled() bool {
+	return logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// redactID: 923001234567 → 92******4567 (redact آف ہو تو ویسا ہی)
+func redactID(s string) string {
+	if !Config().Log.Redact || s == "" {
+		return s
+	}
+	user, server, hasServer := strings.Cut(s, "@")
+	if len(user) > 6 {
+		user = user[:2] + strings.Repeat("*", len(user)-6) + user[len(user)-4:]
+	}
+	if hasServer {
+		return user + "@" + server
+	}
+	return user
+}
+
+// redactText: میسج کا متن (redact آن ہو تو صرف لمبائی)
+func redactText(s string) string {
+	if !Config().Log.Redact {
+		return s
+	}
+	return fmt.Sprintf("[redacted %d chars]", len([]rune(s)))
+}
+
+// botLog: کسی بوٹ کا لاگر
+func botLog(botID string) *slog.Logger {
+	return logger.With("bot", botID)
+}
+
+// msgLog: آنے والے میسج کا لاگر (bot + chat + sender)
+func msgLog(client *whatsmeow.Client, v *events.Message) *slog.Logger {
+	botID := "unknown"
+	if client != nil && client.Store != nil && client.Store.ID != nil {
+		botID = getCleanID(client.Store.ID.User)
+	}
+	return logger.With("bot", botID, "chat", redactID(v.Info.Chat.String()), "sender", redactID(v.Info.Sender.String()))
+}
+
+// logRawMessage: پورا روٹنگ پے لوڈ — صرف debug لیول پر
+func logRawMessage(l *slog.Logger, v *events.Message, where string) {
+	if !debugEnabled() {
+		return
+	}
+	l.Debug("raw message",
+		"where", where,
+		"id", v.Info.ID,
+		"sender_alt", redactID(v.Info.SenderAlt.String()),
+		"recipient_alt", redactID(v.Info.RecipientAlt.String()),
+		"push_name", redactText(v.Info.PushName),
+		"type", v.Info.Type,
+		"media_type", v.Info.MediaType,
+		"is_group", v.Info.IsGroup,
+		"from_me", v.Info.IsFromMe,
+		"timestamp", v.Info.Timestamp,
+		"text", redactText(getText(v.Message)),
+	)
+}
@@ -23,7 +23,6 @@ import (
 
 	"github.com/gorilla/websocket"
 	_ "github.com/lib/pq"            // Postgres
-	_ "github.com/go-sql-driver/mysql" // ✅ Added MySQL Driver
 	"github.com/redis/go-redis/v9"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store"
@@ -32,14 +31,14 @@ import (
 //	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/protobuf/proto"
 )
 	
 
 // 📦 STRUCT FOR MESSAGE HISTORY
-// 📦 STRUCT FOR MESSAGE HISTORY (Compatible with MongoDB & MySQL)
+// 📦 STRUCT FOR MESSAGE HISTORY (MessageStore: Mongo / Postgres / memory)
 type ChatMessage struct {
 	ID           int64     `bson:"-" json:"id"`
 	BotID        string    `bson:"bot_id" json:"bot_id"`
@@ -59,6 +58,8 @@ type ChatMessage struct {
 
 	HasMedia bool   `bson:"has_media,omitempty" json:"has_media,omitempty"`
 	MediaRef string `bson:"media_ref,omitempty" json:"media_ref,omitempty"` // usually same as message_id
+
+	Raw []byte `bson:"raw,omitempty" json:"-"` // 📦 اصل waProto.Message (anti-delete / .vv دوبارہ بھیجنے کے لیے)
 }
 
 // 📦 Chat Item Structure
@@ -72,43 +73,37 @@ var (
 	client                *whatsmeow.Client
 	container             *sqlstore.Container
 	dbContainer           *sqlstore.Container
-	historyDB             *sql.DB // ✅ MySQL Connection for Chat History
+	sessionDB             *sql.DB // 🐘 whatsmeow sqlstore (session export/import)
 	rdb                   *redis.Client
 	ctx                   = context.Background()
-	mediaCollection *mongo.Collection
-    statusCollection *mongo.Collection // optional (statuses کیلئے)
 	persistentUptime      int64
 	groupCache            = make(map[string]*GroupSettings)
 	cacheMutex            sync.RWMutex
 	upgrader              = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
-	wsClients             = make(map[*websocket.Conn]bool)
 	botCleanIDCache       = make(map[string]string)
 	botPrefixes           = make(map[string]string)
 	prefixMutex           sync.RWMutex
 	clientsMutex          sync.RWMutex
 	activeClients         = make(map[string]*whatsmeow.Client)
 	globalClient          *whatsmeow.Client
-	ytCache               = make(map[string]YTSession)
-	ytDownloadCache       = make(map[string]YTState)
 	cachedMenuImage       *waProto.ImageMessage
 	mongoClient           *mongo.Client
-	chatHistoryCollection *mongo.Collection
 )
 
 // ✅ 1. Redis Connection
 func initRedis() {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		fmt.Println("⚠️ [REDIS] Warning: REDIS_URL is empty! Defaulting to localhost...")
-		redisURL = "redis://localhost:6379"
+	redisURL := Config().Database.RedisURL
+	if redisURL == defaultConfig().Database.RedisURL {
+		fmt.Println("📡 [REDIS] Using default Redis URL (localhost)...")
 	} else {
-		fmt.Println("📡 [REDIS] Connecting to Redis Cloud...")
+		fmt.Println("📡 [REDIS] Connecting to configured Redis...")
 	}
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
 		log.Fatalf("❌ Redis URL parsing failed: %v", err)
 	}
 	rdb = redis.NewClient(opt)
+	rdb.AddHook(redisMetricsHook{}) // 📈 /metrics latency
 	_, err = rdb.Ping(ctx).Result()
 	if err != nil {
 		log.Fatalf("❌ Redis connection failed: %v", err)
@@ -116,18 +111,33 @@ func initRedis() {
 	fmt.Println("🚀 [REDIS] Connection Established!")
 }
 
-// ✅ 2. Load Global Settings
-func loadGlobalSettings() {
-	if rdb == nil {
-		return
+// ✅ Postgres + WhatsMeow SQL Container (سرور اور session CLI دونوں کے لیے)
+func initSessionStore() {
+	dbURL := Config().Database.PostgresURL // validate پہلے ہی یقینی بنا چکا ہے
+
+	fmt.Println("🐘 [DATABASE] Connecting to PostgreSQL...")
+	rawDB, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		log.Fatalf("❌ Failed to open Postgres connection: %v", err)
 	}
-	val, err := rdb.Get(ctx, "bot_global_settings").Result()
-	if err == nil {
-		dataMutex.Lock()
-		json.Unmarshal([]byte(val), &data)
-		dataMutex.Unlock()
-		fmt.Println("✅ [SETTINGS] Bot Settings Restored from Redis")
+
+	// Pool tuning
+	rawDB.SetMaxOpenConns(20)
+	rawDB.SetMaxIdleConns(5)
+	rawDB.SetConnMaxLifetime(30 * time.Minute)
+	fmt.Println("✅ [TUNING] Postgres Pool Configured")
+
+	// WhatsMeow SQL Container
+	dbLog := waLog.Stdout("Database", "ERROR", true)
+	container = sqlstore.NewWithDB(rawDB, "postgres", dbLog)
+
+	err = container.Upgrade(context.Background())
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize database tables: %v", err)
 	}
+	fmt.Println("✅ [DATABASE] Tables verified/created successfully!")
+	dbContainer = container
+	sessionDB = rawDB
 }
 
 // ✅ 3. Load Persistent Uptime
@@ -155,16 +165,33 @@ func startPersistentUptimeTracker() {
 }
 
 func main() {
+	// 💻 CLI: ./bot apikey create|list|revoke
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		InitConfig()
+		InitLogger()
+		initRedis()
+		os.Exit(runAPIKeyCLI(os.Args[2:]))
+	}
+	// 💻 CLI: ./bot session export|import (ہوسٹ منتقلی)
+	if len(os.Args) > 1 && os.Args[1] == "session" {
+		InitConfig()
+		InitLogger()
+		initRedis()
+		initSessionStore()
+		initStateStores()
+		os.Exit(runSessionCLI(os.Args[2:]))
+	}
+
 	fmt.Println("🚀 IMPOSSIBLE BOT | STARTING (HYBRID MODE)")
+	InitConfig()
+	InitLogger()
 
 	// ----------------------------------------------------
 	// 1) Init Core Services
 	// ----------------------------------------------------
 	initRedis()
 	loadPersistentUptime()
-	loadGlobalSettings()
 	startPersistentUptimeTracker()
-	SetupFeatures()
 	KeepServerAlive()
 
 	// 🔥 START PYTHON ENGINE (BACKGROUND)
@@ -181,13 +208,13 @@ func main() {
 	// ----------------------------------------------------
 	// 2) MongoDB (Optional) - Chat history + Media + Status
 	// ----------------------------------------------------
-	mongoURL := os.Getenv("MONGO_URL")
+	mongoURL := Config().Database.MongoURL
 	if mongoURL != "" {
 		// 🔥 FIX: ٹائم آؤٹ 10 سے بڑھا کر 20 سیکنڈ کر دیا تاکہ کنکشن مستحکم رہے
 		mCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
 
-		mClient, err := mongo.Connect(mCtx, options.Client().ApplyURI(mongoURL))
+		mClient, err := mongo.Connect(mCtx, options.Client().ApplyURI(mongoURL).SetMonitor(mongoMonitor()))
 		if err != nil {
 			fmt.Println("❌ MongoDB Connection Error:", err)
 		} else {
@@ -195,161 +222,77 @@ func main() {
 				fmt.Println("❌ MongoDB Ping Failed:", err)
 			} else {
 				mongoClient = mClient
-
-				db := mClient.Database("whatsapp_bot")
-				chatHistoryCollection = db.Collection("messages")
-				mediaCollection = db.Collection("media")
-				statusCollection = db.Collection("statuses")
-
-				fmt.Println("🍃 [MONGODB] Connected for Chat History + Media + Status!")
-
-				// ✅ Ensure indexes (best-effort)
-				// یہ بیک گراؤنڈ میں انڈیکس بنائے گا تاکہ بوٹ سٹارٹ ہونے میں دیر نہ لگے
-				go func() {
-					// انڈیکس بنانے کے لیے ٹائم آؤٹ بھی بڑھا دیا ہے (60 سیکنڈ)
-					ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-					defer cancel()
-
-					// ----------------------------
-					// MESSAGES Indexes
-					// ----------------------------
-					// Fast paging: bot_id + chat_id + timestamp desc + message_id desc
-					_, err := chatHistoryCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-						{
-							Keys: bson.D{
-								{Key: "bot_id", Value: 1},
-								{Key: "chat_id", Value: 1},
-								{Key: "timestamp", Value: -1},
-								{Key: "message_id", Value: -1},
-							},
-						},
-						// ✅ unique per bot (so multi-bot collision na ho)
-						{
-							Keys:    bson.D{{Key: "bot_id", Value: 1}, {Key: "message_id", Value: 1}},
-							Options: options.Index().SetUnique(true).SetSparse(true),
-						},
-						// 🔥 NEW: GetChats کے لیے خصوصی انڈیکس
-						{
-							Keys: bson.D{{Key: "bot_id", Value: 1}, {Key: "timestamp", Value: -1}},
-						},
-					})
-					
-					if err != nil {
-						fmt.Printf("⚠️ [MONGO INDEX] Messages Index Error: %v\n", err)
-					}
-
-					// ----------------------------
-					// MEDIA Indexes
-					// ----------------------------
-					if mediaCollection != nil {
-						_, _ = mediaCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-							{
-								Keys: bson.D{
-									{Key: "bot_id", Value: 1},
-									{Key: "created_at", Value: -1},
-								},
-							},
-							{
-								Keys:    bson.D{{Key: "bot_id", Value: 1}, {Key: "message_id", Value: 1}},
-								Options: options.Index().SetUnique(true).SetSparse(true),
-							},
-						})
-					}
-
-					// ----------------------------
-					// STATUSES Indexes
-					// ----------------------------
-					if statusCollection != nil {
-						_, _ = statusCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-							{
-								Keys: bson.D{
-									{Key: "bot_id", Value: 1},
-									{Key: "timestamp", Value: -1},
-								},
-							},
-							{
-								Keys: bson.D{
-									{Key: "bot_id", Value: 1},
-									{Key: "chat_id", Value: 1},
-									{Key: "timestamp", Value: -1},
-								},
-							},
-						})
-					}
-
-					fmt.Println("✅ [MONGODB] Indexes ensured!")
-				}()
+				fmt.Println("🍃 [MONGODB] Connected for Chat History + Media!")
 			}
 		}
 	} else {
-		fmt.Println("⚠️ MONGO_URL not found! Chat history/media/status will not be saved.")
-	}
-
-	// ----------------------------------------------------
-	// 3) Postgres (Sessions / WhatsMeow Store)
-	// ----------------------------------------------------
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		log.Fatal("❌ FATAL ERROR: DATABASE_URL environment variable is missing!")
-	}
-
-	fmt.Println("🐘 [DATABASE] Connecting to PostgreSQL...")
-	rawDB, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		log.Fatalf("❌ Failed to open Postgres connection: %v", err)
+		fmt.Println("ℹ️ MONGO_URL not set — chat history/media go to the configured store (storage.go)")
 	}
 
-	// Pool tuning
-	rawDB.SetMaxOpenConns(20)
-	rawDB.SetMaxIdleConns(5)
-	rawDB.SetConnMaxLifetime(30 * time.Minute)
-	fmt.Println("✅ [TUNING] Postgres Pool Configured")
-
 	// ----------------------------------------------------
-	// 4) WhatsMeow SQL Container
+	// 3-4) Postgres (Sessions / WhatsMeow Store)
 	// ----------------------------------------------------
-	dbLog := waLog.Stdout("Database", "ERROR", true)
-	container = sqlstore.NewWithDB(rawDB, "postgres", dbLog)
-
-	err = container.Upgrade(context.Background())
-	if err != nil {
-		log.Fatalf("❌ Failed to initialize database tables: %v", err)
-	}
-	fmt.Println("✅ [DATABASE] Tables verified/created successfully!")
-	dbContainer = container
+	initSessionStore()
+	initStores()
+	loadGlobalSettings() // 🗄️ سیٹنگز اسٹور کے بعد
+	go MigrateLegacyMessages() // 📦 پرانا anti-delete / Mongo ڈیٹا ایک اسٹور میں
 
 	// ----------------------------------------------------
 	// 5) Multi-Bot System
 	// ----------------------------------------------------
 	fmt.Println("🤖 Initializing Multi-Bot System from Database...")
+	LoadConversations()
 	StartAllBots(container)
 	InitLIDSystem()
+	StartRateLimitJanitor()
+	StartSendQueueWorker()
+	StartConversationJanitor()
+	StartRetentionSweeper()
+	StartWSHub()
+	StartConfigReloader()
+	warnIfNoAPIKeys()
 
 	// ----------------------------------------------------
 	// 🌐 ROUTES (Bot UI + Web View)
 	// ----------------------------------------------------
 	http.HandleFunc("/", serveHTML)
 	http.HandleFunc("/pic.png", servePicture)
-	http.HandleFunc("/ws", handleWebSocket)
+	http.HandleFunc("/ws", requireAnyScope([]string{ScopeReadHistory, ScopeManageSessions}, handleWebSocket))
 
+	// 🔑 API روٹس کلید + اسکوپ مانگتے ہیں (api_keys.go)
 	// Bot Pair / Session Management
-	http.HandleFunc("/api/pair", handlePairAPI)
-	http.HandleFunc("/link/pair/", handlePairAPILegacy)
-	http.HandleFunc("/link/delete", handleDeleteSession)
-	http.HandleFunc("/del/all", handleDelAllAPI)
-	http.HandleFunc("/del/", handleDelNumberAPI)
+	http.HandleFunc("/api/pair", requireScope(ScopeManageSessions, handlePairAPI))
+	http.HandleFunc("/api/pair/qr", requireScope(ScopeManageSessions, handlePairQRAPI))
+	http.HandleFunc("/link/pair/", requireScope(ScopeManageSessions, handlePairAPILegacy))
+	http.HandleFunc("/link/delete", requireScope(ScopeAdmin, handleDeleteSession)) // ⚠️ تمام ڈیوائسز — /del/all کی طرح ایڈمن
+	http.HandleFunc("/del/all", requireScope(ScopeAdmin, handleDelAllAPI))
+	http.HandleFunc("/del/", requireScope(ScopeManageSessions, handleDelNumberAPI))
 
 	// Web View APIs
 	http.HandleFunc("/lists", serveListsHTML)
 	http.HandleFunc("/lists/vip", serveListsHTML)
-	http.HandleFunc("/api/sessions", handleGetSessions)
-	http.HandleFunc("/api/chats", handleGetChats)
-	http.HandleFunc("/api/messages", handleGetMessages)
-	http.HandleFunc("/api/media", handleGetMedia)
-	http.HandleFunc("/api/avatar", handleGetAvatar)
+	http.HandleFunc("/api/sessions", requireScope(ScopeReadHistory, handleGetSessions))
+	http.HandleFunc("/api/chats", requireScope(ScopeReadHistory, handleGetChats))
+	http.HandleFunc("/api/messages", requireScope(ScopeReadHistory, handleGetMessages))
+	http.HandleFunc("/api/media", requireScope(ScopeReadHistory, handleGetMedia))
+	http.HandleFunc("/api/avatar", requireScope(ScopeReadHistory, handleGetAvatar))
 
 	// ✅ Status APIs (route now)
-	http.HandleFunc("/api/statuses", handleGetStatuses)
+	http.HandleFunc("/api/statuses", requireScope(ScopeReadHistory, handleGetStatuses))
+	http.HandleFunc("/api/admin/reload-config", requireScope(ScopeAdmin, handleReloadConfigAPI))
+	http.HandleFunc("/api/sessions/export", requireScope(ScopeAdmin, handleSessionExportAPI))
+	http.HandleFunc("/api/sessions/import", requireScope(ScopeAdmin, handleSessionImportAPI))
+
+	// ⚙️ Settings APIs (settings_api.go)
+	http.HandleFunc("GET /api/bots/{id}/settings", requireScope(ScopeManageSettings, handleBotSettingsAPI))
+	http.HandleFunc("PATCH /api/bots/{id}/settings", requireScope(ScopeManageSettings, handleBotSettingsAPI))
+	http.HandleFunc("GET /api/bots/{id}/groups", requireScope(ScopeManageSettings, handleBotGroupsAPI))
+	http.HandleFunc("GET /api/bots/{id}/groups/{chat}/settings", requireScope(ScopeManageSettings, handleGroupSettingsAPI))
+	http.HandleFunc("PATCH /api/bots/{id}/groups/{chat}/settings", requireScope(ScopeManageSettings, handleGroupSettingsAPI))
+
+	http.HandleFunc("POST /api/bots/{id}/send", requireScope(ScopeSendMessages, handleSendAPI)) // 📤 send_api.go
+
+	http.HandleFunc("/metrics", requireScope(ScopeMetrics, handleMetrics))
 
 	// ----------------------------------------------------
 	// ✅ Health / Ready
@@ -360,32 +303,12 @@ func main() {
 		_, _ = w.Write([]byte(`{"ok":true,"service":"impossible-bot"}`))
 	})
 
-	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		deps := map[string]bool{
-			"postgres": rawDB != nil,
-			"redis":    rdb != nil,
-			"mongo":    mongoClient != nil,
-		}
-
-		ok := deps["postgres"] && deps["redis"] // mongo optional
-		w.Header().Set("Content-Type", "application/json")
-
-		if !ok {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "deps": deps})
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "deps": deps})
-	})
+	http.HandleFunc("/readyz", handleReadyz) // 🩺 readiness.go
 
 	// ----------------------------------------------------
 	// Server Boot
 	// ----------------------------------------------------
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := Config().Port
 
 	srv := &http.Server{
 		Addr:              ":" + port,
@@ -418,22 +341,20 @@ func main() {
 		activeClient.Disconnect()
 	}
 	clientsMutex.Unlock()
+	leaveCluster()
 
 	if mongoClient != nil {
 		_ = mongoClient.Disconnect(context.Background())
 	}
-	if rawDB != nil {
-		_ = rawDB.Close()
-	}
-	if historyDB != nil {
-		_ = historyDB.Close()
+	if sessionDB != nil {
+		_ = sessionDB.Close()
 	}
 
 	fmt.Println("👋 Goodbye!")
 }
 
 func saveMediaForMessage(client *whatsmeow.Client, botID, messageID, kind string, anyMsg interface{}) {
-	if mediaCollection == nil {
+	if mediaStore == nil {
 		return
 	}
 
@@ -515,12 +436,7 @@ func saveMediaForMessage(client *whatsmeow.Client, botID, messageID, kind string
 	}
 
 	// Upsert (overwrite if re-downloaded)
-	_, _ = mediaCollection.UpdateOne(
-		ctx,
-		bson.M{"bot_id": botID, "message_id": messageID},
-		bson.M{"$set": md},
-		options.Update().SetUpsert(true),
-	)
+	_ = mediaStore.SaveMedia(ctx, &md)
 }
 
 func handleGetStatuses(w http.ResponseWriter, r *http.Request) {
@@ -541,7 +457,7 @@ func UploadToCatbox(data []byte, filename string) (string, error) {
 	writer.WriteField("reqtype", "fileupload")
 	writer.Close()
 
-	req, _ := http.NewRequest("POST", "https://catbox-production.up.railway.app/upload", body)
+	req, _ := http.NewRequest("POST", Config().APIs.CatboxMirror, body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	client := &http.Client{}
@@ -564,20 +480,19 @@ type MediaItem struct {
 	Size       int       `bson:"size" json:"size"`
 	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
 }
-// 🔥 HELPER: Save Message to Mongo (Fixed Context)
-// 🔥 HELPER: Save Message to Mongo (DEBUG VERSION)
-func saveMessageToMongo(client *whatsmeow.Client, rawBotID, chatID string, senderJID types.JID, msg *waProto.Message, isFromMe bool, ts uint64) {
+// 🔥 HELPER: Save Message to History (MessageStore)
+func saveChatHistory(client *whatsmeow.Client, rawBotID, chatID string, senderJID types.JID, messageID string, msg *waProto.Message, isFromMe bool, ts uint64) {
 	// 🔥 Bot ID کو صاف رکھیں
 	botID := strings.Split(rawBotID, "@")[0]
 	botID = strings.Split(botID, ":")[0]
 
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Printf("❌ [MONGO PANIC] Save failed: %v\n", r)
+			fmt.Printf("❌ [HISTORY PANIC] Save failed: %v\n", r)
 		}
 	}()
 
-	if chatHistoryCollection == nil || msg == nil {
+	if messageStore == nil || msg == nil {
 		return
 	}
 
@@ -662,11 +577,7 @@ func saveMessageToMongo(client *whatsmeow.Client, rawBotID, chatID string, sende
 		}
 	}
 
-	// Get Message ID
-	messageID := ""
-	if contextInfo != nil && contextInfo.StanzaID != nil {
-		messageID = *contextInfo.StanzaID
-	}
+	// Get Message ID (پہلے یہاں غلطی سے quoted میسج کی StanzaID لی جاتی تھی)
 	if messageID == "" {
 		messageID = fmt.Sprintf("%s_%d", senderJID.User, time.Now().UnixNano())
 	}
@@ -730,10 +641,16 @@ func saveMessageToMongo(client *whatsmeow.Client, rawBotID, chatID string, sende
 			if err != nil { return "", err }
 			return url, nil
 		})
+	} else if label := historyLabel(msg); label != "" {
+		msgType = "text"
+		content = label
 	} else {
 		return
 	}
 
+	// 📦 اصل پروٹوبف بھی ساتھ (anti-delete / .vv)
+	raw, _ := proto.Marshal(msg)
+
 	doc := ChatMessage{
 		BotID:        botID,
 		ChatID:       chatID,
@@ -749,15 +666,13 @@ func saveMessageToMongo(client *whatsmeow.Client, rawBotID, chatID string, sende
 		IsSticker:    isSticker,
 		QuotedMsg:    quotedMsg,
 		QuotedSender: quotedSender,
+		Raw:          raw,
 	}
 
-	_, err = chatHistoryCollection.InsertOne(context.Background(), doc)
-	if err != nil {
-		if !strings.Contains(err.Error(), "E11000") {
-			fmt.Printf("❌ [MONGO ERROR] Failed to insert: %v\n", err)
-		}
+	if err := messageStore.SaveMessage(context.Background(), &doc); err != nil {
+		botLog(botID).Error("❌ history save failed", "chat", redactID(chatID), "err", err)
 	} else {
-		fmt.Printf("📝 [MONGO SAVED] Chat: %s | Type: %s | Sender: %s\n", chatID, msgType, senderName)
+		botLog(botID).Debug("📝 history saved", "chat", redactID(chatID), "type", msgType, "sender", redactText(senderName))
 	}
 }
 
@@ -766,7 +681,7 @@ func saveMessageToMongo(client *whatsmeow.Client, rawBotID, chatID string, sende
 
 // helper: save media in separate collection
 func saveMediaDoc(botID, chatID, messageID, typ, mime string, loader func() (string, error)) {
-	if mediaCollection == nil {
+	if mediaStore == nil {
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
@@ -777,20 +692,15 @@ func saveMediaDoc(botID, chatID, messageID, typ, mime string, loader func() (str
 		return
 	}
 
-	_, _ = mediaCollection.UpdateOne(
-		ctx,
-		bson.M{"message_id": messageID},
-		bson.M{"$set": bson.M{
-			"bot_id":      botID,
-			"chat_id":     chatID,
-			"message_id":  messageID,
-			"type":        typ,
-			"mime":        mime,
-			"content":     content,
-			"created_at":  time.Now(),
-		}},
-		options.Update().SetUpsert(true),
-	)
+	_ = mediaStore.SaveMedia(ctx, &MediaDoc{
+		BotID:     botID,
+		ChatID:    chatID,
+		MessageID: messageID,
+		Type:      typ,
+		Mime:      mime,
+		Content:   content,
+		CreatedAt: time.Now(),
+	})
 }
 
 // ⚡ SetGlobalClient
@@ -807,10 +717,7 @@ func ConnectNewSession(device *store.Device) {
 	botCleanIDCache[rawID] = cleanID
 	clientsMutex.Unlock()
 
-	p, err := rdb.Get(ctx, "prefix:"+cleanID).Result()
-	if err != nil {
-		p = "."
-	}
+	p := getSetting("prefix:"+cleanID, Config().Prefix)
 
 	prefixMutex.Lock()
 	botPrefixes[cleanID] = p
@@ -823,6 +730,11 @@ func ConnectNewSession(device *store.Device) {
 		fmt.Printf("⚠️ [MULTI-BOT] Bot %s is already active. Skipping...\n", cleanID)
 		return
 	}
+	// 🧩 کلسٹر: یہ بوٹ کسی اور انسٹینس پر چل رہا ہے
+	if !claimBotLease(cleanID) {
+		fmt.Printf("🧩 [CLUSTER] Bot %s is hosted by another instance. Skipping...\n", cleanID)
+		return
+	}
 
 	clientLog := waLog.Stdout("Client", "ERROR", true)
 	newBotClient := whatsmeow.NewClient(device, clientLog)
@@ -830,16 +742,18 @@ func ConnectNewSession(device *store.Device) {
 	newBotClient.AddEventHandler(func(evt interface{}) {
 		handler(newBotClient, evt)
 	})
+	superviseClient(newBotClient)
 
-	err = newBotClient.Connect()
-	if err != nil {
-		fmt.Printf("❌ [CONNECT ERROR] Bot %s: %v\n", cleanID, err)
-		return
-	}
-	go StartKeepAliveLoop(newBotClient)
+	err := newBotClient.Connect()
 	clientsMutex.Lock()
 	activeClients[cleanID] = newBotClient
 	clientsMutex.Unlock()
+	go StartKeepAliveLoop(newBotClient)
+	if err != nil {
+		fmt.Printf("❌ [CONNECT ERROR] Bot %s: %v (retrying with backoff)\n", cleanID, err)
+		scheduleReconnect(newBotClient, "connect failed", 0)
+		return
+	}
 
 	fmt.Printf("✅ [CONNECTED] Bot: %s | Prefix: %s | Status: Ready\n", cleanID, p)
 }
@@ -851,10 +765,7 @@ func StartKeepAliveLoop(client *whatsmeow.Client) {
 				time.Sleep(10 * time.Second)
 				continue
 			}
-			dataMutex.RLock()
-			isEnabled := data.AlwaysOnline
-			dataMutex.RUnlock()
-			if isEnabled {
+			if GetBotSettings(settingsBotID(client)).AlwaysOnline {
 				client.SendPresence(context.Background(), types.PresenceAvailable)
 			}
 			time.Sleep(30 * time.Second)
@@ -866,10 +777,12 @@ func updatePrefixDB(botID string, newPrefix string) {
 	prefixMutex.Lock()
 	botPrefixes[botID] = newPrefix
 	prefixMutex.Unlock()
-	err := rdb.Set(ctx, "prefix:"+botID, newPrefix, 0).Err()
+	err := settingsStore.Set(ctx, "prefix:"+botID, newPrefix)
 	if err != nil {
-		fmt.Printf("❌ [REDIS ERR] Could not save prefix: %v\n", err)
+		fmt.Printf("❌ [SETTINGS ERR] Could not save prefix: %v\n", err)
+		return
 	}
+	publishSettingsChange(botID, "")
 }
 
 // ✅ serveListsHTML
@@ -881,34 +794,6 @@ func servePicture(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "pic.png")
 }
 
-func handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
-		return
-	}
-	defer conn.Close()
-	wsClients[conn] = true
-	defer delete(wsClients, conn)
-	status := map[string]interface{}{
-		"connected": client != nil && client.IsConnected(),
-		"session":   client != nil && client.Store.ID != nil,
-	}
-	conn.WriteJSON(status)
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
-		}
-	}
-}
-
-func broadcastWS(data interface{}) {
-	for conn := range wsClients {
-		conn.WriteJSON(data)
-	}
-}
-
 // ✅ handleDeleteSession
 func handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	if client != nil && client.IsConnected() {
@@ -918,10 +803,7 @@ func handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	for _, device := range devices {
 		device.Delete(context.Background())
 	}
-	broadcastWS(map[string]interface{}{
-		"event":     "session_deleted",
-		"connected": false,
-	})
+	wsHub.Publish(WSEvent{Event: EvtSessionGone, Data: map[string]any{"scope": "legacy"}})
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, `{"success":true,"message":"Session deleted"}`)
 }
@@ -935,9 +817,11 @@ func handleDelAllAPI(w http.ResponseWriter, r *http.Request) {
 		delete(activeClients, id)
 	}
 	clientsMutex.Unlock()
+	releaseAllLeases()
 	devices, _ := container.GetAllDevices(context.Background())
 	for _, dev := range devices {
 		dev.Delete(context.Background())
+		emitBotEvent(dev.ID.User, EvtSessionGone, nil)
 	}
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, `{"success":true, "message":"All sessions wiped from Database"}`)
@@ -950,6 +834,9 @@ func handleDelNumberAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	targetNum := parts[2]
+	if !requireBotAccess(w, r, getCleanID(targetNum)) {
+		return
+	}
 	fmt.Printf("🗑️ [API] Deleting session for: %s\n", targetNum)
 	clientsMutex.Lock()
 	if c, ok := activeClients[getCleanID(targetNum)]; ok {
@@ -957,12 +844,14 @@ func handleDelNumberAPI(w http.ResponseWriter, r *http.Request) {
 		delete(activeClients, getCleanID(targetNum))
 	}
 	clientsMutex.Unlock()
+	releaseBotLease(getCleanID(targetNum))
 	devices, _ := container.GetAllDevices(context.Background())
 	deleted := false
 	for _, dev := range devices {
 		if getCleanID(dev.ID.User) == getCleanID(targetNum) {
 			dev.Delete(context.Background())
 			deleted = true
+			emitBotEvent(targetNum, EvtSessionGone, nil)
 			break
 		}
 	}
@@ -991,6 +880,10 @@ func handlePairAPI(w http.ResponseWriter, r *http.Request) {
 	number = strings.ReplaceAll(number, " ", "")
 	number = strings.ReplaceAll(number, "-", "")
 	cleanNum := getCleanID(number)
+	// 🔒 محدود کلید صرف اپنے بوٹس دوبارہ pair کر سکے (پرانی ڈیوائس نیچے مٹتی ہے)
+	if !requireBotAccess(w, r, cleanNum) {
+		return
+	}
 	fmt.Printf("📱 [PAIRING] New request for: %s on POSTGRES\n", cleanNum)
 	devices, _ := container.GetAllDevices(context.Background())
 	for _, dev := range devices {
@@ -1010,6 +903,7 @@ func handlePairAPI(w http.ResponseWriter, r *http.Request) {
 	tempClient.AddEventHandler(func(evt interface{}) {
 		handler(tempClient, evt)
 	})
+	superviseClient(tempClient)
 	err := tempClient.Connect()
 	if err != nil {
 		http.Error(w, fmt.Sprintf(`{"error":"%v"}`, err), 500)
@@ -1023,25 +917,21 @@ func handlePairAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	fmt.Printf("✅ [CODE] Generated for %s: %s\n", cleanNum, code)
-	broadcastWS(map[string]interface{}{
-		"event": "pairing_code",
-		"code":  code,
-	})
+	wsHub.Publish(WSEvent{Event: EvtPairingCode, BotID: cleanNum, Scope: ScopeManageSessions, Data: map[string]any{"code": code}})
 	go func() {
 		for i := 0; i < 60; i++ {
 			time.Sleep(1 * time.Second)
 			if tempClient.Store.ID != nil {
 				fmt.Printf("🎉 [PAIRED] %s is now active on Postgres!\n", cleanNum)
-				clientsMutex.Lock()
-				activeClients[cleanNum] = tempClient
-				clientsMutex.Unlock()
+				// ✅ مشترکہ راستہ (QR بھی): ConnectNewSession + OnNewPairing + ویور لاگ ان
+				registerPairedDevice(tempClient)
 				return
 			}
 		}
 		tempClient.Disconnect()
 	}()
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"success":true,"code":"%s"}`, code)
+	fmt.Fprintf(w, `{"success":true,"code":"%s","owner_login":"sent to the paired number after linking"}`, code)
 }
 
 func handlePairAPILegacy(w http.ResponseWriter, r *http.Request) {
@@ -1058,6 +948,9 @@ func handlePairAPILegacy(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"Invalid number"}`, 400)
 		return
 	}
+	if !requireBotAccess(w, r, getCleanID(number)) {
+		return
+	}
 	fmt.Printf("📱 Pairing: %s\n", number)
 	if client != nil && client.IsConnected() {
 		client.Disconnect()
@@ -1100,6 +993,13 @@ func handlePairAPILegacy(w http.ResponseWriter, r *http.Request) {
 
 func StartAllBots(container *sqlstore.Container) {
 	dbContainer = container
+	cleanOrphanTempFiles()
+	// 🧩 کلسٹر موڈ: سب نہیں، صرف اپنے حصے کے بوٹس (leases)
+	if clusterEnabled() {
+		StartCluster(container)
+		go ResumePersistedJobs()
+		return
+	}
 	devices, err := container.GetAllDevices(context.Background())
 	if err != nil {
 		fmt.Printf("❌ [DB-ERROR] Could not load sessions: %v\n", err)
@@ -1123,22 +1023,24 @@ func StartAllBots(container *sqlstore.Container) {
 		}(device)
 		time.Sleep(2 * time.Second)
 	}
+	// 🔁 پچھلے رن کی ادھوری جابز (دوبارہ چلائیں یا یوزر کو بتائیں)
+	go ResumePersistedJobs()
 	go monitorNewSessions(container)
 }
 
 func PreloadAllGroupSettings() {
-	if rdb == nil {
+	if settingsStore == nil {
 		return
 	}
 	fmt.Println("🚀 [RAM] Preloading all group settings into Memory...")
-	keys, err := rdb.Keys(ctx, "group_settings:*").Result()
+	keys, err := settingsStore.Keys(ctx, "group_settings:")
 	if err != nil {
 		fmt.Println("⚠️ [RAM] Failed to fetch keys:", err)
 		return
 	}
 	count := 0
 	for _, key := range keys {
-		val, err := rdb.Get(ctx, key).Result()
+		val, err := settingsStore.Get(ctx, key)
 		if err == nil {
 			var s GroupSettings
 			if json.Unmarshal([]byte(val), &s) == nil {
@@ -1164,9 +1066,8 @@ func getGroupSettings(botID, chatID string) *GroupSettings {
 	if exists {
 		return s
 	}
-	if rdb != nil {
-		redisKey := "group_settings:" + uniqueKey
-		val, err := rdb.Get(ctx, redisKey).Result()
+	if settingsStore != nil {
+		val, err := settingsStore.Get(ctx, "group_settings:"+uniqueKey)
 		if err == nil {
 			var loadedSettings GroupSettings
 			if json.Unmarshal([]byte(val), &loadedSettings) == nil {
@@ -1188,14 +1089,15 @@ func saveGroupSettings(botID string, s *GroupSettings) {
 	cacheMutex.Lock()
 	groupCache[uniqueKey] = s
 	cacheMutex.Unlock()
-	if rdb != nil {
+	if settingsStore != nil {
 		jsonData, err := json.Marshal(s)
 		if err == nil {
-			redisKey := "group_settings:" + uniqueKey
-			err := rdb.Set(ctx, redisKey, jsonData, 0).Err()
+			err := settingsStore.Set(ctx, "group_settings:"+uniqueKey, string(jsonData))
 			if err != nil {
-				fmt.Printf("⚠️ [REDIS ERROR] Failed to save settings: %v\n", err)
+				fmt.Printf("⚠️ [SETTINGS ERROR] Failed to save settings: %v\n", err)
+				return
 			}
+			publishSettingsChange(botID, s.ChatID)
 		}
 	}
 }
@@ -1213,7 +1115,7 @@ func monitorNewSessions(container *sqlstore.Container) {
 			clientsMutex.RLock()
 			_, exists := activeClients[botID]
 			clientsMutex.RUnlock()
-			if !exists {
+			if !exists && !isBotOnHold(botID) {
 				fmt.Printf("\n🆕 [AUTO-CONNECT] New session detected: %s. Connecting...\n", botID)
 				go ConnectNewSession(device)
 				time.Sleep(2 * time.Second)
@@ -1226,16 +1128,74 @@ func monitorNewSessions(container *sqlstore.Container) {
 // 🔥 WEB API HANDLERS (UPDATED & FIXED)
 // -----------------------------------------------------
 
+// SessionInfo: /api/sessions کی ایک لائن
+type SessionInfo struct {
+	BotID    string `json:"bot_id"`
+	Instance string `json:"instance,omitempty"` // کون سا انسٹینس چلا رہا ہے (خالی = کوئی نہیں)
+	Local    bool   `json:"local"`              // اسی انسٹینس پر
+	Online   bool   `json:"online"`
+	State    string `json:"state,omitempty"`
+}
+
 func handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	key := requestAPIKey(r)
+	seen := make(map[string]bool)
+	ids := []string{}
+	add := func(id string) {
+		id = getCleanID(id)
+		if id != "" && id != "unknown" && !seen[id] && key.CanAccessBot(id) {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
 	clientsMutex.RLock()
-	var sessions []string
 	for id := range activeClients {
-		sessions = append(sessions, id)
+		add(id)
 	}
 	clientsMutex.RUnlock()
 
-	// 🔥 یہ لائن ایڈ کرو: اپنے بین شدہ نمبرز کو زبردستی لسٹ میں ڈال دو
-	sessions = append(sessions, "923277635849") // یہاں اپنا بین نمبر لکھو
+	// 🧩 دوسرے انسٹینسز پر چلنے والے بوٹس بھی (مشترکہ Postgres)
+	if devices, err := container.GetAllDevices(r.Context()); err == nil {
+		for _, dev := range devices {
+			add(dev.ID.User)
+		}
+	}
+
+	// 📜 آف لائن/بین شدہ بوٹس کی ہسٹری بھی دکھے
+	if len(key.Bots) > 0 {
+		for _, id := range key.Bots {
+			add(id)
+		}
+	} else if messageStore != nil {
+		dctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		distinct, err := messageStore.BotIDs(dctx)
+		cancel()
+		if err == nil {
+			for _, id := range distinct {
+				add(id)
+			}
+		}
+	}
+
+	hosts := BotHosts(ids)
+	sessions := make([]SessionInfo, 0, len(ids))
+	for _, id := range ids {
+		info := SessionInfo{BotID: id, Instance: hosts[id]}
+		clientsMutex.RLock()
+		c, local := activeClients[id]
+		clientsMutex.RUnlock()
+		if local && c != nil {
+			info.Local = true
+			info.Online = c.IsConnected() && c.IsLoggedIn()
+			if h, ok := GetBotHealth(id); ok {
+				info.State = h.State
+			}
+		} else if info.Instance != "" {
+			info.Online = true // lease زندہ = دوسرا انسٹینس اسے چلا رہا ہے
+		}
+		sessions = append(sessions, info)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(sessions)
@@ -1257,9 +1217,9 @@ func handleGetChats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// 1. DB Connection Check
-	if chatHistoryCollection == nil {
-		http.Error(w, "MongoDB not connected", http.StatusInternalServerError)
-		fmt.Println("❌ [API ERROR] Mongo collection is nil")
+	if messageStore == nil {
+		http.Error(w, "history store not ready", http.StatusInternalServerError)
+		fmt.Println("❌ [API ERROR] Message store is nil")
 		return
 	}
 
@@ -1274,6 +1234,9 @@ func handleGetChats(w http.ResponseWriter, r *http.Request) {
 	// اگر API میں "92300...@s.whatsapp.net" آیا تو یہ اسے "92300..." بنا دے گا
 	botID := strings.Split(rawBotID, "@")[0]
 	botID = strings.Split(botID, ":")[0]
+	if !requireBotAccess(w, r, botID) {
+		return
+	}
 
 	fmt.Printf("🔍 [API REQUEST] GetChats for Bot: %s (Cleaned)\n", botID)
 
@@ -1281,41 +1244,13 @@ func handleGetChats(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
 
-	// 🛠️ Debug: کاؤنٹ چیک کریں
-	count, _ := chatHistoryCollection.CountDocuments(ctx, bson.M{"bot_id": botID})
-	fmt.Printf("📊 [DB CHECK] Found %d total documents for bot_id: %s\n", count, botID)
-
-	// 3. Aggregation Pipeline
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"bot_id":  botID,
-			"chat_id": bson.M{"$ne": ""},
-		}}},
-		// انڈیکس استعمال کرنے کے لیے ٹائم سورٹ
-		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: 1}}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":     "$chat_id",
-			"last_ts": bson.M{"$last": "$timestamp"},
-			"name":    bson.M{"$last": "$sender_name"},
-		}}},
-		{{Key: "$sort", Value: bson.D{{Key: "last_ts", Value: -1}}}},
-		{{Key: "$limit", Value: 3000}}, // 5000 سے کم کر کے 3000 کیا ہے تاکہ لوڈ جلدی ہو
-	}
-
-	cur, err := chatHistoryCollection.Aggregate(ctx, pipeline)
+	// 3. ہر چیٹ کا آخری میسج (5000 سے کم کر کے 3000 کیا ہے تاکہ لوڈ جلدی ہو)
+	chats, err := messageStore.ListChats(ctx, botID, 3000)
 	if err != nil {
-		fmt.Printf("❌ [API ERROR] Aggregate failed: %v\n", err)
+		fmt.Printf("❌ [API ERROR] ListChats failed: %v\n", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer cur.Close(ctx)
-
-	// ... (Data processing logic) ...
-	type row struct {
-		ChatID string    `bson:"_id"`
-		LastTS time.Time `bson:"last_ts"`
-		Name   string    `bson:"name"`
-	}
 
 	type agg struct {
 		ChatID string
@@ -1325,11 +1260,7 @@ func handleGetChats(w http.ResponseWriter, r *http.Request) {
 
 	merged := make(map[string]*agg)
 
-	for cur.Next(ctx) {
-		var it row
-		if err := cur.Decode(&it); err != nil {
-			continue
-		}
+	for _, it := range chats {
 		origID := strings.TrimSpace(it.ChatID)
 		if origID == "" {
 			continue
@@ -1390,8 +1321,8 @@ func handleGetMessages(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Access-Control-Allow-Origin", "*")
     w.Header().Set("Content-Type", "application/json")
 
-    if chatHistoryCollection == nil {
-        http.Error(w, "MongoDB not connected", 500)
+    if messageStore == nil {
+        http.Error(w, "history store not ready", 500)
         return
     }
 
@@ -1399,16 +1330,19 @@ func handleGetMessages(w http.ResponseWriter, r *http.Request) {
     chatID := r.URL.Query().Get("chat_id")
     
     // 🔍 DEBUG LOG
-    fmt.Printf("🔍 [MSG API] Request -> Bot: '%s' | Chat: '%s'\n", botID, chatID)
+    botLog(botID).Debug("🔍 messages API request", "chat", redactID(chatID))
 
     if botID == "" || chatID == "" {
         http.Error(w, "bot_id and chat_id required", 400)
         return
     }
+    if !requireBotAccess(w, r, botID) {
+        return
+    }
 
-    limit := int64(200)
+    limit := 200
     if s := r.URL.Query().Get("limit"); s != "" {
-        if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 && n <= 500 {
+        if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= 500 {
             limit = n
         }
     }
@@ -1420,40 +1354,24 @@ func handleGetMessages(w http.ResponseWriter, r *http.Request) {
         ids = append(ids, canon)
     }
 
-    // فلٹر بنائیں
-    filter := bson.M{
-        "bot_id":  botID,
-        "chat_id": bson.M{"$in": ids},
-    }
-
-    // 🔍 Query کرنے سے پہلے لاگ
-    // fmt.Printf("🕵️ [MONGO QUERY] Filter: %+v\n", filter)
-
-    opts := options.Find().
-        SetSort(bson.D{{Key: "timestamp", Value: -1}}).
-        SetLimit(limit)
-
     ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
     defer cancel()
 
-    cursor, err := chatHistoryCollection.Find(ctx, filter, opts)
+    messages, err := messageStore.ListMessages(ctx, botID, ids, limit)
     if err != nil {
         http.Error(w, err.Error(), 500)
-        fmt.Printf("❌ [MONGO ERROR] Find failed: %v\n", err)
+        botLog(botID).Error("❌ history list failed", "chat", redactID(chatID), "err", err)
         return
     }
-
-    var messages []ChatMessage
-    if err = cursor.All(ctx, &messages); err != nil {
-        http.Error(w, err.Error(), 500)
-        return
+    if messages == nil {
+        messages = []ChatMessage{}
     }
 
-    fmt.Printf("✅ [MSG API] Found %d messages for chat: %s\n", len(messages), chatID)
+    botLog(botID).Debug("✅ messages API result", "chat", redactID(chatID), "count", len(messages))
 
     // اگر میسج 0 ہیں تو شاید bot_id میچ نہیں ہو رہا
     if len(messages) == 0 {
-        fmt.Println("⚠️ [WARNING] No messages returned. Check if BotID matches what is in DB.")
+        botLog(botID).Warn("⚠️ no messages returned, check that bot_id matches the store", "chat", redactID(chatID))
     }
 
     // reverse to old->new
@@ -1464,45 +1382,6 @@ func handleGetMessages(w http.ResponseWriter, r *http.Request) {
     _ = json.NewEncoder(w).Encode(messages)
 }
 
-func ensureMongoIndexes(db *mongo.Database) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// messages: bot_id + chat_id + timestamp + message_id (paging fast)
-	_, err := db.Collection("messages").Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "bot_id", Value: 1},
-			{Key: "chat_id", Value: 1},
-			{Key: "timestamp", Value: -1},
-			{Key: "message_id", Value: -1},
-		},
-	})
-	if err != nil {
-		fmt.Println("⚠️ index messages failed:", err)
-	}
-
-	// media: bot_id + message_id (fast download)
-	_, err = db.Collection("media").Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "bot_id", Value: 1},
-			{Key: "message_id", Value: 1},
-		},
-	})
-	if err != nil {
-		fmt.Println("⚠️ index media failed:", err)
-	}
-
-	// statuses optional
-	_, _ = db.Collection("statuses").Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "bot_id", Value: 1},
-			{Key: "timestamp", Value: -1},
-		},
-	})
-
-	return nil
-}
-
 func extractTimestamp(m ChatMessage) int64 {
 	// Timestamp is time.Time in your struct
 	if m.Timestamp.IsZero() {
@@ -1514,6 +1393,7 @@ func extractTimestamp(m ChatMessage) int64 {
 
 type MediaDoc struct {
 	BotID     string    `bson:"bot_id" json:"bot_id"`
+	ChatID    string    `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
 	MessageID string    `bson:"message_id" json:"message_id"`
 	Type      string    `bson:"type" json:"type"`     // image/audio/video/file/sticker
 	Mime      string    `bson:"mime" json:"mime"`     // image/jpeg, audio/ogg etc
@@ -1523,8 +1403,8 @@ type MediaDoc struct {
 }
 
 func handleGetMedia(w http.ResponseWriter, r *http.Request) {
-	if mongoClient == nil {
-		http.Error(w, "MongoDB not connected", 500)
+	if mediaStore == nil || messageStore == nil {
+		http.Error(w, "history store not ready", 500)
 		return
 	}
 
@@ -1534,46 +1414,42 @@ func handleGetMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 🔐 بوٹ تک محدود کلید کو bot_id دینا لازمی ہے
+	botID := r.URL.Query().Get("bot_id")
+	if botID != "" {
+		if !requireBotAccess(w, r, botID) {
+			return
+		}
+		botID = getCleanID(botID)
+	} else if len(requestAPIKey(r).Bots) > 0 {
+		http.Error(w, "bot_id required", 400)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	type MediaDoc struct {
-		MessageID string    `bson:"message_id"`
-		Type      string    `bson:"type"`
-		Content   string    `bson:"content"`
-		Mime      string    `bson:"mime"`
-		CreatedAt time.Time `bson:"created_at"`
-	}
-
-	// ✅ 1) Try media collection first
-	if mediaCollection != nil {
-		var md MediaDoc
-		err := mediaCollection.FindOne(ctx, bson.M{"message_id": msgID}).Decode(&md)
-		if err == nil && md.Content != "" {
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(map[string]string{
-				"status":  "ok",
-				"content": md.Content,
-				"type":    md.Type,
-				"mime":    md.Mime,
-			})
-			return
-		}
+	// ✅ 1) Try media store first
+	if md, err := mediaStore.GetMedia(ctx, botID, msgID); err == nil && md.Content != "" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"content": md.Content,
+			"type":    md.Type,
+			"mime":    md.Mime,
+		})
+		return
 	}
 
-	// ✅ 2) Fallback to messages collection (backward compatibility)
-	if chatHistoryCollection != nil {
-		var msg ChatMessage
-		err := chatHistoryCollection.FindOne(ctx, bson.M{"message_id": msgID}).Decode(&msg)
-		if err == nil && msg.Content != "" && msg.Content != "MEDIA_WAITING" {
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(map[string]string{
-				"status":  "ok",
-				"content": msg.Content,
-				"type":    msg.Type,
-			})
-			return
-		}
+	// ✅ 2) Fallback to message content (backward compatibility)
+	if msg, err := messageStore.GetMessage(ctx, botID, msgID); err == nil && msg.Content != "" && msg.Content != "MEDIA_WAITING" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"content": msg.Content,
+			"type":    msg.Type,
+		})
+		return
 	}
 
 	http.Error(w, "Media not found", 404)
@@ -1582,6 +1458,9 @@ func handleGetMedia(w http.ResponseWriter, r *http.Request) {
 func handleGetAvatar(w http.ResponseWriter, r *http.Request) {
 	botID := r.URL.Query().Get("bot_id")
 	chatID := r.URL.Query().Get("chat_id")
+	if !requireBotAccess(w, r, botID) {
+		return
+	}
 
 	clientsMutex.RLock()
 	client, exists := activeClients[botID]
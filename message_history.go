@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/protobuf/proto"
+)
+
+// =========================================================
+// 💬 UNIFIED MESSAGE HISTORY
+// ہر میسج ایک ہی MessageStore میں — نارملائزڈ فیلڈز + اصل پروٹوبف (Raw)۔
+// anti-delete، .vv، ہسٹری ویور اور AI کلون سب یہیں سے پڑھتے ہیں۔
+// =========================================================
+
+const (
+	aiTranscriptPrefix = "ai_transcript:"
+	aiTranscriptTTL    = 7 * 24 * time.Hour
+	aiHistoryLimit     = 50
+
+	messagesMigrationKey = "migration:messages_v1"
+)
+
+// realChatJID: LID کے بجائے اصل نمبر والی چیٹ اور بھیجنے والا
+func realChatJID(v *events.Message) (chat, sender types.JID) {
+	sender = v.Info.Sender.ToNonAD()
+	if v.Info.Sender.Server == types.HiddenUserServer && !v.Info.SenderAlt.IsEmpty() {
+		sender = v.Info.SenderAlt.ToNonAD()
+	}
+
+	chat = v.Info.Chat.ToNonAD()
+	if !v.Info.IsGroup {
+		if v.Info.IsFromMe {
+			if v.Info.Chat.Server == types.HiddenUserServer && !v.Info.RecipientAlt.IsEmpty() {
+				chat = v.Info.RecipientAlt.ToNonAD()
+			}
+		} else {
+			chat = sender
+		}
+	}
+	return chat, sender
+}
+
+// historyLabel: وہ میسجز جن کا ٹیکسٹ/میڈیا نہیں — ہسٹری میں لیبل کے ساتھ۔
+// "" = محفوظ کرنے کے لائق نہیں (revoke، ری ایکشن، پول ووٹ وغیرہ)
+func historyLabel(msg *waProto.Message) string {
+	switch {
+	case msg.ProtocolMessage != nil, msg.ReactionMessage != nil, msg.EncReactionMessage != nil,
+		msg.PollUpdateMessage != nil, msg.KeepInChatMessage != nil, msg.PinInChatMessage != nil,
+		msg.SenderKeyDistributionMessage != nil && msg.GetConversation() == "":
+		return ""
+	case msg.ContactMessage != nil:
+		return "👤 Contact: " + msg.ContactMessage.GetDisplayName()
+	case msg.ContactsArrayMessage != nil:
+		return fmt.Sprintf("👥 %d contacts", len(msg.ContactsArrayMessage.GetContacts()))
+	case msg.LocationMessage != nil:
+		return "📍 Location"
+	case msg.LiveLocationMessage != nil:
+		return "📍 Live location"
+	case msg.PollCreationMessage != nil:
+		return "📊 Poll: " + msg.PollCreationMessage.GetName()
+	case msg.PollCreationMessageV3 != nil:
+		return "📊 Poll: " + msg.PollCreationMessageV3.GetName()
+	}
+	return ""
+}
+
+// describeStoredMessage: صرف Raw سے ٹائپ/ٹیکسٹ (مائیگریشن کے لیے — میڈیا ڈاؤنلوڈ نہیں ہوتا)
+func describeStoredMessage(msg *waProto.Message) (typ, content string, sticker bool) {
+	switch {
+	case getText(msg) != "":
+		return "text", getText(msg), false
+	case msg.ImageMessage != nil:
+		return "image", "", false
+	case msg.StickerMessage != nil:
+		return "image", "", true
+	case msg.VideoMessage != nil:
+		return "video", "", false
+	case msg.AudioMessage != nil:
+		return "audio", "", false
+	case msg.DocumentMessage != nil:
+		return "file", "", false
+	}
+	if label := historyLabel(msg); label != "" {
+		return "text", label, false
+	}
+	return "", "", false
+}
+
+// findStoredMessage: پہلے اسی بوٹ کا، پھر بغیر bot_id والا پرانا مائیگریٹ شدہ ڈیٹا
+// (کسی دوسرے بوٹ کا ریکارڈ کبھی نہیں)
+func findStoredMessage(botID, messageID string) (*ChatMessage, *waProto.Message) {
+	if messageStore == nil || messageID == "" {
+		return nil, nil
+	}
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	m, err := messageStore.GetMessage(c, getCleanID(botID), messageID)
+	if err != nil {
+		m, err = messageStore.GetLegacyMessage(c, messageID)
+	}
+	if err != nil || len(m.Raw) == 0 {
+		return m, nil
+	}
+	var raw waProto.Message
+	if proto.Unmarshal(m.Raw, &raw) != nil {
+		return m, nil
+	}
+	return m, &raw
+}
+
+// recordSentMessage: بوٹ کا اپنا بھیجا میسج (whatsmeow اس کا event نہیں دیتا) ہسٹری میں
+func recordSentMessage(client *whatsmeow.Client, chat types.JID, resp whatsmeow.SendResponse, msg *waProto.Message) {
+	if client.Store == nil || client.Store.ID == nil || resp.ID == "" {
+		return
+	}
+	ts := resp.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	saveChatHistory(client, client.Store.ID.User, chat.String(), client.Store.ID.ToNonAD(), resp.ID, msg, true, uint64(ts.Unix()))
+}
+
+// saveAITranscript: وائس نوٹ کا ٹرانسکرپٹ (AI ہسٹری میں "[Voice]: ..." کے لیے)
+func saveAITranscript(botID, messageID, text string) {
+	if stateStore == nil || text == "" {
+		return
+	}
+	key := aiTranscriptPrefix + getCleanID(botID) + ":" + messageID
+	_ = stateStore.Put(context.Background(), key, []byte(text), aiTranscriptTTL)
+}
+
+// aiChatHistory: AI کلون کے لیے آخری میسجز، پرانے پہلے — "Me: ..." / "Name: ..."
+func aiChatHistory(botID string, chat types.JID, limit int) []string {
+	if messageStore == nil {
+		return nil
+	}
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	botID = getCleanID(botID)
+	chatIDs := []string{canonicalChatID(chat.String())}
+	if raw := chat.String(); raw != chatIDs[0] {
+		chatIDs = append(chatIDs, raw)
+	}
+	msgs, err := messageStore.ListMessages(c, botID, chatIDs, limit)
+	if err != nil {
+		return nil
+	}
+
+	lines := make([]string, 0, len(msgs))
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+		name := m.SenderName
+		if m.IsFromMe {
+			name = "Me"
+		}
+		text := m.Content
+		switch m.Type {
+		case "text":
+		case "audio":
+			text = "[Voice message]"
+			if stateStore != nil {
+				if t, err := stateStore.Get(c, aiTranscriptPrefix+botID+":"+m.MessageID); err == nil {
+					text = "[Voice]: " + string(t)
+				}
+			}
+		default:
+			text = fmt.Sprintf("[%s message]", m.Type)
+		}
+		if text == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, text))
+	}
+	return lines
+}
+
+// =========================================================
+// 📦 ONE-TIME MIGRATION
+//   whatsapp_bot_multi.messages        → MessageStore (Raw کے ساتھ)
+//   whatsapp_bot_multi.feature_settings → SettingsStore "antidelete:<bot>"
+//   whatsapp_bot.messages / media      → Postgres/memory (اگر ہسٹری اب Mongo پر نہیں)
+//   chat:history:* (Redis لسٹیں)        → ختم، AI اب MessageStore پڑھتا ہے
+// پرانی کلیکشنز ڈراپ نہیں ہوتیں — تسلی کے بعد خود ہٹائیں۔
+// =========================================================
+
+// MigrateLegacyMessages: initStores کے بعد بیک گراؤنڈ میں؛ مکمل ہونے پر دوبارہ نہیں چلتا
+func MigrateLegacyMessages() {
+	if messageStore == nil || settingsStore == nil || getSetting(messagesMigrationKey, "") != "" {
+		return
+	}
+	if rdb != nil {
+		// کلسٹر میں صرف ایک انسٹینس
+		ok, err := rdb.SetNX(ctx, messagesMigrationKey+":lock", InstanceID(), time.Hour).Result()
+		if err != nil || !ok {
+			return
+		}
+		defer rdb.Del(ctx, messagesMigrationKey+":lock")
+	}
+	c := context.Background()
+
+	if uri := Config().FeaturesMongo(); uri != "" {
+		client := mongoClient
+		if client == nil || uri != Config().Database.MongoURL {
+			dialCtx, cancel := context.WithTimeout(c, 30*time.Second)
+			fc, err := mongo.Connect(dialCtx, options.Client().ApplyURI(uri))
+			cancel()
+			if err != nil {
+				logger.Warn("⚠️ migration: anti-delete Mongo connect failed, retry on next start", "err", err)
+				return
+			}
+			defer fc.Disconnect(c)
+			client = fc
+		}
+		db := client.Database("whatsapp_bot_multi")
+		n, skipped, err := migrateAntiDeleteMessages(c, db.Collection("messages"))
+		if err != nil {
+			logger.Warn("⚠️ migration: anti-delete messages failed, retry on next start", "done", n, "err", err)
+			return
+		}
+		s, err := migrateAntiDeleteSettings(c, db.Collection("feature_settings"))
+		if err != nil {
+			logger.Warn("⚠️ migration: anti-delete settings failed, retry on next start", "err", err)
+			return
+		}
+		logger.Info("📦 migration: anti-delete copied", "messages", n, "settings", s)
+		if skipped > 0 {
+			logger.Warn("⚠️ migration: anti-delete messages skipped — no bot id in old data; left in whatsapp_bot_multi.messages",
+				"skipped", skipped, "devices", pairedDeviceCount())
+		}
+	}
+
+	if mongoClient != nil && storageBackends["messages"] != StorageMongo {
+		db := mongoClient.Database("whatsapp_bot")
+		n, err := migrateMongoHistory(c, db.Collection("messages"))
+		if err != nil {
+			logger.Warn("⚠️ migration: history failed, retry on next start", "done", n, "err", err)
+			return
+		}
+		md, err := migrateMongoMedia(c, db.Collection("media"))
+		if err != nil {
+			logger.Warn("⚠️ migration: media failed, retry on next start", "done", md, "err", err)
+			return
+		}
+		logger.Info("📦 migration: Mongo history copied", "to", storageBackends["messages"], "messages", n, "media", md)
+	}
+
+	if rdb != nil {
+		if keys, err := scanRedisKeys(c, "chat:history:"); err == nil && len(keys) > 0 {
+			rdb.Del(c, keys...)
+			logger.Info("🧹 migration: removed old AI history lists from Redis", "count", len(keys))
+		}
+	}
+
+	_ = settingsStore.Set(c, messagesMigrationKey, time.Now().UTC().Format(time.RFC3339))
+	logger.Info("✅ migration: message store complete")
+}
+
+// legacyAntiDeleteBot: پرانا anti-delete ڈیٹا بوٹ ID کے بغیر تھا — ایک ہی ڈیوائس ہو تو اسی کا۔
+// کئی ڈیوائسز پر "" — پتا نہیں کس بوٹ کا، اس لیے مائیگریٹ نہیں ہوتا (دوسرے بوٹ کو نہ دکھے)
+func legacyAntiDeleteBot() string {
+	if container == nil {
+		return ""
+	}
+	devices, err := container.GetAllDevices(context.Background())
+	if err != nil || len(devices) != 1 || devices[0].ID == nil {
+		return ""
+	}
+	return getCleanID(devices[0].ID.User)
+}
+
+func pairedDeviceCount() int {
+	if container == nil {
+		return 0
+	}
+	devices, err := container.GetAllDevices(context.Background())
+	if err != nil {
+		return 0
+	}
+	return len(devices)
+}
+
+// migrateAntiDeleteMessages: skipped = بوٹ معلوم نہ ہونے کی وجہ سے چھوڑے گئے
+func migrateAntiDeleteMessages(c context.Context, col *mongo.Collection) (n, skipped int, err error) {
+	botID := legacyAntiDeleteBot()
+	cur, err := col.Find(c, bson.M{})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cur.Close(c)
+
+	for cur.Next(c) {
+		var s SavedMsg
+		if cur.Decode(&s) != nil || s.ID == "" {
+			continue
+		}
+		if botID == "" {
+			skipped++
+			continue
+		}
+		var raw waProto.Message
+		if proto.Unmarshal(s.Content, &raw) != nil {
+			continue
+		}
+		typ, content, sticker := describeStoredMessage(&raw)
+		if typ == "" {
+			continue
+		}
+		chatID := canonicalChatID(s.Sender + "@" + types.DefaultUserServer)
+		err := messageStore.SaveMessage(c, &ChatMessage{
+			BotID:      botID,
+			ChatID:     chatID,
+			Sender:     chatID,
+			SenderName: s.Sender,
+			MessageID:  s.ID,
+			Type:       typ,
+			Content:    content,
+			Timestamp:  time.Unix(s.Timestamp, 0),
+			IsSticker:  sticker,
+			Raw:        s.Content,
+		})
+		if err != nil {
+			return n, skipped, err
+		}
+		n++
+	}
+	return n, skipped, cur.Err()
+}
+
+func migrateAntiDeleteSettings(c context.Context, col *mongo.Collection) (int, error) {
+	cur, err := col.Find(c, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(c)
+
+	n := 0
+	for cur.Next(c) {
+		var fs FeatureSettings
+		if cur.Decode(&fs) != nil || fs.BotJID == "" {
+			continue
+		}
+		// نئی سیٹنگ پہلے سے ہو تو وہی رہے
+		if _, err := settingsStore.Get(c, antiDeleteKey(fs.BotJID)); err == nil {
+			continue
+		}
+		if err := saveAntiDeleteSettings(fs); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, cur.Err()
+}
+
+func migrateMongoHistory(c context.Context, col *mongo.Collection) (int, error) {
+	cur, err := col.Find(c, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(c)
+
+	n := 0
+	for cur.Next(c) {
+		var m ChatMessage
+		if cur.Decode(&m) != nil || m.MessageID == "" {
+			continue
+		}
+		if err := messageStore.SaveMessage(c, &m); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, cur.Err()
+}
+
+func migrateMongoMedia(c context.Context, col *mongo.Collection) (int, error) {
+	cur, err := col.Find(c, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(c)
+
+	n := 0
+	for cur.Next(c) {
+		var m MediaDoc
+		if cur.Decode(&m) != nil || m.MessageID == "" {
+			continue
+		}
+		if err := mediaStore.SaveMedia(c, &m); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, cur.Err()
+}
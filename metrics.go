@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// =========================================================
+// 📈 METRICS (/metrics — Prometheus text format)
+// چھوٹا سا اپنا رجسٹر (کوئی نئی dependency نہیں)۔ .stats بھی انہی
+// کاؤنٹرز سے اصلی نمبر دکھاتا ہے۔
+// =========================================================
+
+const metricsNS = "impossible_"
+
+var (
+	latencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	jobBuckets     = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800}
+)
+
+// ---------- counter ----------
+
+type metricCounter struct {
+	name, help string
+	labels     []string
+
+	mu   sync.Mutex
+	vals map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels []string
+	v      float64
+}
+
+func newCounter(name, help string, labels ...string) *metricCounter {
+	c := &metricCounter{name: metricsNS + name, help: help, labels: labels, vals: make(map[string]*counterSeries)}
+	registerMetric(c)
+	return c
+}
+
+func (c *metricCounter) Inc(lv ...string) { c.Add(1, lv...) }
+
+func (c *metricCounter) Add(n float64, lv ...string) {
+	key := strings.Join(lv, "\xff")
+	c.mu.Lock()
+	s, ok := c.vals[key]
+	if !ok {
+		s = &counterSeries{labels: lv}
+		c.vals[key] = s
+	}
+	s.v += n
+	c.mu.Unlock()
+}
+
+// Total: تمام لیبلز کا مجموعہ (.stats کے لیے)
+func (c *metricCounter) Total() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var t float64
+	for _, s := range c.vals {
+		t += s.v
+	}
+	return t
+}
+
+func (c *metricCounter) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.vals) {
+		s := c.vals[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labelString(c.labels, s.labels, "", ""), formatFloat(s.v))
+	}
+}
+
+// ---------- histogram ----------
+
+type metricHistogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu   sync.Mutex
+	vals map[string]*histSeries
+}
+
+type histSeries struct {
+	labels []string
+	counts []uint64 // ہر bucket (cumulative نہیں؛ لکھتے وقت جمع)
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(name, help string, buckets []float64, labels ...string) *metricHistogram {
+	h := &metricHistogram{name: metricsNS + name, help: help, labels: labels, buckets: buckets, vals: make(map[string]*histSeries)}
+	registerMetric(h)
+	return h
+}
+
+func (h *metricHistogram) Observe(v float64, lv ...string) {
+	key := strings.Join(lv, "\xff")
+	h.mu.Lock()
+	s, ok := h.vals[key]
+	if !ok {
+		s = &histSeries{labels: lv, counts: make([]uint64, len(h.buckets))}
+		h.vals[key] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+	h.mu.Unlock()
+}
+
+// Since: time.Since کو سیکنڈز میں ریکارڈ کریں
+func (h *metricHistogram) Since(start time.Time, lv ...string) {
+	h.Observe(time.Since(start).Seconds(), lv...)
+}
+
+// Summary: کل گنتی اور اوسط (.stats کے لیے)
+func (h *metricHistogram) Summary() (count uint64, avg float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var sum float64
+	for _, s := range h.vals {
+		count += s.count
+		sum += s.sum
+	}
+	if count > 0 {
+		avg = sum / float64(count)
+	}
+	return count, avg
+}
+
+func (h *metricHistogram) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.vals) {
+		s := h.vals[key]
+		var cum uint64
+		for i, b := range h.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, s.labels, "le", formatFloat(b)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelString(h.labels, s.labels, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelString(h.labels, s.labels, "", ""), s.count)
+	}
+}
+
+// ---------- gauge (اسکریپ کے وقت حساب) ----------
+
+type metricGaugeFunc struct {
+	name, help string
+	label      string
+	fn         func() map[string]float64 // label ویلیو → نمبر ("" = بغیر لیبل)
+}
+
+func newGaugeFunc(name, help, label string, fn func() map[string]float64) *metricGaugeFunc {
+	g := &metricGaugeFunc{name: metricsNS + name, help: help, label: label, fn: fn}
+	registerMetric(g)
+	return g
+}
+
+func (g *metricGaugeFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	vals := g.fn()
+	for _, key := range sortedKeys(vals) {
+		if key == "" || g.label == "" {
+			fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(vals[key]))
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.name, labelString([]string{g.label}, []string{key}, "", ""), formatFloat(vals[key]))
+	}
+}
+
+// ---------- registry ----------
+
+type metricWriter interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	metricsRegistry []metricWriter
+	metricsMu       sync.Mutex
+)
+
+func registerMetric(m metricWriter) {
+	metricsMu.Lock()
+	metricsRegistry = append(metricsRegistry, m)
+	metricsMu.Unlock()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func labelString(names, values []string, extraName, extraValue string) string {
+	var parts []string
+	for i, n := range names {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		parts = append(parts, n+`="`+escapeLabel(v)+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+extraValue+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// =========================================================
+// 📊 THE METRICS
+// =========================================================
+
+var (
+	mMessagesReceived = newCounter("messages_received_total", "Messages received, per bot.", "bot")
+	mCommands         = newCounter("commands_total", "Commands dispatched, by name and outcome.", "command", "status")
+	mCommandLatency   = newHistogram("command_duration_seconds", "Command handler run time.", latencyBuckets, "command")
+	mJobDuration      = newHistogram("job_duration_seconds", "Download / ffmpeg job run time (excluding queue wait).", jobBuckets, "type")
+	mJobFailures      = newCounter("job_failures_total", "Failed jobs by type and reason.", "type", "reason")
+	mAIErrors         = newCounter("ai_api_errors_total", "Upstream AI API errors by provider.", "provider")
+	mRedisLatency     = newHistogram("redis_op_duration_seconds", "Redis command latency.", latencyBuckets, "cmd")
+	mMongoLatency     = newHistogram("mongo_op_duration_seconds", "MongoDB command latency.", latencyBuckets, "cmd")
+	mMongoErrors      = newCounter("mongo_op_errors_total", "Failed MongoDB commands.", "cmd")
+	mAPISends         = newCounter("api_messages_total", "Messages sent through the HTTP API, by result.", "bot", "result")
+	mRetentionDeleted = newCounter("retention_deleted_total", "History rows removed by the retention sweeper and .purgechat.", "kind", "what")
+)
+
+func init() {
+	newGaugeFunc("bots_connected", "Bots connected and logged in on this instance.", "", func() map[string]float64 {
+		return map[string]float64{"": float64(connectedBotCount())}
+	})
+	newGaugeFunc("bot_up", "1 if the bot is connected and logged in on this instance.", "bot", func() map[string]float64 {
+		out := make(map[string]float64)
+		clientsMutex.RLock()
+		defer clientsMutex.RUnlock()
+		for id, c := range activeClients {
+			up := 0.0
+			if c != nil && c.IsConnected() && c.IsLoggedIn() {
+				up = 1
+			}
+			out[id] = up
+		}
+		return out
+	})
+	newGaugeFunc("go_goroutines", "Number of goroutines.", "", func() map[string]float64 {
+		return map[string]float64{"": float64(runtime.NumGoroutine())}
+	})
+	newGaugeFunc("go_heap_bytes", "Heap bytes in use.", "", func() map[string]float64 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return map[string]float64{"": float64(m.HeapAlloc)}
+	})
+	newGaugeFunc("uptime_seconds", "Seconds since this process started.", "", func() map[string]float64 {
+		return map[string]float64{"": time.Since(startTime).Seconds()}
+	})
+}
+
+func connectedBotCount() int {
+	clientsMutex.RLock()
+	defer clientsMutex.RUnlock()
+	n := 0
+	for _, c := range activeClients {
+		if c != nil && c.IsConnected() && c.IsLoggedIn() {
+			n++
+		}
+	}
+	return n
+}
+
+// 🌐 GET /metrics (admin)
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	metricsMu.Lock()
+	list := append([]metricWriter(nil), metricsRegistry...)
+	metricsMu.Unlock()
+	for _, m := range list {
+		m.write(bw)
+	}
+	bw.Flush()
+}
+
+// =========================================================
+// 🔌 HOOKS (Redis / Mongo)
+// =========================================================
+
+type redisMetricsHook struct{}
+
+func (redisMetricsHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (redisMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(c context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(c, cmd)
+		mRedisLatency.Since(start, cmd.Name())
+		return err
+	}
+}
+
+func (redisMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(c context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(c, cmds)
+		mRedisLatency.Since(start, "pipeline")
+		return err
+	}
+}
+
+// mongoMonitor: options.Client().SetMonitor(...) میں
+func mongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mMongoLatency.Observe(e.Duration.Seconds(), e.CommandName)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mMongoLatency.Observe(e.Duration.Seconds(), e.CommandName)
+			mMongoErrors.Inc(e.CommandName)
+		},
+	}
+}
+
+// =========================================================
+// 🖥️ HOST INFO (.stats)
+// =========================================================
+
+// systemMemoryMB: /proc/meminfo سے کل اور دستیاب RAM (لینکس کے علاوہ 0)
+func systemMemoryMB() (total, available uint64) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, _ := strconv.ParseUint(fields[1], 10, 64)
+		switch fields[0] {
+		case "MemTotal:":
+			total = kb / 1024
+		case "MemAvailable:":
+			available = kb / 1024
+		}
+	}
+	return total, available
+}
@@ -12,7 +12,6 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"go.mau.fi/whatsmeow"
@@ -33,9 +32,6 @@ type ArchiveResult struct {
 	Type       string // New: To verify file type
 }
 
-var archiveCache = make(map[string][]ArchiveResult)
-var archiveMutex sync.Mutex
-
 // API Response Structures
 type IAHeader struct {
 	Identifier string      `json:"identifier"`
@@ -69,10 +65,11 @@ func handleArchive(client *whatsmeow.Client, v *events.Message, input string, mo
 	// 1️⃣ Number Selection (Download Logic)
 	if isNumber(input) {
 		index, _ := strconv.Atoi(input)
-		
-		archiveMutex.Lock()
-		results, exists := archiveCache[senderJID]
-		archiveMutex.Unlock()
+
+		var results []ArchiveResult
+		botID, chatID, senderID := convScope(client, v)
+		conv := GetConversation(botID, chatID, senderID, FlowArchive)
+		exists := conv != nil && conv.Decode(&results)
 
 		if exists && index > 0 && index <= len(results) {
 			selected := results[index-1]
@@ -98,6 +95,15 @@ func handleArchive(client *whatsmeow.Client, v *events.Message, input string, mo
 	go performArchiveSearch(client, v, input, senderJID, mode)
 }
 
+// 🎞️ نمبر والا جواب (FlowArchive) — باقی میسج آگے جانے دیں
+func handleArchiveReply(client *whatsmeow.Client, v *events.Message, conv *Conversation, text string) bool {
+	if !isNumber(text) {
+		return false
+	}
+	go handleArchive(client, v, text, "download")
+	return true
+}
+
 // --- 🔍 Helper: Search Engine (Updated for Modes) ---
 func performArchiveSearch(client *whatsmeow.Client, v *events.Message, query string, senderJID string, mode string) {
 	// 🔥 Dynamic Query Builder
@@ -166,9 +172,8 @@ func performArchiveSearch(client *whatsmeow.Client, v *events.Message, query str
 	
 	msgText += "\n👇 *Reply with a number to download.*"
 
-	archiveMutex.Lock()
-	archiveCache[senderJID] = list
-	archiveMutex.Unlock()
+	// 💬 سیشن (10 منٹ) — نمبر والا جواب handleArchiveReply تک جائے گا
+	StartConversation(client, v, FlowArchive, "", 10*time.Minute, list)
 
 	// Send Menu
 	client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
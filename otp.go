@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,33 +20,26 @@ import (
 // ==========================================
 
 type SMSConfig struct {
-	Name      string
-	NumberURL string
-	SmsURL    string
+	Name      string `yaml:"name"`
+	NumberURL string `yaml:"number_url"`
+	SmsURL    string `yaml:"sms_url"`
 }
 
-// یہاں آپ جتنی مرضی APIs ایڈ کر سکتے ہیں (1, 2, 3...)
-var SMS_APIS = map[string]SMSConfig{
-	"1": {
-		Name:      "Kamina Server 1",
-		NumberURL: "https://api-kami-nodejs-production.up.railway.app/api?type=numbers",
-		SmsURL:    "https://api-kami-nodejs-production.up.railway.app/api?type=sms",
-	},
-	"2": {
-		Name:      "Server 2 (D-group)",
-		NumberURL: "https://kamina-otp.up.railway.app/d-group/numbers",
-		SmsURL:    "https://kamina-otp.up.railway.app/d-group/sms", // یہاں غلطی تھی
-	},
-	"3": {
-		Name:      "Server 3 (Npm-neon)",
-		NumberURL: "https://kamina-otp.up.railway.app/npm-neon/numbers",
-		SmsURL:    "https://kamina-otp.up.railway.app/npm-neon/sms", // یہاں غلطی تھی
-	},
-	"4": {
-		Name:      "Server 4 (mait)",
-		NumberURL: "https://kamina-otp.up.railway.app/mait/numbers",
-		SmsURL:    "https://kamina-otp.up.railway.app/mait/sms", // یہاں غلطی تھی
-	},
+// 📋 APIs کی لسٹ اب config.yaml (sms_apis) سے آتی ہے — Config().SMSAPIs
+// (کوئی بلٹ اِن لسٹ نہیں؛ کنفیگ نہ ہو تو .num / .code بند)
+
+// smsAPIList: "1, 2" یا "none configured" — ایرر میسج کے لیے
+func smsAPIList() string {
+	apis := Config().SMSAPIs
+	if len(apis) == 0 {
+		return "none configured (sms_apis)"
+	}
+	ids := make([]string, 0, len(apis))
+	for id := range apis {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ", ")
 }
 
 
@@ -90,9 +84,9 @@ func HandleGetNumber(client *whatsmeow.Client, v *events.Message, args []string)
 	}
 
 	// چیک کریں کہ API موجود ہے یا نہیں
-	config, exists := SMS_APIS[apiID]
+	config, exists := Config().SMSAPIs[apiID]
 	if !exists {
-		replyMessage(client, v, fmt.Sprintf("❌ Invalid API ID: %s\nAvailable: 1", apiID))
+		replyMessage(client, v, fmt.Sprintf("❌ Invalid API ID: %s\nAvailable: %s", apiID, smsAPIList()))
 		return
 	}
 
@@ -174,9 +168,9 @@ func HandleGetOTP(client *whatsmeow.Client, v *events.Message, args []string) {
 	numberArgs := strings.Join(args[1:], "") // باقی سب نمبر ہے
 
 	// 1. Validate API ID
-	config, exists := SMS_APIS[apiID]
+	config, exists := Config().SMSAPIs[apiID]
 	if !exists {
-		replyMessage(client, v, fmt.Sprintf("❌ Invalid API ID: %s", apiID))
+		replyMessage(client, v, fmt.Sprintf("❌ Invalid API ID: %s\nAvailable: %s", apiID, smsAPIList()))
 		return
 	}
 
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// =========================================================
+// 🔐 OWNER LOGIN (/lists ویور)
+// ہر پیئر شدہ نمبر کے مالک کو صرف اپنے بوٹ تک محدود read-history کلید ملتی ہے۔
+// کلید بوٹ کی "Message Yourself" چیٹ میں بھیجی جاتی ہے — یعنی صرف فون کا مالک دیکھ سکتا ہے۔
+// =========================================================
+
+func ownerKeyName(botID string) string {
+	return "owner:" + botID
+}
+
+// issueOwnerKey پرانی اونر کلید ختم کر کے نئی بناتا ہے
+func issueOwnerKey(botID string) (string, error) {
+	botID = getCleanID(botID)
+	keys, err := ListAPIKeys()
+	if err != nil {
+		return "", err
+	}
+	for _, k := range keys {
+		if k.Name == ownerKeyName(botID) {
+			RevokeAPIKey(k.ID)
+		}
+	}
+	key, _, err := CreateAPIKey(ownerKeyName(botID), []string{ScopeReadHistory}, []string{botID})
+	return key, err
+}
+
+// sendOwnerKey: نئی کلید بنا کر بوٹ کے اپنے نمبر پر بھیجیں
+func sendOwnerKey(client *whatsmeow.Client) error {
+	if client == nil || client.Store.ID == nil {
+		return fmt.Errorf("bot not logged in")
+	}
+	botID := getCleanID(client.Store.ID.User)
+	key, err := issueOwnerKey(botID)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("╔════════════════════╗\n║ 🔐 HISTORY VIEWER LOGIN\n╠════════════════════╣\n║ Open */lists* and paste\n║ this key when asked:\n╚════════════════════╝\n\n%s\n\n_Only shows chats of %s. Send .weblogin to get a new key (the old one stops working)._", key, botID)
+	_, err = client.SendMessage(context.Background(), client.Store.ID.ToNonAD(), &waProto.Message{
+		Conversation: proto.String(msg),
+	})
+	if err == nil {
+		botLog(botID).Info("🔐 owner viewer key issued")
+	}
+	return err
+}
+
+// ⚙️ .weblogin COMMAND (اونر)
+func handleWebLoginCmd(c *CommandContext) {
+	if err := sendOwnerKey(c.Client); err != nil {
+		replyMessage(c.Client, c.Msg, "❌ Could not create viewer login: "+err.Error())
+		return
+	}
+	if c.ChatID != c.Client.Store.ID.ToNonAD().String() {
+		replyMessage(c.Client, c.Msg, "✅ Viewer login sent to your *Message Yourself* chat. 🔐")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// =========================================================
+// 📷 QR PAIRING (/api/pair/qr)
+// جن یوزرز کو پیئرنگ کوڈ والا نوٹیفکیشن نہیں ملتا وہ QR اسکین کر سکتے ہیں۔
+// جواب SSE اسٹریم ہے (event: qr / success / error)؛ وہی ایونٹس WebSocket
+// پر manage-sessions کلائنٹس کو بھی جاتے ہیں۔ کامیابی پر ڈیوائس عام راستے
+// (ConnectNewSession + OnNewPairing) سے رجسٹر ہوتی ہے۔
+// =========================================================
+
+const (
+	EvtPairingQR = "pairing-qr"
+
+	maxQRSessions   = 3
+	qrLoginWait     = 30 * time.Second // success کے بعد لاگ ان مکمل ہونے کا انتظار
+	qrSessionMaxAge = 4 * time.Minute
+)
+
+var (
+	qrSessions   int
+	qrSessionsMu sync.Mutex
+)
+
+// 🌐 POST /api/pair/qr (requireScope manage-sessions) → text/event-stream
+func handlePairQRAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		http.Error(w, `{"error":"Method not allowed"}`, 405)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"error":"Streaming not supported"}`, 500)
+		return
+	}
+
+	qrSessionsMu.Lock()
+	if qrSessions >= maxQRSessions {
+		qrSessionsMu.Unlock()
+		http.Error(w, `{"error":"Too many QR pairings in progress, try again shortly"}`, http.StatusTooManyRequests)
+		return
+	}
+	qrSessions++
+	qrSessionsMu.Unlock()
+	defer func() {
+		qrSessionsMu.Lock()
+		qrSessions--
+		qrSessionsMu.Unlock()
+	}()
+
+	pairID := newJobID()
+	send := func(event string, data map[string]any) {
+		raw, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, raw)
+		flusher.Flush()
+		wsHub.Publish(WSEvent{Event: EvtPairingQR, Scope: ScopeManageSessions, Data: map[string]any{"pair_id": pairID, "stage": event, "info": data}})
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	qctx, cancel := context.WithTimeout(r.Context(), qrSessionMaxAge)
+	defer cancel()
+
+	tempClient := whatsmeow.NewClient(container.NewDevice(), waLog.Stdout("PairingQR", "INFO", true))
+	tempClient.AddEventHandler(func(evt interface{}) {
+		handler(tempClient, evt)
+	})
+	superviseClient(tempClient)
+
+	qrChan, err := tempClient.GetQRChannel(qctx)
+	if err != nil {
+		send("error", map[string]any{"error": err.Error()})
+		return
+	}
+	if err := tempClient.Connect(); err != nil {
+		send("error", map[string]any{"error": err.Error()})
+		return
+	}
+	logger.Info("📷 QR pairing started", "pair", pairID, "remote", r.RemoteAddr)
+
+	for {
+		select {
+		case <-qctx.Done():
+			// براؤزر بند یا وقت ختم — ادھوری ڈیوائس چھوڑ دیں
+			if tempClient.Store.ID == nil {
+				tempClient.Disconnect()
+			}
+			logger.Info("📷 QR pairing abandoned", "pair", pairID)
+			return
+
+		case item, ok := <-qrChan:
+			if !ok {
+				return
+			}
+			switch item.Event {
+			case whatsmeow.QRChannelEventCode:
+				send("qr", map[string]any{"code": item.Code, "timeout": int(item.Timeout.Seconds())})
+
+			case whatsmeow.QRChannelSuccess.Event:
+				botID := ""
+				if tempClient.Store.ID != nil {
+					botID = getCleanID(tempClient.Store.ID.User)
+				}
+				botLog(botID).Info("🎉 QR pairing linked", "pair", pairID)
+				send("success", map[string]any{"bot_id": botID, "owner_login": "sent to the paired number after linking"})
+				go registerPairedDevice(tempClient)
+				return
+
+			default:
+				msg := item.Event
+				if item.Error != nil {
+					msg = item.Error.Error()
+				}
+				logger.Warn("⚠️ QR pairing failed", "pair", pairID, "err", msg)
+				send("error", map[string]any{"error": msg})
+				tempClient.Disconnect()
+				return
+			}
+		}
+	}
+}
+
+// registerPairedDevice: عارضی پیئرنگ کلائنٹ بند کر کے ڈیوائس کو عام بوٹ کی طرح چلائیں
+func registerPairedDevice(tempClient *whatsmeow.Client) {
+	deadline := time.Now().Add(qrLoginWait)
+	for !tempClient.IsLoggedIn() && time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+	}
+	device := tempClient.Store
+	if device.ID == nil {
+		logger.Warn("⚠️ paired device lost its ID before registration")
+		tempClient.Disconnect()
+		return
+	}
+	botID := getCleanID(device.ID.User)
+	tempClient.Disconnect()
+
+	// 🧹 اسی نمبر کا پرانا سیشن ہٹائیں (فون کوڈ والے راستے کی طرح)
+	dropBotSessions(botID, device.ID)
+	takeOverBotLease(botID) // 🧩 پیئرنگ اسی انسٹینس پر ہوئی
+
+	ConnectNewSession(device)
+
+	clientsMutex.RLock()
+	bot := activeClients[botID]
+	clientsMutex.RUnlock()
+	if bot == nil {
+		botLog(botID).Warn("⚠️ paired but failed to start")
+		return
+	}
+
+	go OnNewPairing(bot)
+	go func() {
+		time.Sleep(5 * time.Second)
+		if err := sendOwnerKey(bot); err != nil {
+			botLog(botID).Warn("⚠️ could not issue owner viewer key", "err", err)
+		}
+	}()
+}
+
+// dropBotSessions: بوٹ کا چلتا کلائنٹ بند + اسی نمبر کی باقی ڈیوائسز sqlstore سے حذف
+// (keep والی ڈیوائس رہنے دیں؛ nil = سب)۔ سرور سے لاگ آؤٹ نہیں ہوتا۔
+func dropBotSessions(botID string, keep *types.JID) {
+	clientsMutex.Lock()
+	if old, ok := activeClients[botID]; ok {
+		old.Disconnect()
+		delete(activeClients, botID)
+	}
+	clientsMutex.Unlock()
+	stopReconnect(botID)
+	releaseBotLease(botID)
+
+	devices, err := container.GetAllDevices(context.Background())
+	if err != nil {
+		return
+	}
+	for _, dev := range devices {
+		if getCleanID(dev.ID.User) != botID || (keep != nil && *dev.ID == *keep) {
+			continue
+		}
+		botLog(botID).Info("🧹 removing old session", "device", redactID(dev.ID.String()))
+		dev.Delete(context.Background())
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// =========================================================
+// 🔐 PER-GROUP COMMAND PERMISSIONS (.perm)
+// رولز GroupSettings.Perms میں رہتے ہیں اور اسی کے ساتھ Redis میں سیو ہوتے ہیں۔
+// سب سے مخصوص رول جیتتا ہے (نمبر > admins/members > all، کمانڈ > کیٹیگری)،
+// برابری پر deny جیتتا ہے۔ اونر پر کوئی رول لاگو نہیں ہوتا۔
+// allow صرف موڈ (private/admin) کھولتا ہے — کمانڈ کے Role سے نیچے رسائی نہیں دیتا۔
+// =========================================================
+
+type permDecision int
+
+const (
+	permNone permDecision = iota
+	permAllow
+	permDeny
+)
+
+const denyByRule = "╔════════════════╗\n║ 🚫 BLOCKED\n╠════════════════╣\n║ 🔐 Not allowed\n║ in this group\n╚════════════════╝"
+
+// permTargetScore: -1 = میچ نہیں، 1 = کمانڈ، 0 = کیٹیگری۔
+// .perm پر کوئی رول لاگو نہیں (cat:safety سے بھی نہیں) — ورنہ ایڈمن لاک آؤٹ ہو جائیں
+func permTargetScore(target string, c *Command) int {
+	if c.Name == "perm" {
+		return -1
+	}
+	if cat, ok := strings.CutPrefix(target, "cat:"); ok {
+		if cat == c.Category {
+			return 0
+		}
+		return -1
+	}
+	if target == c.Name {
+		return 1
+	}
+	return -1
+}
+
+// permSubjectScore: -1 = میچ نہیں، 0 = all، 1 = admins/members، 2 = مخصوص نمبر/LID
+func permSubjectScore(subject string, senderKeys []string, isAdm func() bool) int {
+	switch subject {
+	case "all":
+		return 0
+	case "admins":
+		if isAdm() {
+			return 1
+		}
+		return -1
+	case "members":
+		if !isAdm() {
+			return 1
+		}
+		return -1
+	}
+	for _, k := range senderKeys {
+		if k != "" && k == subject {
+			return 2
+		}
+	}
+	return -1
+}
+
+// evalPermRules گروپ کے رولز میں سے اس کمانڈ اور سینڈر کا فیصلہ نکالتا ہے
+func evalPermRules(rules []PermRule, c *Command, senderKeys []string, isAdm func() bool) permDecision {
+	best := -1
+	decision := permNone
+	for _, r := range rules {
+		t := permTargetScore(r.Target, c)
+		if t < 0 {
+			continue
+		}
+		sub := permSubjectScore(r.Subject, senderKeys, isAdm)
+		if sub < 0 {
+			continue
+		}
+		score := sub*2 + t
+		if score > best {
+			best = score
+			decision = permNone
+		}
+		if score == best {
+			if r.Action == "deny" {
+				decision = permDeny
+			} else if decision != permDeny {
+				decision = permAllow
+			}
+		}
+	}
+	return decision
+}
+
+// senderPermKeys: سینڈر کا نمبر اور LID دونوں (جو بھی دستیاب ہو)
+func senderPermKeys(v *events.Message) []string {
+	keys := []string{getCleanID(v.Info.Sender.User)}
+	if !v.Info.SenderAlt.IsEmpty() {
+		keys = append(keys, getCleanID(v.Info.SenderAlt.User))
+	}
+	return keys
+}
+
+// =========================================================
+// ⚙️ .perm COMMAND
+// =========================================================
+
+func handlePermCmd(c *CommandContext) {
+	s := getGroupSettings(c.BotID, c.ChatID)
+	p := c.Prefix
+
+	sub := "list"
+	if len(c.Args) > 0 {
+		sub = strings.ToLower(c.Args[0])
+	}
+
+	switch sub {
+	case "list":
+		replyMessage(c.Client, c.Msg, formatPermRules(s.Perms, p))
+
+	case "allow", "deny":
+		if len(c.Args) < 2 {
+			replyMessage(c.Client, c.Msg, permUsage(p))
+			return
+		}
+		target, ok := resolvePermTarget(c.Args[1])
+		if !ok {
+			replyMessage(c.Client, c.Msg, "❌ Unknown command or category: "+c.Args[1])
+			return
+		}
+		subject := resolvePermSubject(c.Msg, c.Args[2:])
+		if subject == "" {
+			replyMessage(c.Client, c.Msg, "⚠️ Who? Use *all*, *members*, *admins*, a number or @mention.")
+			return
+		}
+
+		rule := PermRule{Action: sub, Target: target, Subject: subject}
+		replaced := false
+		for i, r := range s.Perms {
+			if r.Target == target && r.Subject == subject {
+				s.Perms[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.Perms = append(s.Perms, rule)
+		}
+		saveGroupSettings(c.BotID, s)
+
+		icon := "✅"
+		if sub == "deny" {
+			icon = "🚫"
+		}
+		replyMessage(c.Client, c.Msg, fmt.Sprintf("╔════════════════╗\n║ 🔐 PERMISSION SET\n╠════════════════╣\n║ %s %s\n║ 🎯 %s\n║ 👤 %s\n╚════════════════╝",
+			icon, strings.ToUpper(sub), target, subject))
+
+	case "del", "remove", "rm":
+		if len(c.Args) < 2 {
+			replyMessage(c.Client, c.Msg, permUsage(p))
+			return
+		}
+		n, err := strconv.Atoi(c.Args[1])
+		if err != nil || n < 1 || n > len(s.Perms) {
+			replyMessage(c.Client, c.Msg, "❌ Invalid rule number. See "+p+"perm list")
+			return
+		}
+		s.Perms = append(s.Perms[:n-1], s.Perms[n:]...)
+		saveGroupSettings(c.BotID, s)
+		replyMessage(c.Client, c.Msg, fmt.Sprintf("🗑️ Rule #%d removed.", n))
+
+	case "reset", "clear":
+		s.Perms = nil
+		saveGroupSettings(c.BotID, s)
+		replyMessage(c.Client, c.Msg, "♻️ All permission rules cleared for this group.")
+
+	default:
+		replyMessage(c.Client, c.Msg, permUsage(p))
+	}
+}
+
+func permUsage(p string) string {
+	return fmt.Sprintf(`╔════════════════╗
+║ 🔐 PERMISSIONS
+╠════════════════╣
+║ %sperm list
+║ %sperm allow <cmd|cat:name> <who>
+║ %sperm deny <cmd|cat:name> <who>
+║ %sperm del <no>
+║ %sperm reset
+╠════════════════╣
+║ 👤 who = all | members | admins
+║    | number | @mention
+╚════════════════╝`, p, p, p, p, p)
+}
+
+func formatPermRules(rules []PermRule, p string) string {
+	if len(rules) == 0 {
+		return "╔════════════════╗\n║ 🔐 PERMISSIONS\n╠════════════════╣\n║ No custom rules\n║ Default roles apply\n╚════════════════╝\n\n" + permUsage(p)
+	}
+	out := "╔════════════════╗\n║ 🔐 PERMISSIONS\n╠════════════════╣\n"
+	for i, r := range rules {
+		icon := "✅"
+		if r.Action == "deny" {
+			icon = "🚫"
+		}
+		out += fmt.Sprintf("║ %d. %s %s → %s\n", i+1, icon, r.Target, r.Subject)
+	}
+	out += "╚════════════════╝"
+	return out
+}
+
+// resolvePermTarget کمانڈ/عرف کو اصل نام میں، یا کیٹیگری کو "cat:x" میں بدلتا ہے
+func resolvePermTarget(raw string) (string, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+
+	if cat, ok := strings.CutPrefix(raw, "cat:"); ok {
+		if isCommandCategory(cat) {
+			return "cat:" + cat, true
+		}
+		return "", false
+	}
+
+	if c, ok := lookupCommand(raw); ok {
+		// 🔒 .perm کو خود بلاک کرنے کی اجازت نہیں (ورنہ ایڈمن لاک آؤٹ ہو جائیں)
+		if c.Name == "perm" {
+			return "", false
+		}
+		return c.Name, true
+	}
+
+	if isCommandCategory(raw) {
+		return "cat:" + raw, true
+	}
+	return "", false
+}
+
+func isCommandCategory(cat string) bool {
+	for _, sec := range menuSections {
+		if sec.Category == cat {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePermSubject: all/members/admins، مینشن، ریپلائی یا نمبر
+func resolvePermSubject(v *events.Message, args []string) string {
+	if len(args) > 0 {
+		switch a := strings.ToLower(args[0]); a {
+		case "all", "everyone":
+			return "all"
+		case "members", "member", "users":
+			return "members"
+		case "admins", "admin":
+			return "admins"
+		}
+	}
+
+	if ext := v.Message.GetExtendedTextMessage(); ext != nil && ext.ContextInfo != nil {
+		if len(ext.ContextInfo.MentionedJID) > 0 {
+			return getCleanID(ext.ContextInfo.MentionedJID[0])
+		}
+		if ext.ContextInfo.Participant != nil && len(args) == 0 {
+			return getCleanID(ext.ContextInfo.GetParticipant())
+		}
+	}
+
+	if len(args) > 0 {
+		num := strings.TrimLeft(strings.ReplaceAll(args[0], "+", ""), "@")
+		if _, err := strconv.ParseUint(getCleanID(num), 10, 64); err == nil {
+			return getCleanID(num)
+		}
+	}
+	return ""
+}
+
+// groupPermDecision: commandAccess کے لیے مختصر راستہ
+func groupPermDecision(v *events.Message, s *GroupSettings, c *Command, isAdm func() bool) permDecision {
+	if len(s.Perms) == 0 {
+		return permNone
+	}
+	return evalPermRules(s.Perms, c, senderPermKeys(v), isAdm)
+}
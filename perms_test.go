@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestEvalPermRules(t *testing.T) {
+	yt := &Command{Name: "yt", Category: CatDownload}
+	perm := &Command{Name: "perm", Category: CatSafety}
+	sender := []string{"923001", "88001"} // نمبر + LID
+
+	cases := []struct {
+		name  string
+		rules []PermRule
+		cmd   *Command
+		admin bool
+		want  permDecision
+	}{
+		{"no rules", nil, yt, false, permNone},
+		{"other command", []PermRule{{"deny", "tt", "all"}}, yt, false, permNone},
+		{"other category", []PermRule{{"deny", "cat:music", "all"}}, yt, false, permNone},
+		{"category deny", []PermRule{{"deny", "cat:download", "all"}}, yt, false, permDeny},
+		{"command beats category", []PermRule{{"deny", "cat:download", "all"}, {"allow", "yt", "all"}}, yt, false, permAllow},
+		{"number beats command", []PermRule{{"allow", "yt", "all"}, {"deny", "cat:download", "923001"}}, yt, false, permDeny},
+		{"lid matches", []PermRule{{"allow", "yt", "88001"}}, yt, false, permAllow},
+		{"tie goes to deny", []PermRule{{"allow", "yt", "all"}, {"deny", "yt", "all"}}, yt, false, permDeny},
+		{"tie goes to deny (order)", []PermRule{{"deny", "yt", "all"}, {"allow", "yt", "all"}}, yt, false, permDeny},
+		{"admins allow for admin", []PermRule{{"deny", "yt", "all"}, {"allow", "yt", "admins"}}, yt, true, permAllow},
+		{"admins allow skips member", []PermRule{{"deny", "yt", "all"}, {"allow", "yt", "admins"}}, yt, false, permDeny},
+		{"members deny skips admin", []PermRule{{"deny", "yt", "members"}}, yt, true, permNone},
+		{"members deny", []PermRule{{"deny", "yt", "members"}}, yt, false, permDeny},
+		{"perm never ruled", []PermRule{{"deny", "cat:safety", "all"}, {"deny", "perm", "923001"}}, perm, false, permNone},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			isAdm := func() bool { return tc.admin }
+			if got := evalPermRules(tc.rules, tc.cmd, sender, isAdm); got != tc.want {
+				t.Errorf("evalPermRules = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
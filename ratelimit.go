@@ -0,0 +1,218 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// =========================================================
+// 🚦 COMMAND RATE LIMITER (Token Bucket)
+// ہر bot+chat+sender کی اپنی بالٹی، اور گروپ میں پورے گروپ کی ایک مشترکہ بالٹی
+// (تاکہ بہت سے ممبر مل کر بوٹ کو نہ ڈبو دیں)۔ بھاری کمانڈز زیادہ ٹوکن کھاتی ہیں۔
+// حد سے بڑھنے پر ایک بار شائستہ جواب، پھر خاموشی۔ حدیں config (rate_limit) سے۔
+// =========================================================
+
+type rateBucket struct {
+	Tokens   float64
+	LastFill time.Time
+	LastUsed map[string]time.Time // کمانڈ → آخری استعمال (cooldown کے لیے)
+	Warned   bool                 // "slow down" بھیج دیا؟
+}
+
+var (
+	rateBuckets = make(map[string]*rateBucket)
+	rateMutex   sync.Mutex
+)
+
+const (
+	slowDownMsg      = "╔════════════════╗\n║ 🐢 SLOW DOWN\n╠════════════════╣\n║ Too many commands\n║ Please wait a bit\n║ and try again 🙏\n╚════════════════╝"
+	groupSlowDownMsg = "╔════════════════╗\n║ 🐢 SLOW DOWN\n╠════════════════╣\n║ This group sent\n║ too many commands\n║ Please wait a bit 🙏\n╚════════════════╝"
+)
+
+// refill: پچھلی بار سے اب تک کے ٹوکن (burst سے زیادہ نہیں)
+func (b *rateBucket) refill(now time.Time, burst, perMin float64) {
+	b.Tokens += now.Sub(b.LastFill).Minutes() * perMin
+	if b.Tokens > burst {
+		b.Tokens = burst
+	}
+	b.LastFill = now
+}
+
+// getRateBucket: rateMutex کے اندر
+func getRateBucket(key string, burst float64, now time.Time) *rateBucket {
+	b, ok := rateBuckets[key]
+	if !ok {
+		b = &rateBucket{Tokens: burst, LastFill: now, LastUsed: make(map[string]time.Time)}
+		rateBuckets[key] = b
+	}
+	return b
+}
+
+// commandCost: رجسٹری میں Cost نہ ہو تو کیٹیگری سے اندازہ
+func commandCost(c *Command) float64 {
+	if c.Cost > 0 {
+		return c.Cost
+	}
+	switch c.Category {
+	case CatGeneral:
+		return 0.5 // menu, ping, id جیسی ہلکی کمانڈز
+	case CatDownload, CatMusic, CatSocial:
+		return 3 // yt-dlp / ffmpeg
+	case CatTools, CatEditing:
+		return 2 // بیرونی API یا ffmpeg
+	}
+	return 1
+}
+
+// commandCooldown: رجسٹری کی ڈیفالٹ، config (rate_limit.cooldowns / CMD_COOLDOWN_<NAME>) سے اوور رائیڈ
+func commandCooldown(c *Command) time.Duration {
+	if secs, ok := Config().RateLimit.Cooldowns[c.Name]; ok {
+		return time.Duration(secs) * time.Second
+	}
+	return c.Cooldown
+}
+
+// allowCommandRate: (اجازت، جواب) — جواب خالی ہو تو خاموش رہیں
+func allowCommandRate(c *CommandContext, command *Command) (bool, string) {
+	if isOwner(c.Client, c.Msg.Info.Sender) {
+		return true, ""
+	}
+
+	rl := Config().RateLimit
+	cost := commandCost(command)
+	cooldown := commandCooldown(command)
+	now := time.Now()
+
+	rateMutex.Lock()
+	defer rateMutex.Unlock()
+
+	b := getRateBucket(c.BotID+"|"+c.ChatID+"|"+c.SenderID, rl.Burst, now)
+	b.refill(now, rl.Burst, rl.RefillPerMin)
+
+	limited := b.Tokens < cost
+	if cooldown > 0 {
+		if last, used := b.LastUsed[command.Name]; used && now.Sub(last) < cooldown {
+			limited = true
+		}
+	}
+	if limited {
+		if b.Warned {
+			return false, ""
+		}
+		b.Warned = true
+		botLog(c.BotID).Warn("🚦 rate limited", "chat", redactID(c.ChatID), "sender", redactID(c.SenderID), "cmd", command.Name)
+		return false, slowDownMsg
+	}
+
+	// 👥 گروپ کی مشترکہ بالٹی (یوزر کی بالٹی سے ٹوکن تبھی کٹیں جب گروپ میں بھی گنجائش ہو)
+	var g *rateBucket
+	if c.Msg.Info.IsGroup && rl.GroupBurst > 0 {
+		g = getRateBucket("group|"+c.BotID+"|"+c.ChatID, rl.GroupBurst, now)
+		g.refill(now, rl.GroupBurst, rl.GroupRefillPerMin)
+		if g.Tokens < cost {
+			if g.Warned {
+				return false, ""
+			}
+			g.Warned = true
+			botLog(c.BotID).Warn("🚦 group rate limited", "chat", redactID(c.ChatID), "cmd", command.Name)
+			return false, groupSlowDownMsg
+		}
+		g.Tokens -= cost
+		g.Warned = false
+	}
+
+	b.Tokens -= cost
+	b.LastUsed[command.Name] = now
+	b.Warned = false
+	return true, ""
+}
+
+// sendRateKeyPrefix: کلسٹر میں send بالٹی Redis میں (hash: tokens, ts) — سب انسٹینسز کی ایک حد
+const sendRateKeyPrefix = "rate:send:"
+
+// sendRateScript: وہی token bucket جو rateBucket.refill کرتا ہے، مگر Redis میں ایٹامک۔
+// ARGV: burst, per_min, now (ms)؛ واپسی: 0 = اجازت، ورنہ انتظار (ms)
+var sendRateScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local perMs = tonumber(ARGV[2]) / 60000
+local now = tonumber(ARGV[3])
+local b = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+if now > ts then
+	tokens = tokens + (now - ts) * perMs
+	ts = now
+end
+if tokens > burst then
+	tokens = burst
+end
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) / perMs)
+else
+	tokens = tokens - 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / perMs) + 60000)
+return wait`)
+
+// allowSendRate: API سے بھیجنے کی حد؛ رکاوٹ پر کتنی دیر بعد دوبارہ۔
+// کلسٹر میں بالٹی Redis میں ہے، ورنہ ہر انسٹینس اپنی الگ حد دیتا
+func allowSendRate(botID string) (bool, time.Duration) {
+	rl := Config().RateLimit
+	now := time.Now()
+
+	if clusterEnabled() {
+		wait, err := sendRateScript.Run(ctx, rdb, []string{sendRateKeyPrefix + botID}, rl.SendBurst, rl.SendPerMin, now.UnixMilli()).Int64()
+		if err == nil {
+			return wait == 0, time.Duration(wait) * time.Millisecond
+		}
+		botLog(botID).Warn("⚠️ shared send rate limit unavailable, using local bucket", "err", err)
+	}
+
+	rateMutex.Lock()
+	defer rateMutex.Unlock()
+
+	b := getRateBucket("api-send|"+botID, rl.SendBurst, now)
+	b.refill(now, rl.SendBurst, rl.SendPerMin)
+
+	if b.Tokens < 1 {
+		wait := time.Duration((1 - b.Tokens) / rl.SendPerMin * float64(time.Minute))
+		return false, wait
+	}
+	b.Tokens--
+	return true, 0
+}
+
+// rateBucketIdle: 10 منٹ سے خاموش اور کوئی cooldown ابھی جاری نہیں
+func rateBucketIdle(b *rateBucket, now time.Time) bool {
+	if now.Sub(b.LastFill) <= 10*time.Minute {
+		return false
+	}
+	for name, last := range b.LastUsed {
+		if c, ok := lookupCommand(name); ok && now.Sub(last) < commandCooldown(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// 🧹 پرانی بالٹیاں صاف کریں (خاموش، اور جن کا cooldown ختم ہو چکا)
+func StartRateLimitJanitor() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			rateMutex.Lock()
+			for k, b := range rateBuckets {
+				if rateBucketIdle(b, now) {
+					delete(rateBuckets, k)
+				}
+			}
+			rateMutex.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestCommandCost(t *testing.T) {
+	cases := []struct {
+		cmd  *Command
+		want float64
+	}{
+		{&Command{Category: CatGeneral}, 0.5},
+		{&Command{Category: CatDownload}, 3},
+		{&Command{Category: CatMusic}, 3},
+		{&Command{Category: CatTools}, 2},
+		{&Command{Category: CatAdmin}, 1},
+		{&Command{Category: CatDownload, Cost: 1.5}, 1.5}, // رجسٹری والی قیمت غالب
+	}
+	for _, tc := range cases {
+		if got := commandCost(tc.cmd); got != tc.want {
+			t.Errorf("commandCost(%s, cost %v) = %v, want %v", tc.cmd.Category, tc.cmd.Cost, got, tc.want)
+		}
+	}
+}
+
+func TestRateBucketRefill(t *testing.T) {
+	cases := []struct {
+		name            string
+		tokens, elapsed float64 // elapsed منٹوں میں
+		burst, perMin   float64
+		want            float64
+	}{
+		{"no time passed", 2, 0, 6, 6, 2},
+		{"half a minute", 2, 0.5, 6, 6, 5},
+		{"capped at burst", 2, 10, 6, 6, 6},
+		{"already full", 6, 1, 6, 6, 6},
+		{"no refill", 1, 5, 6, 0, 1},
+		{"burst lowered on reload", 20, 0, 6, 6, 6},
+	}
+	start := time.Unix(1735732800, 0)
+	for _, tc := range cases {
+		b := &rateBucket{Tokens: tc.tokens, LastFill: start}
+		now := start.Add(time.Duration(tc.elapsed * float64(time.Minute)))
+		b.refill(now, tc.burst, tc.perMin)
+		if b.Tokens != tc.want || !b.LastFill.Equal(now) {
+			t.Errorf("%s: tokens = %v (last fill %v), want %v", tc.name, b.Tokens, b.LastFill, tc.want)
+		}
+	}
+}
+
+func TestAllowCommandRate(t *testing.T) {
+	withTestConfig(t, func(c *ConfigStruct) {
+		c.RateLimit.Burst = 3
+		c.RateLimit.RefillPerMin = 0
+	})
+
+	cmd := &Command{Name: "zzcost", Cost: 1}
+	c := testCommandContext(t, testUser, ".zzcost")
+	clearRateBucket(t, c)
+
+	for i := 0; i < 3; i++ {
+		if ok, msg := allowCommandRate(c, cmd); !ok || msg != "" {
+			t.Fatalf("call %d: ok=%v msg=%q, want allowed", i+1, ok, msg)
+		}
+	}
+	// پہلی بار شائستہ جواب، پھر خاموشی
+	if ok, msg := allowCommandRate(c, cmd); ok || msg != slowDownMsg {
+		t.Errorf("over burst: ok=%v msg=%q, want slow-down reply", ok, msg)
+	}
+	if ok, msg := allowCommandRate(c, cmd); ok || msg != "" {
+		t.Errorf("repeat over burst: ok=%v msg=%q, want silent", ok, msg)
+	}
+
+	// بالٹی بھرنے پر دوبارہ اجازت اور نئی وارننگ
+	rateMutex.Lock()
+	b := rateBuckets[c.BotID+"|"+c.ChatID+"|"+c.SenderID]
+	b.Tokens = 3
+	rateMutex.Unlock()
+	if ok, _ := allowCommandRate(c, cmd); !ok {
+		t.Error("refilled bucket still limited")
+	}
+	rateMutex.Lock()
+	warned := b.Warned
+	rateMutex.Unlock()
+	if warned {
+		t.Error("allowed call did not reset the warning")
+	}
+
+	// اونر پر کوئی حد نہیں
+	owner := testCommandContext(t, testBotLID, ".zzcost")
+	for i := 0; i < 10; i++ {
+		if ok, _ := allowCommandRate(owner, cmd); !ok {
+			t.Fatalf("owner limited on call %d", i+1)
+		}
+	}
+}
+
+func TestCommandCooldown(t *testing.T) {
+	withTestConfig(t, func(c *ConfigStruct) {
+		c.RateLimit.Burst = 10
+		c.RateLimit.RefillPerMin = 0
+		c.RateLimit.Cooldowns = map[string]int{"zzcfg": 3600}
+	})
+
+	slow := &Command{Name: "zzslow", Cost: 1, Cooldown: time.Hour}
+	other := &Command{Name: "zzother", Cost: 1}
+	configured := &Command{Name: "zzcfg", Cost: 1} // cooldown صرف config سے
+	c := testCommandContext(t, testUser, ".zzslow")
+	key := clearRateBucket(t, c)
+
+	if ok, _ := allowCommandRate(c, slow); !ok {
+		t.Fatal("first call limited")
+	}
+	if ok, msg := allowCommandRate(c, slow); ok || msg == "" {
+		t.Errorf("second call in cooldown: ok=%v msg=%q", ok, msg)
+	}
+	// cooldown صرف اسی کمانڈ پر
+	if ok, _ := allowCommandRate(c, other); !ok {
+		t.Error("cooldown blocked a different command")
+	}
+	if ok, _ := allowCommandRate(c, configured); !ok {
+		t.Fatal("first configured call limited")
+	}
+	if ok, _ := allowCommandRate(c, configured); ok {
+		t.Error("rate_limit.cooldowns not applied")
+	}
+
+	rateMutex.Lock()
+	rateBuckets[key].LastUsed["zzslow"] = time.Now().Add(-2 * time.Hour)
+	rateMutex.Unlock()
+	if ok, _ := allowCommandRate(c, slow); !ok {
+		t.Error("still limited after the cooldown passed")
+	}
+}
+
+func TestAllowSendRate(t *testing.T) {
+	withTestConfig(t, func(c *ConfigStruct) {
+		c.RateLimit.SendBurst = 3
+		c.RateLimit.SendPerMin = 60 // ہر سیکنڈ ایک ٹوکن
+	})
+
+	const bot = "923009990001"
+	key := "api-send|" + bot
+	t.Cleanup(func() {
+		rateMutex.Lock()
+		delete(rateBuckets, key)
+		delete(rateBuckets, "api-send|923009990002")
+		rateMutex.Unlock()
+	})
+
+	for i := 0; i < 3; i++ {
+		if ok, wait := allowSendRate(bot); !ok || wait != 0 {
+			t.Fatalf("send %d: ok=%v wait=%v, want allowed", i+1, ok, wait)
+		}
+	}
+	ok, wait := allowSendRate(bot)
+	if ok {
+		t.Fatal("4th send allowed past burst")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("wait = %v, want (0, 1s]", wait)
+	}
+
+	// دو سیکنڈ پیچھے = دو ٹوکن واپس
+	rateMutex.Lock()
+	rateBuckets[key].LastFill = rateBuckets[key].LastFill.Add(-2 * time.Second)
+	rateMutex.Unlock()
+	for i := 0; i < 2; i++ {
+		if ok, _ := allowSendRate(bot); !ok {
+			t.Fatalf("send after refill %d denied", i+1)
+		}
+	}
+	if ok, _ := allowSendRate(bot); ok {
+		t.Error("refill gave more than two tokens")
+	}
+
+	// دوسرے بوٹ کی اپنی بالٹی
+	if ok, _ := allowSendRate("923009990002"); !ok {
+		t.Error("other bot shares the bucket")
+	}
+}
+
+// TestAllowSendRateCluster: TEST_REDIS_URL نہ ہو تو skip
+func TestAllowSendRateCluster(t *testing.T) {
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("parse redis url: %v", err)
+	}
+	prev := rdb
+	rdb = redis.NewClient(opt)
+	t.Cleanup(func() {
+		rdb.Close()
+		rdb = prev
+	})
+	withTestConfig(t, func(c *ConfigStruct) {
+		c.Cluster.Enabled = true
+		c.RateLimit.SendBurst = 3
+		c.RateLimit.SendPerMin = 60
+	})
+
+	bot := testBotIDs(1)[0]
+	key := sendRateKeyPrefix + bot
+	t.Cleanup(func() { rdb.Del(context.Background(), key) })
+
+	for i := 0; i < 3; i++ {
+		if ok, wait := allowSendRate(bot); !ok || wait != 0 {
+			t.Fatalf("send %d: ok=%v wait=%v, want allowed", i+1, ok, wait)
+		}
+	}
+	ok, wait := allowSendRate(bot)
+	if ok || wait <= 0 || wait > time.Second {
+		t.Errorf("4th send: ok=%v wait=%v, want denied with wait in (0, 1s]", ok, wait)
+	}
+	rateMutex.Lock()
+	_, local := rateBuckets["api-send|"+bot]
+	rateMutex.Unlock()
+	if local {
+		t.Error("cluster mode used the local bucket")
+	}
+
+	// دو سیکنڈ پیچھے = دو ٹوکن واپس (کسی بھی انسٹینس کے لیے)
+	rdb.HSet(context.Background(), key, "ts", time.Now().Add(-2*time.Second).UnixMilli())
+	for i := 0; i < 2; i++ {
+		if ok, _ := allowSendRate(bot); !ok {
+			t.Fatalf("send after refill %d denied", i+1)
+		}
+	}
+	if ok, _ := allowSendRate(bot); ok {
+		t.Error("refill gave more than two tokens")
+	}
+}
+
+// testGroupContext: وہی سینڈر، مگر گروپ سے
+func testGroupContext(t *testing.T, sender string) *CommandContext {
+	t.Helper()
+	c := testCommandContext(t, sender, ".zzgroup")
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	c.Msg.Info.Chat = group
+	c.Msg.Info.IsGroup = true
+	c.ChatID = group.String()
+	clearRateBucket(t, c)
+	return c
+}
+
+func TestGroupRateBucket(t *testing.T) {
+	withTestConfig(t, func(c *ConfigStruct) {
+		c.RateLimit.Burst = 10
+		c.RateLimit.RefillPerMin = 0
+		c.RateLimit.GroupBurst = 3
+		c.RateLimit.GroupRefillPerMin = 0
+	})
+	cmd := &Command{Name: "zzgroup", Cost: 1}
+	a, b := testGroupContext(t, "923001110001"), testGroupContext(t, "923001110002")
+	groupKey := "group|" + a.BotID + "|" + a.ChatID
+	t.Cleanup(func() {
+		rateMutex.Lock()
+		delete(rateBuckets, groupKey)
+		rateMutex.Unlock()
+	})
+
+	for i, c := range []*CommandContext{a, a, b} {
+		if ok, _ := allowCommandRate(c, cmd); !ok {
+			t.Fatalf("call %d limited inside the group burst", i+1)
+		}
+	}
+	// ہر یوزر کی اپنی بالٹی میں گنجائش ہے، مگر گروپ کی بالٹی خالی
+	if ok, msg := allowCommandRate(b, cmd); ok || msg != groupSlowDownMsg {
+		t.Errorf("over group burst: ok=%v msg=%q, want group slow-down", ok, msg)
+	}
+	if ok, msg := allowCommandRate(a, cmd); ok || msg != "" {
+		t.Errorf("repeat over group burst: ok=%v msg=%q, want silent", ok, msg)
+	}
+
+	// گروپ کی حد پر یوزر کے ٹوکن نہیں کٹتے
+	rateMutex.Lock()
+	tokens := rateBuckets[b.BotID+"|"+b.ChatID+"|"+b.SenderID].Tokens
+	rateMutex.Unlock()
+	if tokens != 9 {
+		t.Errorf("user tokens = %v after one allowed call, want 9", tokens)
+	}
+
+	// DM پر گروپ کی بالٹی لاگو نہیں
+	dm := testCommandContext(t, "923001110001", ".zzgroup")
+	clearRateBucket(t, dm)
+	if ok, _ := allowCommandRate(dm, cmd); !ok {
+		t.Error("group bucket applied to a DM")
+	}
+}
+
+func TestRateBucketIdle(t *testing.T) {
+	registerTestCommand(t, &Command{Name: "zzidle", Cooldown: time.Hour, Handler: func(*CommandContext) {}})
+	now := time.Now()
+
+	cases := []struct {
+		name string
+		b    *rateBucket
+		want bool
+	}{
+		{"recently used", &rateBucket{LastFill: now.Add(-time.Minute)}, false},
+		{"quiet", &rateBucket{LastFill: now.Add(-11 * time.Minute)}, true},
+		{"quiet, cooldown running", &rateBucket{LastFill: now.Add(-11 * time.Minute), LastUsed: map[string]time.Time{"zzidle": now.Add(-11 * time.Minute)}}, false},
+		{"quiet, cooldown over", &rateBucket{LastFill: now.Add(-2 * time.Hour), LastUsed: map[string]time.Time{"zzidle": now.Add(-2 * time.Hour)}}, true},
+		{"quiet, unknown command", &rateBucket{LastFill: now.Add(-11 * time.Minute), LastUsed: map[string]time.Time{"zzgone": now}}, true},
+	}
+	for _, tc := range cases {
+		if got := rateBucketIdle(tc.b, now); got != tc.want {
+			t.Errorf("%s: rateBucketIdle = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =========================================================
+// 🩺 READINESS (/readyz)
+// Postgres / Redis / Mongo کو واقعی ping (ٹائم آؤٹ کے ساتھ)، باہر کے ٹولز
+// (yt-dlp, ffmpeg ...) اور ہر بوٹ کی کنکشن حالت۔
+//   ready    = سب ٹھیک (200)
+//   degraded = چل رہا ہے مگر کچھ کمی — Mongo، کوئی ٹول یا کوئی بوٹ (200)
+//   down     = Postgres یا Redis نہیں — سروس کام نہیں کر سکتی (503)
+// =========================================================
+
+const (
+	ReadyOK       = "ready"
+	ReadyDegraded = "degraded"
+	ReadyDown     = "down"
+
+	probeTimeout = 2 * time.Second
+
+	// readyCacheTTL: بار بار /readyz پر ڈیٹا بیس کو ping نہ ہو
+	readyCacheTTL = 5 * time.Second
+)
+
+// readinessTools: کمانڈز انہی پر چلتی ہیں (PATH میں ہونے چاہئیں)
+var readinessTools = []string{"yt-dlp", "ffmpeg", "ffprobe", "python3", "node"}
+
+// ProbeResult: ایک dependency کا نتیجہ
+type ProbeResult struct {
+	Status    string `json:"status"` // ok / down / disabled
+	Required  bool   `json:"required"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BotReadiness: اس انسٹینس پر چلنے والا ایک بوٹ
+type BotReadiness struct {
+	Connected bool   `json:"connected"`
+	LoggedIn  bool   `json:"logged_in"`
+	State     string `json:"state,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type ReadinessReport struct {
+	Status   string                  `json:"status"`
+	OK       bool                    `json:"ok"` // پرانے کلائنٹس کے لیے: down کے علاوہ true
+	Instance string                  `json:"instance"`
+	Checks   map[string]ProbeResult  `json:"checks"`
+	Tools    map[string]string       `json:"tools"`   // ٹول → path (یا "missing")
+	Storage  map[string]string       `json:"storage"` // اسٹور → backend (storage.go)
+	Bots     map[string]BotReadiness `json:"bots,omitempty"`
+	BotsUp   int                     `json:"bots_up"`
+	BotsDown int                     `json:"bots_down"`
+	Reasons  []string                `json:"reasons,omitempty"`
+}
+
+// runProbe: ٹائم آؤٹ کے ساتھ ایک ping
+func runProbe(required bool, fn func(ctx context.Context) error) ProbeResult {
+	pctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	start := time.Now()
+	err := fn(pctx)
+	res := ProbeResult{Status: "ok", Required: required, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Status = "down"
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// ReadinessSummary: بغیر کلید کے یہی ملتا ہے — اندرونی تفصیل نہیں
+type ReadinessSummary struct {
+	Status   string `json:"status"`
+	OK       bool   `json:"ok"`
+	BotsUp   int    `json:"bots_up"`
+	BotsDown int    `json:"bots_down"`
+}
+
+var (
+	readyCacheMu sync.Mutex
+	readyCached  ReadinessReport
+	readyCacheAt time.Time
+)
+
+// cachedReadiness: readyCacheTTL تک پچھلا نتیجہ؛ lock کے اندر چلتا ہے تاکہ
+// ایک ساتھ آنے والی درخواستیں الگ الگ probe نہ کریں
+func cachedReadiness() ReadinessReport {
+	readyCacheMu.Lock()
+	defer readyCacheMu.Unlock()
+	if readyCacheAt.IsZero() || time.Since(readyCacheAt) >= readyCacheTTL {
+		readyCached = CheckReadiness()
+		readyCacheAt = time.Now()
+	}
+	report := readyCached
+	// Bots ہر درخواست پر فلٹر ہوتا ہے — cache والا map نہ بدلے
+	report.Bots = make(map[string]BotReadiness, len(readyCached.Bots))
+	for id, b := range readyCached.Bots {
+		report.Bots[id] = b
+	}
+	return report
+}
+
+func disabledProbe(required bool) ProbeResult {
+	return ProbeResult{Status: "disabled", Required: required}
+}
+
+// CheckReadiness: تمام probes متوازی (کل وقت ≈ probeTimeout سے زیادہ نہیں)
+func CheckReadiness() ReadinessReport {
+	type probe struct {
+		name     string
+		required bool
+		fn       func(ctx context.Context) error // nil = configure نہیں
+	}
+	probes := []probe{
+		{name: "postgres", required: true},
+		{name: "redis", required: true},
+		{name: "mongo", required: false},
+	}
+	if sessionDB != nil {
+		probes[0].fn = func(c context.Context) error { return sessionDB.PingContext(c) }
+	}
+	if rdb != nil {
+		probes[1].fn = func(c context.Context) error { return rdb.Ping(c).Err() }
+	}
+	if mongoClient != nil {
+		probes[2].fn = func(c context.Context) error { return mongoClient.Ping(c, nil) }
+	}
+
+	report := ReadinessReport{
+		Status:   ReadyOK,
+		Instance: InstanceID(),
+		Checks:   make(map[string]ProbeResult, len(probes)),
+		Tools:    make(map[string]string, len(readinessTools)),
+		Storage:  storageBackends,
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, p := range probes {
+		if p.fn == nil {
+			report.Checks[p.name] = disabledProbe(p.required)
+			continue
+		}
+		wg.Add(1)
+		go func(p probe) {
+			defer wg.Done()
+			res := runProbe(p.required, p.fn)
+			mu.Lock()
+			report.Checks[p.name] = res
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	degrade := func(reason string) {
+		if report.Status == ReadyOK {
+			report.Status = ReadyDegraded
+		}
+		report.Reasons = append(report.Reasons, reason)
+	}
+
+	names := make([]string, 0, len(report.Checks))
+	for name := range report.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		res := report.Checks[name]
+		switch {
+		case res.Status == "ok":
+		case res.Required:
+			report.Status = ReadyDown
+			report.Reasons = append(report.Reasons, name+" "+res.Status)
+		case res.Status == "down":
+			degrade(name + " down")
+		}
+	}
+
+	// 🛠️ ٹولز
+	for _, tool := range readinessTools {
+		path, err := exec.LookPath(tool)
+		if err != nil {
+			report.Tools[tool] = "missing"
+			degrade(tool + " missing")
+			continue
+		}
+		report.Tools[tool] = path
+	}
+
+	// 🤖 بوٹس (صرف اس انسٹینس کے)
+	report.Bots = make(map[string]BotReadiness)
+	clientsMutex.RLock()
+	for id, c := range activeClients {
+		b := BotReadiness{}
+		if c != nil {
+			b.Connected = c.IsConnected()
+			b.LoggedIn = c.IsLoggedIn()
+		}
+		report.Bots[id] = b
+	}
+	clientsMutex.RUnlock()
+	for id, b := range report.Bots {
+		if h, ok := GetBotHealth(id); ok {
+			b.State = h.State
+			if h.State != BotOnline {
+				b.LastError = h.LastError
+			}
+		}
+		if b.Connected && b.LoggedIn {
+			report.BotsUp++
+		} else {
+			report.BotsDown++
+		}
+		report.Bots[id] = b
+	}
+	if report.BotsDown > 0 {
+		degrade("bots offline")
+	}
+
+	report.OK = report.Status != ReadyDown
+	return report
+}
+
+// 🌐 GET /readyz — بغیر کلید کے صرف حالت اور گنتی؛ کلید ہو تو پوری رپورٹ
+// (صرف اپنے بوٹس کے ساتھ)
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := cachedReadiness()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == ReadyDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	key, err := authenticate(r)
+	if err != nil {
+		_ = json.NewEncoder(w).Encode(ReadinessSummary{
+			Status:   report.Status,
+			OK:       report.OK,
+			BotsUp:   report.BotsUp,
+			BotsDown: report.BotsDown,
+		})
+		return
+	}
+
+	for id := range report.Bots {
+		if !key.CanAccessBot(id) {
+			delete(report.Bots, id)
+		}
+	}
+	if len(report.Bots) == 0 {
+		report.Bots = nil
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// =========================================================
+// 📚 COMMAND REGISTRY
+// ہر کمانڈ صرف ایک جگہ رجسٹر ہوتی ہے۔ ڈسپیچ، پرمیشن چیک،
+// مینو اور isKnownCommand سب اسی رجسٹری سے بنتے ہیں۔
+// =========================================================
+
+// CommandRole کمانڈ چلانے کے لیے کم از کم درکار رول
+type CommandRole int
+
+const (
+	RoleAny   CommandRole = iota // ہر کوئی
+	RoleAdmin                    // گروپ ایڈمن (یا اونر)
+	RoleOwner                    // صرف اونر
+)
+
+func (r CommandRole) String() string {
+	switch r {
+	case RoleOwner:
+		return "owner"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "any"
+}
+
+// 🗂️ Categories (مینو میں اسی ترتیب سے سیکشن بنتے ہیں)
+const (
+	CatGeneral  = "general"
+	CatDownload = "download"
+	CatMusic    = "music"
+	CatSocial   = "social"
+	CatTools    = "tools"
+	CatEditing  = "editing"
+	CatSafety   = "safety"
+	CatAdmin    = "admin"
+	CatPrivate  = "private"
+	CatOwner    = "owner"
+)
+
+type menuSection struct {
+	Category string
+	Title    string
+}
+
+var menuSections = []menuSection{
+	{CatGeneral, "🌸 𝐆𝐞𝐧𝐞𝐫𝐚𝐥 🌸"},
+	{CatDownload, "🍭 𝐃𝐨𝐰𝐧𝐥𝐨𝐚𝐝𝐬 🍭"},
+	{CatMusic, "🧸 𝐌𝐮𝐬𝐢𝐜 𝐋𝐨𝐯𝐞 🧸"},
+	{CatSocial, "🎀 𝐒𝐨𝐜𝐢𝐚𝐥 𝐌𝐞𝐝𝐢𝐚 🎀"},
+	{CatTools, "✨ 𝐌𝐚𝐠𝐢𝐜 𝐓𝐨𝐨𝐥𝐬 ✨"},
+	{CatEditing, "🎨 𝐄𝐝𝐢𝐭𝐢𝐧𝐠 𝐙𝐨𝐧𝐞 🎨"},
+	{CatSafety, "🛡️ 𝐆𝐫𝐨𝐮𝐩 𝐒𝐚𝐟𝐞𝐭𝐲 🛡️"},
+	{CatAdmin, "🏰 𝐀𝐝𝐦𝐢𝐧 𝐏𝐨𝐰𝐞𝐫 🏰"},
+	{CatPrivate, "🔒 𝐏𝐫𝐢𝐯𝐚𝐭𝐞 𝐓𝐨𝐨𝐥𝐬 🔒"},
+	{CatOwner, "👑 𝐌𝐲 𝐊𝐢𝐧𝐠𝐝𝐨𝐦 👑"},
+}
+
+// CommandContext ہینڈلر کو ملنے والا سارا ڈیٹا
+type CommandContext struct {
+	Client   *whatsmeow.Client
+	Msg      *events.Message
+	BotID    string
+	ChatID   string
+	SenderID string
+	Prefix   string
+	Cmd      string   // یوزر نے جو نام/عرف لکھا (lowercase)
+	Args     []string // کمانڈ کے بعد والے الفاظ
+	FullArgs string   // Args ایک سٹرنگ میں
+	Body     string   // پورا صاف میسج (prefix سمیت)
+}
+
+// Command ایک رجسٹرڈ کمانڈ کی تعریف
+type Command struct {
+	Name      string
+	Aliases   []string
+	Category  string
+	Role      CommandRole
+	GroupOnly bool
+	DMOnly    bool
+	NeedArgs  bool          // خالی ہو تو Usage دکھا کر رک جائے
+	Usage     string        // بغیر prefix کے، مثلاً "yt [YouTube Link]"
+	Desc      string        // مینو میں دکھنے والی لائن
+	React     string        // چلنے سے پہلے کا ری ایکشن (خالی = کوئی نہیں)
+	Hidden    bool          // مینو میں نہ دکھائیں
+	Cost      float64       // ریٹ لمٹ ٹوکن (0 = کیٹیگری ڈیفالٹ)
+	Cooldown  time.Duration // اسی کمانڈ کو دوبارہ چلانے کا وقفہ
+	Handler   func(c *CommandContext)
+}
+
+var (
+	commandIndex  = make(map[string]*Command) // name + aliases → command
+	commandList   []*Command                  // رجسٹریشن کی ترتیب
+	registryMutex sync.RWMutex
+)
+
+// RegisterCommand نئی کمانڈ رجسٹری میں ڈالتا ہے
+func RegisterCommand(c *Command) {
+	if c == nil || c.Name == "" || c.Handler == nil {
+		return
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	c.Name = strings.ToLower(c.Name)
+	for _, key := range append([]string{c.Name}, c.Aliases...) {
+		key = strings.ToLower(key)
+		if old, exists := commandIndex[key]; exists {
+			fmt.Printf("⚠️ [REGISTRY] '%s' already bound to .%s, overriding with .%s\n", key, old.Name, c.Name)
+		}
+		commandIndex[key] = c
+	}
+	commandList = append(commandList, c)
+}
+
+// lookupCommand نام یا عرف سے کمانڈ ڈھونڈتا ہے
+func lookupCommand(name string) (*Command, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	c, ok := commandIndex[strings.ToLower(name)]
+	return c, ok
+}
+
+// allCommands رجسٹریشن کی ترتیب میں کاپی واپس کرتا ہے
+func allCommands() []*Command {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	out := make([]*Command, len(commandList))
+	copy(out, commandList)
+	return out
+}
+
+// =========================================================
+// 🛡️ ACCESS CONTROL
+// =========================================================
+
+const (
+	denyOwnerOnly = "╔════════════════╗\n║ ❌ ACCESS DENIED\n╠════════════════╣\n║ 🔒 Owner Only\n╚════════════════╝"
+	denyAdminOnly = "╔════════════════╗\n║ ❌ ACCESS DENIED\n╠════════════════╣\n║ 👮 Admins Only\n╚════════════════╝"
+	denyGroupOnly = "╔════════════════╗\n║ ❌ GROUP ONLY\n╠════════════════\n║ This command\n║ works only in\n║ group chats\n╚════════════════"
+	denyDMOnly    = "╔════════════════╗\n║ ❌ DM ONLY\n╠════════════════\n║ This command\n║ works only in\n║ private chat\n╚════════════════"
+)
+
+// commandAccess بتاتا ہے کہ کمانڈ چل سکتی ہے یا نہیں۔
+// انکار کی صورت میں جواب کا ٹیکسٹ بھی دیتا ہے (خالی = خاموش رہو)
+func commandAccess(client *whatsmeow.Client, v *events.Message, c *Command) (bool, string) {
+	owner := isOwner(client, v.Info.Sender)
+
+	// ایڈمن چیک صرف ضرورت پڑنے پر (isAdmin کیشڈ ہے، پھر بھی ایک بار)
+	adminChecked, admin := false, false
+	isAdm := func() bool {
+		if !adminChecked {
+			admin = v.Info.IsGroup && isAdmin(client, v.Info.Chat, v.Info.Sender)
+			adminChecked = true
+		}
+		return admin
+	}
+
+	// 1. گروپ کے .perm رولز اور موڈ (private/admin میں غیر متعلقہ لوگوں کو خاموشی)
+	decision := permNone
+	if !owner && v.Info.IsGroup {
+		botID := getCleanID(client.Store.ID.User)
+		s := getGroupSettings(botID, v.Info.Chat.String())
+
+		decision = groupPermDecision(v, s, c, isAdm)
+		if decision == permDeny {
+			return false, denyByRule
+		}
+		if decision != permAllow {
+			if s.Mode == "private" {
+				return false, ""
+			}
+			if s.Mode == "admin" && !isAdm() {
+				return false, ""
+			}
+		}
+	}
+
+	// 2. Scope
+	if c.GroupOnly && !v.Info.IsGroup {
+		return false, denyGroupOnly
+	}
+	if c.DMOnly && v.Info.IsGroup {
+		return false, denyDMOnly
+	}
+
+	// 3. Role — کم از کم حد: .perm allow بھی ایڈمن/اونر والی کمانڈ عام ممبر کو نہیں دیتا
+	if owner {
+		return true, ""
+	}
+	switch c.Role {
+	case RoleOwner:
+		return false, denyOwnerOnly
+	case RoleAdmin:
+		if !isAdm() {
+			return false, denyAdminOnly
+		}
+	}
+	return true, ""
+}
+
+// ⚡ PERMISSION CHECK FUNCTION (Registry based)
+func canExecute(client *whatsmeow.Client, v *events.Message, cmd string) bool {
+	c, ok := lookupCommand(cmd)
+	if !ok {
+		return false
+	}
+	allowed, _ := commandAccess(client, v, c)
+	return allowed
+}
+
+// isKnownCommand: کیا ٹیکسٹ (بغیر prefix) کسی رجسٹرڈ کمانڈ سے شروع ہوتا ہے؟
+func isKnownCommand(text string) bool {
+	words := strings.Fields(strings.ToLower(strings.TrimSpace(text)))
+	if len(words) == 0 {
+		return false
+	}
+	_, ok := lookupCommand(words[0])
+	return ok
+}
+
+// =========================================================
+// 🚀 DISPATCH
+// =========================================================
+
+// newCommandContext صاف میسج سے cmd/args نکالتا ہے (prefix پہلے سے چیک شدہ ہو)
+func newCommandContext(client *whatsmeow.Client, v *events.Message, botID, prefix, body string) *CommandContext {
+	words := strings.Fields(strings.TrimPrefix(body, prefix))
+	if len(words) == 0 {
+		return nil
+	}
+
+	var args []string
+	if len(words) > 1 {
+		args = words[1:]
+	}
+
+	return &CommandContext{
+		Client:   client,
+		Msg:      v,
+		BotID:    botID,
+		ChatID:   v.Info.Chat.String(),
+		SenderID: v.Info.Sender.ToNonAD().String(),
+		Prefix:   prefix,
+		Cmd:      strings.ToLower(words[0]),
+		Args:     args,
+		FullArgs: strings.TrimSpace(strings.Join(args, " ")),
+		Body:     body,
+	}
+}
+
+// dispatchCommand رجسٹری سے کمانڈ نکال کر پرمیشن چیک کے بعد چلاتا ہے
+func dispatchCommand(c *CommandContext) {
+	if c == nil {
+		return
+	}
+
+	command, ok := lookupCommand(c.Cmd)
+	if !ok {
+		return
+	}
+
+	// 🛡️ PERMISSION CHECK
+	allowed, denial := commandAccess(c.Client, c.Msg, command)
+	if !allowed {
+		mCommands.Inc(command.Name, "denied")
+		if denial != "" {
+			replyMessage(c.Client, c.Msg, denial)
+		}
+		return
+	}
+
+	// ⚠️ USAGE — ٹوکن کٹنے سے پہلے (غلط استعمال پر بالٹی خالی نہ ہو)
+	if command.NeedArgs && c.FullArgs == "" {
+		mCommands.Inc(command.Name, "usage")
+		replyMessage(c.Client, c.Msg, "⚠️ *Usage:* "+c.Prefix+command.Usage)
+		return
+	}
+
+	// 🚦 RATE LIMIT
+	if ok, msg := allowCommandRate(c, command); !ok {
+		mCommands.Inc(command.Name, "rate_limited")
+		if msg != "" {
+			replyMessage(c.Client, c.Msg, msg)
+		}
+		return
+	}
+
+	// Log Command
+	botLog(c.BotID).Info("🚀 exec", "chat", redactID(c.ChatID), "sender", redactID(c.SenderID), "cmd", command.Name)
+
+	if command.React != "" {
+		react(c.Client, c.Msg.Info.Chat, c.Msg.Info.ID, command.React)
+	}
+
+	// 📈 /metrics: گنتی + وقت (panic بھی ریکارڈ، پھر آگے)
+	start := time.Now()
+	defer func() {
+		mCommandLatency.Since(start, command.Name)
+		if r := recover(); r != nil {
+			mCommands.Inc(command.Name, "panic")
+			panic(r)
+		}
+		mCommands.Inc(command.Name, "ok")
+	}()
+	command.Handler(c)
+}
+
+// =========================================================
+// 📂 MENU BUILDER
+// =========================================================
+
+// buildMenuSections رجسٹری سے مینو کے سارے سیکشن بناتا ہے
+func buildMenuSections(prefix string) string {
+	byCategory := make(map[string][]*Command)
+	for _, c := range allCommands() {
+		if c.Hidden {
+			continue
+		}
+		byCategory[c.Category] = append(byCategory[c.Category], c)
+	}
+
+	var sb strings.Builder
+	for _, sec := range menuSections {
+		cmds := byCategory[sec.Category]
+		if len(cmds) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n ╭── %s ──╮\n", sec.Title))
+		for _, c := range cmds {
+			sb.WriteString(fmt.Sprintf(" │ ❥ *%s%s* - %s\n", prefix, c.Name, c.Desc))
+		}
+		sb.WriteString(" ╰───────────────╯\n")
+	}
+	return sb.String()
+}
+
+// =========================================================
+// 🔌 HANDLER ADAPTERS (پرانے فنکشنز کو رجسٹری کے ساتھ جوڑنے کے لیے)
+// =========================================================
+
+func plainCmd(fn func(*whatsmeow.Client, *events.Message)) func(*CommandContext) {
+	return func(c *CommandContext) { fn(c.Client, c.Msg) }
+}
+
+func argsCmd(fn func(*whatsmeow.Client, *events.Message, []string)) func(*CommandContext) {
+	return func(c *CommandContext) { fn(c.Client, c.Msg, c.Args) }
+}
+
+func queryCmd(fn func(*whatsmeow.Client, *events.Message, string)) func(*CommandContext) {
+	return func(c *CommandContext) { fn(c.Client, c.Msg, c.FullArgs) }
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	testBotLID = "88001"        // بوٹ کی اپنی LID — اسی سے بھیجنے والا اونر ہے
+	testUser   = "923001112222" // عام یوزر
+)
+
+// registerTestCommand: ٹیسٹ ختم ہونے پر رجسٹری سے ہٹا دیں
+func registerTestCommand(t *testing.T, c *Command) {
+	t.Helper()
+	RegisterCommand(c)
+	t.Cleanup(func() {
+		registryMutex.Lock()
+		for _, key := range append([]string{c.Name}, c.Aliases...) {
+			delete(commandIndex, key)
+		}
+		commandList = slices.DeleteFunc(commandList, func(x *Command) bool { return x == c })
+		registryMutex.Unlock()
+	})
+}
+
+// testCommandContext: بغیر لاگ ان کلائنٹ سے DM — جوابات ErrNotLoggedIn پر خاموشی سے گرتے ہیں
+func testCommandContext(t *testing.T, sender, body string) *CommandContext {
+	t.Helper()
+	lid := types.NewJID(testBotLID, types.HiddenUserServer)
+	client := &whatsmeow.Client{Store: &store.Device{LID: lid}}
+	server := types.DefaultUserServer
+	if sender == testBotLID {
+		server = types.HiddenUserServer
+	}
+	jid := types.NewJID(sender, server)
+	v := &events.Message{Info: types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: jid, Sender: jid},
+		ID:            "TESTMSG",
+	}}
+	c := newCommandContext(client, v, "923000000001", ".", body)
+	if c == nil {
+		t.Fatalf("newCommandContext(%q) = nil", body)
+	}
+	return c
+}
+
+func TestRegisterCommandLookup(t *testing.T) {
+	cmd := &Command{Name: "ZZLook", Aliases: []string{"zzl", "zzfind"}, Handler: func(*CommandContext) {}}
+	registerTestCommand(t, cmd)
+
+	for _, name := range []string{"zzlook", "ZZLOOK", "zzl", "ZzFind"} {
+		got, ok := lookupCommand(name)
+		if !ok || got != cmd {
+			t.Errorf("lookupCommand(%q) = %v, %v", name, got, ok)
+		}
+	}
+	if _, ok := lookupCommand("zzmissing"); ok {
+		t.Error("lookupCommand found an unregistered command")
+	}
+	if !isKnownCommand("  ZZL some args") || isKnownCommand("zzmissing zzl") || isKnownCommand("   ") {
+		t.Error("isKnownCommand only looks at the first word")
+	}
+
+	// نام کے بغیر یا ہینڈلر کے بغیر کمانڈ رجسٹر نہیں ہوتی
+	before := len(allCommands())
+	RegisterCommand(&Command{Name: "", Handler: func(*CommandContext) {}})
+	RegisterCommand(&Command{Name: "zznohandler"})
+	if len(allCommands()) != before {
+		t.Error("invalid command was registered")
+	}
+}
+
+func TestNewCommandContext(t *testing.T) {
+	c := testCommandContext(t, testUser, ".YT  some   song ")
+	if c.Cmd != "yt" || c.FullArgs != "some song" || !slices.Equal(c.Args, []string{"some", "song"}) {
+		t.Errorf("context = cmd %q args %q full %q", c.Cmd, c.Args, c.FullArgs)
+	}
+	if c.SenderID != testUser+"@s.whatsapp.net" || c.ChatID != c.SenderID {
+		t.Errorf("ids = sender %q chat %q", c.SenderID, c.ChatID)
+	}
+	if c := testCommandContext(t, testUser, ".ping"); c.Args != nil || c.FullArgs != "" {
+		t.Errorf("no-arg context = args %q full %q", c.Args, c.FullArgs)
+	}
+}
+
+func TestDispatchCommandOrder(t *testing.T) {
+	var ran atomic.Int32
+	handler := func(*CommandContext) { ran.Add(1) }
+	registerTestCommand(t, &Command{Name: "zzsecret", Role: RoleOwner, NeedArgs: true, Usage: "zzsecret [x]", Cost: 1, Handler: handler})
+	registerTestCommand(t, &Command{Name: "zzecho", NeedArgs: true, Usage: "zzecho [text]", Cost: 1, Handler: handler})
+	registerTestCommand(t, &Command{Name: "zzgroup", GroupOnly: true, Cost: 1, Handler: handler})
+
+	cases := []struct {
+		name   string
+		sender string
+		body   string
+		runs   bool
+	}{
+		{"owner only denied before usage", testUser, ".zzsecret", false},
+		{"owner only denied with args", testUser, ".zzsecret go", false},
+		{"usage stops empty args", testUser, ".zzecho", false},
+		{"valid command runs", testUser, ".zzecho hello", true},
+		{"group only in DM", testUser, ".zzgroup", false},
+		{"owner runs owner only", testBotLID, ".zzsecret go", true},
+		{"owner still needs args", testBotLID, ".zzsecret", false},
+		{"unknown command", testUser, ".zzmissing", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := ran.Load()
+			dispatchCommand(testCommandContext(t, tc.sender, tc.body))
+			if got := ran.Load() > before; got != tc.runs {
+				t.Errorf("handler ran = %v, want %v", got, tc.runs)
+			}
+		})
+	}
+	dispatchCommand(nil) // nil context پر panic نہیں
+}
+
+// clearRateBucket: اس سینڈر کی بالٹی ابھی اور ٹیسٹ کے بعد ہٹا دیں
+func clearRateBucket(t *testing.T, c *CommandContext) string {
+	t.Helper()
+	key := c.BotID + "|" + c.ChatID + "|" + c.SenderID
+	reset := func() {
+		rateMutex.Lock()
+		delete(rateBuckets, key)
+		rateMutex.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+	return key
+}
+
+func TestDispatchRateLimit(t *testing.T) {
+	withTestConfig(t, func(c *ConfigStruct) {
+		c.RateLimit.Burst = 2
+		c.RateLimit.RefillPerMin = 0
+	})
+
+	var ran atomic.Int32
+	registerTestCommand(t, &Command{Name: "zzrate", Role: RoleOwner, Cost: 1, Handler: func(*CommandContext) { ran.Add(1) }})
+	registerTestCommand(t, &Command{Name: "zzfree", NeedArgs: true, Usage: "zzfree [x]", Cost: 1, Handler: func(*CommandContext) { ran.Add(1) }})
+
+	// پرمیشن پہلے: انکار پر بالٹی کو ہاتھ نہیں لگتا
+	key := clearRateBucket(t, testCommandContext(t, testUser, ".zzrate"))
+	dispatchCommand(testCommandContext(t, testUser, ".zzrate"))
+	rateMutex.Lock()
+	_, touched := rateBuckets[key]
+	rateMutex.Unlock()
+	if touched {
+		t.Error("denied command touched the rate bucket")
+	}
+
+	// usage ٹوکن کٹنے سے پہلے: بار بار غلط استعمال بالٹی خالی نہیں کرتا
+	for i := 0; i < 5; i++ {
+		dispatchCommand(testCommandContext(t, testUser, ".zzfree"))
+	}
+	rateMutex.Lock()
+	_, touched = rateBuckets[key]
+	rateMutex.Unlock()
+	if touched {
+		t.Error("usage error touched the rate bucket")
+	}
+
+	// burst تک چلتی ہے، پھر ہینڈلر نہیں چلتا
+	for i := 0; i < 3; i++ {
+		dispatchCommand(testCommandContext(t, testUser, ".zzfree go"))
+	}
+	if ran.Load() != 2 {
+		t.Errorf("ran %d times with burst 2, want 2", ran.Load())
+	}
+
+	// اونر پر کوئی ریٹ لمٹ نہیں
+	ran.Store(0)
+	clearRateBucket(t, testCommandContext(t, testBotLID, ".zzrate"))
+	for i := 0; i < 4; i++ {
+		dispatchCommand(testCommandContext(t, testBotLID, ".zzrate"))
+	}
+	if ran.Load() != 4 {
+		t.Errorf("owner ran %d times, want 4", ran.Load())
+	}
+}
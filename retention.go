@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// =========================================================
+// 🧹 RETENTION
+// ہسٹری اسٹور کو ہمیشہ بڑھنے سے روکتا ہے: ہر بوٹ اور چیٹ قسم (dm/group/status)
+// کی اپنی عمر — ٹیکسٹ الگ، میڈیا الگ (config: retention)۔ TTL انڈیکس کی جگہ
+// sweeper تاکہ per-bot اصول تینوں اسٹورز (Mongo/Postgres/memory) پر ایک جیسے چلیں۔
+// =========================================================
+
+const (
+	retentionReportKey = "retention:last_report"
+	retentionLockKey   = "retention:sweep_lock"
+	retentionTimeout   = 30 * time.Minute
+)
+
+var (
+	historyTextTypes  = []string{"text"}
+	historyMediaTypes = []string{"image", "video", "audio", "file"}
+
+	// ایک انسٹینس میں ایک وقت میں ایک ہی sweep
+	retentionMu sync.Mutex
+)
+
+// RetentionCount: کتنے میسجز / میڈیا ہٹے
+type RetentionCount struct {
+	Messages int64 `json:"messages"`
+	Media    int64 `json:"media"`
+}
+
+func (rc *RetentionCount) add(o RetentionCount) {
+	rc.Messages += o.Messages
+	rc.Media += o.Media
+}
+
+// RetentionReport: آخری sweep کا خلاصہ (stateStore میں، .retention دکھاتا ہے)
+type RetentionReport struct {
+	Instance string                               `json:"instance"`
+	Started  time.Time                            `json:"started"`
+	Duration string                               `json:"duration"`
+	Bots     map[string]map[string]RetentionCount `json:"bots,omitempty"` // بوٹ → dm/group/status
+	Total    RetentionCount                       `json:"total"`
+	Errors   []string                             `json:"errors,omitempty"`
+}
+
+// StartRetentionSweeper: ہر retention.interval پر (config ری لوڈ فوراً لاگو)
+func StartRetentionSweeper() {
+	go func() {
+		time.Sleep(2 * time.Minute) // سٹارٹ اپ / مائیگریشن کو پہلے چلنے دیں
+		for {
+			if Config().Retention.Enabled {
+				if rep, ok := RunRetentionSweep(); ok && (rep.Total.Messages > 0 || rep.Total.Media > 0 || len(rep.Errors) > 0) {
+					logger.Info("🧹 retention sweep", "messages", rep.Total.Messages, "media", rep.Total.Media,
+						"took", rep.Duration, "errors", len(rep.Errors))
+				}
+			}
+			time.Sleep(Config().RetentionInterval())
+		}
+	}()
+}
+
+// RunRetentionSweep: ok=false جب کوئی اور (یہ یا دوسرا انسٹینس) پہلے سے چلا رہا ہو
+func RunRetentionSweep() (RetentionReport, bool) {
+	rep := RetentionReport{Instance: InstanceID(), Started: time.Now(), Bots: map[string]map[string]RetentionCount{}}
+	if messageStore == nil || mediaStore == nil {
+		return rep, false
+	}
+	if !retentionMu.TryLock() {
+		return rep, false
+	}
+	defer retentionMu.Unlock()
+	if rdb != nil {
+		// کلسٹر میں ایک وقفے میں صرف ایک انسٹینس
+		ok, err := rdb.SetNX(ctx, retentionLockKey, InstanceID(), retentionTimeout).Result()
+		if err != nil || !ok {
+			return rep, false
+		}
+		defer rdb.Del(ctx, retentionLockKey)
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), retentionTimeout)
+	defer cancel()
+
+	bots, err := messageStore.BotIDs(c)
+	if err != nil {
+		rep.Errors = append(rep.Errors, "list bots: "+err.Error())
+	}
+	cfg := Config()
+	now := time.Now()
+	for _, bot := range bots {
+		for _, kind := range chatKinds {
+			textAge, mediaAge := cfg.RetentionFor(bot, kind)
+			var got RetentionCount
+			if textAge > 0 {
+				n, err := messageStore.DeleteMessages(c, PurgeFilter{BotID: bot, Kind: kind, Types: historyTextTypes, Before: now.Add(-textAge)})
+				got.Messages += n
+				rep.noteErr(err, bot, kind, "text")
+			}
+			if mediaAge > 0 {
+				f := PurgeFilter{BotID: bot, Kind: kind, Types: historyMediaTypes, Before: now.Add(-mediaAge)}
+				n, err := messageStore.DeleteMessages(c, f)
+				got.Messages += n
+				rep.noteErr(err, bot, kind, "media messages")
+				n, err = mediaStore.DeleteMedia(c, f)
+				got.Media += n
+				rep.noteErr(err, bot, kind, "media")
+			}
+			if got.Messages > 0 || got.Media > 0 {
+				if rep.Bots[bot] == nil {
+					rep.Bots[bot] = map[string]RetentionCount{}
+				}
+				rep.Bots[bot][kind] = got
+				rep.Total.add(got)
+				mRetentionDeleted.Add(float64(got.Messages), kind, "messages")
+				mRetentionDeleted.Add(float64(got.Media), kind, "media")
+			}
+		}
+	}
+	rep.Duration = time.Since(rep.Started).Round(time.Millisecond).String()
+
+	if stateStore != nil {
+		if data, err := json.Marshal(rep); err == nil {
+			_ = stateStore.Put(context.Background(), retentionReportKey, data, 0)
+		}
+	}
+	return rep, true
+}
+
+func (rep *RetentionReport) noteErr(err error, bot, kind, what string) {
+	if err != nil {
+		rep.Errors = append(rep.Errors, fmt.Sprintf("%s/%s %s: %v", bot, kind, what, err))
+	}
+}
+
+// forBot: رپورٹ کا صرف اس بوٹ والا حصہ (دوسرے بوٹس کے نمبر / گنتی نہ دکھیں)
+func (rep *RetentionReport) forBot(botID string) RetentionReport {
+	out := RetentionReport{Instance: rep.Instance, Started: rep.Started, Duration: rep.Duration}
+	if kinds, ok := rep.Bots[botID]; ok {
+		out.Bots = map[string]map[string]RetentionCount{botID: kinds}
+		for _, rc := range kinds {
+			out.Total.add(rc)
+		}
+	}
+	for _, e := range rep.Errors {
+		if strings.HasPrefix(e, botID+"/") {
+			out.Errors = append(out.Errors, e)
+		}
+	}
+	return out
+}
+
+// lastRetentionReport: کسی بھی انسٹینس کا آخری sweep
+func lastRetentionReport() (*RetentionReport, error) {
+	if stateStore == nil {
+		return nil, ErrNotFound
+	}
+	data, err := stateStore.Get(context.Background(), retentionReportKey)
+	if err != nil {
+		return nil, err
+	}
+	var rep RetentionReport
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+// PurgeChat: ایک چیٹ کی پوری ہسٹری اور میڈیا (اس بوٹ کا)
+func PurgeChat(botID string, chat types.JID) (RetentionCount, error) {
+	var got RetentionCount
+	if messageStore == nil || mediaStore == nil {
+		return got, fmt.Errorf("history store not configured")
+	}
+	c, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	chatIDs := []string{canonicalChatID(chat.String())}
+	if raw := chat.ToNonAD().String(); raw != chatIDs[0] {
+		chatIDs = append(chatIDs, raw)
+	}
+	f := PurgeFilter{BotID: getCleanID(botID), ChatIDs: chatIDs}
+
+	n, err := messageStore.DeleteMessages(c, f)
+	got.Messages = n
+	if err != nil {
+		return got, err
+	}
+	got.Media, err = mediaStore.DeleteMedia(c, f)
+	kind := chatKind(chatIDs[0])
+	mRetentionDeleted.Add(float64(got.Messages), kind, "messages")
+	mRetentionDeleted.Add(float64(got.Media), kind, "media")
+	botLog(f.BotID).Info("🧹 chat purged", "chat", redactID(chatIDs[0]), "messages", got.Messages, "media", got.Media)
+	return got, err
+}
+
+// 🧹 .purgechat 923xxxxxxxxx | 1203xxx@g.us | here | status
+func handlePurgeChatCmd(c *CommandContext) {
+	var chat types.JID
+	switch target := strings.ToLower(c.Args[0]); target {
+	case "here", "this":
+		chat, _ = realChatJID(c.Msg)
+	case "status":
+		chat = types.StatusBroadcastJID
+	default:
+		jid, err := parseSendJID(c.Args[0])
+		if err != nil {
+			replyMessage(c.Client, c.Msg, "❌ "+err.Error()+"\n⚠️ Usage: "+c.Prefix+"purgechat 923xxxxxxxxx | group-id@g.us | here | status")
+			return
+		}
+		chat = jid
+	}
+
+	got, err := PurgeChat(c.BotID, chat)
+	if err != nil {
+		replyMessage(c.Client, c.Msg, "❌ Purge failed: "+err.Error())
+		return
+	}
+	replyMessage(c.Client, c.Msg, fmt.Sprintf(`╔════════════════╗
+║ 🧹 CHAT PURGED
+╠════════════════╣
+║ 💬 Chat: %s
+║ 📝 Messages: %d
+║ 🖼️ Media: %d
+╚════════════════╝`, chat.User, got.Messages, got.Media))
+}
+
+// 🧹 .retention [run] — اس بوٹ کی پالیسی + آخری sweep (صرف اس بوٹ کا حصہ)۔
+// run سب بوٹس پر چلتا ہے، اس لیے صرف سرور اونر
+func handleRetentionCmd(c *CommandContext) {
+	if len(c.Args) > 0 && strings.ToLower(c.Args[0]) == "run" {
+		if !isServerOwner(c.Msg) {
+			replyMessage(c.Client, c.Msg, "❌ Only the server owner (owner.number / developer_lid) can run a sweep for all bots.")
+			return
+		}
+		replyMessage(c.Client, c.Msg, "⏳ Running retention sweep...")
+		rep, ok := RunRetentionSweep()
+		if !ok {
+			replyMessage(c.Client, c.Msg, "⚠️ A sweep is already running (here or on another instance).")
+			return
+		}
+		replyMessage(c.Client, c.Msg, formatRetentionReport(&rep))
+		return
+	}
+
+	cfg := Config()
+	out := "╔════════════════╗\n║ 🧹 RETENTION\n╠════════════════╣\n"
+	if !cfg.Retention.Enabled {
+		out += "║ ⏸️ Sweeper: OFF\n"
+	} else {
+		out += "║ ▶️ Sweeper: every " + cfg.RetentionInterval().String() + "\n"
+	}
+	for _, kind := range chatKinds {
+		text, media := cfg.RetentionFor(c.BotID, kind)
+		out += fmt.Sprintf("║ %s: text %s | media %s\n", kind, formatRetentionAge(text), formatRetentionAge(media))
+	}
+	out += "╚════════════════╝"
+	if rep, err := lastRetentionReport(); err == nil {
+		mine := rep.forBot(getCleanID(c.BotID))
+		out += "\n\n" + formatRetentionReport(&mine)
+	}
+	if isServerOwner(c.Msg) {
+		out += "\n_" + c.Prefix + "retention run · " + c.Prefix + "purgechat <chat>_"
+	} else {
+		out += "\n_" + c.Prefix + "purgechat <chat>_"
+	}
+	replyMessage(c.Client, c.Msg, out)
+}
+
+func formatRetentionAge(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "forever"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+func formatRetentionReport(rep *RetentionReport) string {
+	out := "╔════════════════╗\n║ 📊 LAST SWEEP\n╠════════════════╣\n"
+	out += fmt.Sprintf("║ 🕒 %s (%s)\n", rep.Started.Local().Format("02 Jan 15:04"), rep.Duration)
+	out += fmt.Sprintf("║ 📝 Messages: %d\n║ 🖼️ Media: %d\n", rep.Total.Messages, rep.Total.Media)
+
+	bots := make([]string, 0, len(rep.Bots))
+	for bot := range rep.Bots {
+		bots = append(bots, bot)
+	}
+	sort.Strings(bots)
+	for _, bot := range bots {
+		name := bot
+		if name == "" {
+			name = "(legacy)"
+		}
+		for _, kind := range chatKinds {
+			if rc, ok := rep.Bots[bot][kind]; ok {
+				out += fmt.Sprintf("║ • %s %s: %d / %d\n", name, kind, rc.Messages, rc.Media)
+			}
+		}
+	}
+	if len(rep.Errors) > 0 {
+		out += fmt.Sprintf("║ ⚠️ Errors: %d\n", len(rep.Errors))
+	}
+	return out + "╚════════════════╝"
+}
@@ -103,25 +103,6 @@ func LoadGroupSecurity(rdb *redis.Client, botLID string, groupID string) GroupSe
 	return data
 }
 
-// فرض کریں یوزر نے 'antilink' آن کرنے کا فیصلہ کر لیا ہے
-func finalizeSecurity(client *whatsmeow.Client, senderLID string, choice string) {
-	state := setupMap[senderLID]
-	if state == nil { return }
-
-	allowAdmin := (choice == "1") // اگر یوزر نے 1 دبایا تو ایڈمن الاؤ ہیں
-	
-	// سیٹنگز تیار کریں
-	newConfig := GroupSecurity{
-		AntiLink:   true, // کیونکہ وہ اینٹی لنک کا سیٹ اپ کر رہا تھا
-		AllowAdmin: allowAdmin,
-	}
-
-	// 💾 ریڈیس میں اس گروپ کے لیے مخصوص سیو کریں
-	SaveGroupSecurity(rdb, state.BotLID, state.GroupID, newConfig)
-	
-	// میپ سے ڈیلیٹ کر دیں
-	delete(setupMap, senderLID)
-}
 // ==================== سیکورٹی سسٹم ====================
 func checkSecurity(client *whatsmeow.Client, v *events.Message) {
 	// ✅ 1. Bot ID نکالیں
@@ -350,34 +331,6 @@ func takeSecurityAction(client *whatsmeow.Client, v *events.Message, s *GroupSet
 
 
 
-// مثال کے طور پر
-func onResponse(client *whatsmeow.Client, v *events.Message, choice string) {
-	senderID := v.Info.Sender.String()
-	state, exists := setupMap[senderID]
-
-	// 1. کیا یہ بندہ سیٹ اپ موڈ میں ہے؟
-	if !exists { return }
-
-	// 2. کیا اس نے میسج کو ریپلائی (Quote) کیا ہے؟
-	if v.Message.GetExtendedTextMessage().GetContextInfo() == nil {
-		return // اگر ریپلائی نہیں ہے تو خاموش رہے
-	}
-
-	// 3. کیا ریپلائی اسی بوٹ کے میسج کو کیا گیا ہے؟
-	quotedID := v.Message.ExtendedTextMessage.ContextInfo.GetStanzaID() // ✅ Fixed: ID caps mein
-	if quotedID != state.BotMsgID {
-		return // اگر کسی اور کے میسج کو ریپلائی کیا تو اگنور کریں
-	}
-
-	// 4. اگر سب ٹھیک ہے تو ریڈیس میں سیو کریں
-	key := fmt.Sprintf("group:sec:%s:%s:%s", state.BotLID, state.GroupID, state.Type)
-	rdb.Set(context.Background(), key, choice, 0)
-
-	// اگلا مینیو دکھائیں یا ختم کریں
-	replyMessage(client, v, "✅ Setting Saved Successfully!")
-	delete(setupMap, senderID)
-}
-
 func startSecuritySetup(client *whatsmeow.Client, v *events.Message, args []string, secType string) {
 	// 1️⃣ گروپ چیک
 	if !v.Info.IsGroup {
@@ -385,12 +338,7 @@ func startSecuritySetup(client *whatsmeow.Client, v *events.Message, args []stri
 		return
 	}
 
-	// 2️⃣ ایڈمن چیک (صرف ایڈمن، جیسا آپ نے کہا)
-	// ایرر فکس: isAdmin کو اب ہم IDs پاس کر رہے ہیں
-	if !isAdmin(client, v.Info.Chat, v.Info.Sender) {
-		replyMessage(client, v, "👮 Only Group Admins can use this command.")
-		return
-	}
+	// 2️⃣ ایڈمن چیک رجسٹری میں ہوتا ہے (RoleAdmin + .perm رولز)
 
 	// 🛠️ سیٹنگز لوڈ کریں
 	botID := getCleanID(client.Store.ID.User)
@@ -472,6 +420,7 @@ func startWizard(client *whatsmeow.Client, v *events.Message, secType, botID, gr
 ║ Allow Admins to send links?
 ║ 1️⃣ YES (Admins Safe)
 ║ 2️⃣ NO (Check Admins too)
+║ 0️⃣ CANCEL
 ╚════════════════╝`, strings.ToUpper(secType))
 
 	resp, err := client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
@@ -480,59 +429,28 @@ func startWizard(client *whatsmeow.Client, v *events.Message, secType, botID, gr
 
 	if err != nil { return }
 
-	// سیشن محفوظ کریں
-	setupMap[resp.ID] = &SetupState{
+	// سیشن محفوظ کریں (2 منٹ، اسی کارڈ کے ریپلائی پر)
+	StartConversation(client, v, FlowSetup, resp.ID, 2*time.Minute, &SetupState{
 		Type:     secType,
 		Stage:    1,
 		GroupID:  groupID,
 		User:     v.Info.Sender.User,
 		BotLID:   botID,
 		BotMsgID: resp.ID,
-	}
-
-	// ٹائمر
-	go func() {
-		time.Sleep(2 * time.Minute)
-		delete(setupMap, resp.ID)
-	}()
+	})
 }
 
 
-func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
-	// 🛑 ریپلائی چیک
-	extMsg := v.Message.GetExtendedTextMessage()
-	if extMsg == nil || extMsg.ContextInfo == nil {
-		return
-	}
-
-	quotedID := extMsg.ContextInfo.GetStanzaID()
-	incomingLID := v.Info.Sender.User 
-
-	// ✅ FIX: Bot ID نکالیں
-	rawBotID := client.Store.ID.User
-	botID := getCleanID(rawBotID)
-
-	// 1. ڈیٹا تلاش کریں (جس میسج پر ریپلائی آیا ہے)
-	state, exists := setupMap[quotedID]
-	if !exists {
-		// اگر یہاں نہیں ملا، تو ہو سکتا ہے یہ کسی دوسرے بوٹ کا میسج ہو
-		return
-	}
-
-	// 2. بوٹ میچنگ
-	if state.BotLID != botID {
-		return // یہ سیشن اس بوٹ کا نہیں ہے
-	}
-
-	// 3. یوزر میچنگ
-	fmt.Printf("🔍 [SETUP MATCH] Stage: %d | User: %s vs %s\n", state.Stage, state.User, incomingLID)
+// FlowSetup: بوٹ، یوزر اور quoted کارڈ کی جانچ سیشن مینیجر پہلے ہی کر چکا ہے
+func handleSetupResponse(client *whatsmeow.Client, v *events.Message, conv *Conversation, txt string) bool {
+	botID := getCleanID(client.Store.ID.User)
 
-	if state.User != incomingLID {
-		fmt.Println("🚫 [REJECTED] User mismatch in setup.")
-		return 
+	var state SetupState
+	if !conv.Decode(&state) {
+		EndConversation(conv)
+		return false
 	}
-
-	txt := strings.TrimSpace(getText(v.Message))
+	botLog(botID).Debug("🔍 setup reply matched", "stage", state.Stage, "user", redactID(state.User))
 
 	// ✅ FIX: Settings منگواتے وقت botID پاس کریں
 	s := getGroupSettings(botID, state.GroupID)
@@ -547,12 +465,9 @@ func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
 			s.AntilinkAdmin = false
 		} else {
 			replyMessage(client, v, "⚠️ Please reply with 1 or 2")
-			return
+			return true
 		}
 
-		// پرانا سیشن ڈیلیٹ کریں (کیونکہ اب ہم نیا میسج بھیج رہے ہیں)
-		delete(setupMap, quotedID)
-
 		// اگلا میسج بھیجیں
 		nextMsg := fmt.Sprintf(`╔════════════════╗
 ║ ⚡ %s (2/2)
@@ -560,6 +475,7 @@ func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
 ║ 1️⃣ DELETE ONLY
 ║ 2️⃣ DELETE + KICK
 ║ 3️⃣ DELETE + WARN
+║ 0️⃣ CANCEL
 ╚════════════════╝`, strings.ToUpper(state.Type))
 
 		resp, err := client.SendMessage(context.Background(), v.Info.Chat, &waProto.Message{
@@ -568,29 +484,17 @@ func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
 
 		if err != nil {
 			fmt.Println("❌ Error sending Stage 2 msg:", err)
-			return
+			EndConversation(conv)
+			return true
 		}
 
-		// ✅ نیا سیشن (Stage 2) سیو کریں
-		newKey := resp.ID
-		fmt.Printf("⏭️ [NEXT STAGE] Moving to Stage 2. New Key: %s\n", newKey)
-
-		setupMap[newKey] = &SetupState{
-			Type:     state.Type,
-			Stage:    2, // سٹیج اپڈیٹ
-			GroupID:  state.GroupID,
-			User:     state.User,
-			BotLID:   state.BotLID, // وہی Bot ID رکھیں
-			BotMsgID: resp.ID,
-		}
-		
-		// اس نئے سیشن کے لیے بھی ٹائمر لگا دیں
-		go func() {
-			time.Sleep(2 * time.Minute)
-			delete(setupMap, newKey)
-		}()
-		
-		return
+		// ✅ اسی سیشن کو Stage 2 پر لے جائیں (نیا کارڈ، نیا ٹائمر)
+		fmt.Printf("⏭️ [NEXT STAGE] Moving to Stage 2. New Key: %s\n", resp.ID)
+
+		state.Stage = 2 // سٹیج اپڈیٹ
+		state.BotMsgID = resp.ID
+		conv.Advance(resp.ID, &state)
+		return true
 	}
 
 	// ===========================
@@ -610,7 +514,7 @@ func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
 			actionText = "Delete + Warn"
 		default:
 			replyMessage(client, v, "⚠️ Please reply with 1, 2 or 3")
-			return
+			return true
 		}
 
 		// فائنل سیٹنگز اپلائی کریں
@@ -620,7 +524,7 @@ func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
 		saveGroupSettings(botID, s)
 		
 		// سیشن ختم
-		delete(setupMap, quotedID) 
+		EndConversation(conv)
 
 		adminBypass := "YES ✅"
 		if !s.AntilinkAdmin {
@@ -637,6 +541,7 @@ func handleSetupResponse(client *whatsmeow.Client, v *events.Message) {
 		replyMessage(client, v, finalMsg)
 		fmt.Printf("🏁 [COMPLETE] Setup Success for %s on Bot %s\n", state.Type, botID)
 	}
+	return true
 }
 
 // ہیلپر
@@ -682,8 +587,8 @@ func handleGroupInfoChange(client *whatsmeow.Client, v *events.GroupInfo) {
 	if !settings.Welcome { return }
 
 	// 🛡️ ANTI-SPAM FILTER
-	if RestrictedGroups[chatID] {
-		if !AuthorizedBots[botID] {
+	if Config().IsRestrictedGroup(chatID) {
+		if !Config().IsAuthorizedBot(botID) {
 			return 
 		}
 	}
@@ -911,16 +816,17 @@ func AutoProtect(client *whatsmeow.Client, v *events.Message) bool {
 	if scanForVirus(text) {
 		sender := v.Info.Sender
 
-		fmt.Printf("🚨 VIRUS DETECTED from %s | ACTION: BLOCKING USER\n", sender.User)
+		plog := msgLog(client, v).With("feature", "autoprotect")
+		plog.Warn("🚨 virus detected, blocking user")
 
 		// ✅ FIX: Assignment Mismatch Error Solved
 		// یہاں ہم نے _, err لگایا ہے تاکہ پہلا ویلیو اگنور ہو جائے اور صرف ایرر ملے
 		_, err := client.UpdateBlocklist(context.Background(), sender, events.BlocklistChangeActionBlock)
 		
 		if err != nil {
-			fmt.Println("❌ Block Failed:", err)
+			plog.Warn("❌ block failed", "err", err)
 		} else {
-			fmt.Println("✅ User Successfully Blocked to prevent crash.")
+			plog.Info("✅ user blocked to prevent crash")
 		}
 		
 		return true
@@ -0,0 +1,599 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// =========================================================
+// 📤 SEND API (send-messages)
+//   POST /api/bots/{id}/send
+// ٹیکسٹ، مینشن، ریپلائی، میڈیا (image/video/document/audio) اور ری ایکشن۔
+// بوٹ آن لائن ہو تو فوراً بھیج کر میسج ID؛ آف لائن ہو تو Redis قطار
+// "send_queue:<botID>" میں — جو انسٹینس بوٹ چلا رہا ہے وہ کنیکٹ ہوتے ہی بھیج دیتا ہے
+// اور WebSocket پر message-sent / message-failed بتاتا ہے۔
+// =========================================================
+
+const (
+	sendQueuePrefix    = "send_queue:"
+	sendQueueMax       = 500
+	sendQueueTTL       = 24 * time.Hour   // اس سے پرانے میسج نہیں بھیجے جاتے
+	sendQueueMaxInline = 16 << 20         // قطار میں base64 میڈیا کی حد (بڑی فائل کے لیے url دیں)
+	sendMaxBody        = 64 << 20         // HTTP body
+	sendMaxMedia       = 100 << 20        // url سے ڈاؤنلوڈ کی حد
+	sendQueueTick      = 10 * time.Second // بچ جانے والی قطاریں
+	sendQueueGap       = 1 * time.Second  // قطار خالی کرتے وقت میسجز کا وقفہ
+	sendUploadTimeout  = 10 * time.Minute
+)
+
+// SendRequest: POST body
+type SendRequest struct {
+	To       string        `json:"to"` // نمبر یا پورا JID (…@g.us)
+	Text     string        `json:"text,omitempty"`
+	Mentions []string      `json:"mentions,omitempty"` // نمبر؛ ٹیکسٹ میں @923xx بھی لکھیں
+	ReplyTo  *SendReplyRef `json:"reply_to,omitempty"`
+	Media    *SendMedia    `json:"media,omitempty"`
+	React    *SendReaction `json:"react,omitempty"`
+}
+
+type SendReplyRef struct {
+	ID     string `json:"id"`
+	Sender string `json:"sender,omitempty"` // گروپ میں لازمی؛ DM میں خالی = سامنے والا
+	Text   string `json:"text,omitempty"`   // اقتباس میں دکھنے والا متن
+}
+
+type SendMedia struct {
+	Type     string `json:"type"`           // image / video / document / audio
+	URL      string `json:"url,omitempty"`  // یا
+	Data     string `json:"data,omitempty"` // base64
+	Mime     string `json:"mime,omitempty"`
+	FileName string `json:"filename,omitempty"`
+	PTT      bool   `json:"ptt,omitempty"` // وائس نوٹ
+}
+
+type SendReaction struct {
+	ID     string `json:"id"`
+	Emoji  string `json:"emoji"`            // خالی = ری ایکشن ہٹائیں
+	Sender string `json:"sender,omitempty"` // گروپ میں لازمی
+	FromMe bool   `json:"from_me,omitempty"`
+}
+
+// queuedSend: Redis قطار کا ایک آئٹم
+type queuedSend struct {
+	ID      string      `json:"id"`
+	Key     string      `json:"key"` // کس API کلید نے بھیجا (لاگ کے لیے)
+	Queued  int64       `json:"queued"`
+	Request SendRequest `json:"request"`
+}
+
+var (
+	sendFlushing   = make(map[string]bool) // botID → قطار خالی ہو رہی ہے
+	sendFlushingMu sync.Mutex
+)
+
+// parseSendJID: "923001234567" → user JID؛ "…@g.us" جیسا پورا JID ویسے ہی
+func parseSendJID(raw string) (types.JID, error) {
+	raw = strings.TrimSpace(strings.TrimPrefix(raw, "+"))
+	if raw == "" {
+		return types.JID{}, errors.New("recipient required")
+	}
+	if !strings.Contains(raw, "@") {
+		if _, err := strconv.ParseUint(raw, 10, 64); err != nil {
+			return types.JID{}, fmt.Errorf("invalid number: %s", raw)
+		}
+		return types.NewJID(raw, types.DefaultUserServer), nil
+	}
+	jid, err := types.ParseJID(raw)
+	if err != nil || jid.User == "" {
+		return types.JID{}, fmt.Errorf("invalid jid: %s", raw)
+	}
+	return jid, nil
+}
+
+func (req *SendRequest) validate() error {
+	chat, err := parseSendJID(req.To)
+	if err != nil {
+		return err
+	}
+	if req.React != nil {
+		if req.React.ID == "" {
+			return errors.New("react.id required")
+		}
+		if chat.Server == types.GroupServer && req.React.Sender == "" && !req.React.FromMe {
+			return errors.New("react.sender required in groups")
+		}
+		if req.Text != "" || req.Media != nil {
+			return errors.New("react cannot be combined with text or media")
+		}
+		return nil
+	}
+	if req.Media == nil && strings.TrimSpace(req.Text) == "" {
+		return errors.New("text, media or react required")
+	}
+	if m := req.Media; m != nil {
+		switch m.Type {
+		case "image", "video", "document", "audio":
+		default:
+			return errors.New("media.type must be image, video, document or audio")
+		}
+		if (m.URL == "") == (m.Data == "") {
+			return errors.New("media needs exactly one of url or data")
+		}
+		if m.URL != "" {
+			if err := checkMediaURL(m.URL); err != nil {
+				return err
+			}
+		}
+	}
+	if r := req.ReplyTo; r != nil {
+		if r.ID == "" {
+			return errors.New("reply_to.id required")
+		}
+		if chat.Server == types.GroupServer && r.Sender == "" {
+			return errors.New("reply_to.sender required in groups")
+		}
+	}
+	for _, m := range req.Mentions {
+		if _, err := parseSendJID(m); err != nil {
+			return fmt.Errorf("mention: %w", err)
+		}
+	}
+	return nil
+}
+
+// 🌐 POST /api/bots/{id}/send
+func handleSendAPI(w http.ResponseWriter, r *http.Request) {
+	botID, ok := apiBotID(w, r)
+	if !ok {
+		return
+	}
+	var req SendRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sendMaxBody)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if allowed, wait := allowSendRate(botID); !allowed {
+		mAPISends.Inc(botID, "rate_limited")
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeJSONError(w, http.StatusTooManyRequests, errors.New("send rate limit reached for this bot"))
+		return
+	}
+
+	key := requestAPIKey(r)
+	client := onlineClient(botID)
+	if client == nil {
+		item, pos, err := enqueueSend(botID, key.ID, req)
+		if err != nil {
+			writeJSONError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		mAPISends.Inc(botID, "queued")
+		botLog(botID).Info("📤 api send queued", "queue_id", item.ID, "to", redactID(req.To), "key", key.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "queued": true, "queue_id": item.ID, "position": pos})
+		return
+	}
+
+	resp, err := sendAPIMessage(r.Context(), client, req)
+	if err != nil {
+		mAPISends.Inc(botID, "failed")
+		botLog(botID).Warn("📤 api send failed", "to", redactID(req.To), "key", key.ID, "err", err)
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+	mAPISends.Inc(botID, "sent")
+	botLog(botID).Info("📤 api send", "to", redactID(req.To), "msg", resp.ID, "key", key.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "queued": false, "message_id": resp.ID, "timestamp": resp.Timestamp.Unix()})
+}
+
+// onlineClient: یہی انسٹینس بوٹ چلا رہا ہو اور لاگ اِن ہو
+func onlineClient(botID string) *whatsmeow.Client {
+	clientsMutex.RLock()
+	c := activeClients[botID]
+	clientsMutex.RUnlock()
+	if c == nil || !c.IsConnected() || !c.IsLoggedIn() {
+		return nil
+	}
+	return c
+}
+
+// sendAPIMessage: میسج بنا کر بھیجیں (میڈیا ہو تو پہلے upload)
+func sendAPIMessage(c context.Context, client *whatsmeow.Client, req SendRequest) (whatsmeow.SendResponse, error) {
+	chat, err := parseSendJID(req.To)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	if rx := req.React; rx != nil {
+		sender := chat
+		if rx.FromMe {
+			sender = client.Store.ID.ToNonAD()
+		} else if rx.Sender != "" {
+			if sender, err = parseSendJID(rx.Sender); err != nil {
+				return whatsmeow.SendResponse{}, err
+			}
+		}
+		return client.SendMessage(c, chat, client.BuildReaction(chat, sender, rx.ID, rx.Emoji))
+	}
+
+	ci, err := sendContextInfo(chat, req)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	var msg *waProto.Message
+	if req.Media == nil {
+		msg = &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(req.Text),
+			ContextInfo: ci,
+		}}
+	} else {
+		msg, err = buildMediaMessage(c, client, req.Media, req.Text, ci)
+		if err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+	return client.SendMessage(c, chat, msg)
+}
+
+// sendContextInfo: مینشن + ریپلائی (دونوں نہ ہوں تو nil)
+func sendContextInfo(chat types.JID, req SendRequest) (*waProto.ContextInfo, error) {
+	if len(req.Mentions) == 0 && req.ReplyTo == nil {
+		return nil, nil
+	}
+	ci := &waProto.ContextInfo{}
+	for _, m := range req.Mentions {
+		jid, err := parseSendJID(m)
+		if err != nil {
+			return nil, err
+		}
+		ci.MentionedJID = append(ci.MentionedJID, jid.String())
+	}
+	if rp := req.ReplyTo; rp != nil {
+		participant := chat
+		if rp.Sender != "" {
+			jid, err := parseSendJID(rp.Sender)
+			if err != nil {
+				return nil, err
+			}
+			participant = jid
+		}
+		ci.StanzaID = proto.String(rp.ID)
+		ci.Participant = proto.String(participant.String())
+		ci.QuotedMessage = &waProto.Message{Conversation: proto.String(rp.Text)}
+	}
+	return ci, nil
+}
+
+// buildMediaMessage: uploadToWhatsApp والا ہی client.Upload راستہ
+func buildMediaMessage(c context.Context, client *whatsmeow.Client, m *SendMedia, caption string, ci *waProto.ContextInfo) (*waProto.Message, error) {
+	data, err := loadSendMedia(c, m)
+	if err != nil {
+		return nil, err
+	}
+	mime := m.Mime
+	if mime == "" {
+		mime = http.DetectContentType(data)
+	}
+
+	var mType whatsmeow.MediaType
+	switch m.Type {
+	case "image":
+		mType = whatsmeow.MediaImage
+	case "video":
+		mType = whatsmeow.MediaVideo
+	case "audio":
+		mType = whatsmeow.MediaAudio
+		if m.PTT {
+			mime = "audio/ogg; codecs=opus"
+		}
+	default:
+		mType = whatsmeow.MediaDocument
+	}
+
+	upCtx, cancel := context.WithTimeout(c, sendUploadTimeout)
+	defer cancel()
+	up, err := client.Upload(upCtx, data, mType)
+	if err != nil {
+		return nil, fmt.Errorf("upload failed: %w", err)
+	}
+
+	size := proto.Uint64(uint64(len(data)))
+	switch m.Type {
+	case "image":
+		return &waProto.Message{ImageMessage: &waProto.ImageMessage{
+			URL: proto.String(up.URL), DirectPath: proto.String(up.DirectPath), MediaKey: up.MediaKey,
+			Mimetype: proto.String(mime), Caption: proto.String(caption), FileLength: size,
+			FileSHA256: up.FileSHA256, FileEncSHA256: up.FileEncSHA256, ContextInfo: ci,
+		}}, nil
+	case "video":
+		return &waProto.Message{VideoMessage: &waProto.VideoMessage{
+			URL: proto.String(up.URL), DirectPath: proto.String(up.DirectPath), MediaKey: up.MediaKey,
+			Mimetype: proto.String(mime), Caption: proto.String(caption), FileLength: size,
+			FileSHA256: up.FileSHA256, FileEncSHA256: up.FileEncSHA256, ContextInfo: ci,
+		}}, nil
+	case "audio":
+		return &waProto.Message{AudioMessage: &waProto.AudioMessage{
+			URL: proto.String(up.URL), DirectPath: proto.String(up.DirectPath), MediaKey: up.MediaKey,
+			Mimetype: proto.String(mime), PTT: proto.Bool(m.PTT), FileLength: size,
+			FileSHA256: up.FileSHA256, FileEncSHA256: up.FileEncSHA256, ContextInfo: ci,
+		}}, nil
+	}
+	name := m.FileName
+	if name == "" {
+		name = "file"
+		if m.URL != "" {
+			if base := filepath.Base(strings.SplitN(m.URL, "?", 2)[0]); base != "." && base != "/" {
+				name = base
+			}
+		}
+	}
+	return &waProto.Message{DocumentMessage: &waProto.DocumentMessage{
+		URL: proto.String(up.URL), DirectPath: proto.String(up.DirectPath), MediaKey: up.MediaKey,
+		Mimetype: proto.String(mime), FileName: proto.String(name), Caption: proto.String(caption), FileLength: size,
+		FileSHA256: up.FileSHA256, FileEncSHA256: up.FileEncSHA256, ContextInfo: ci,
+	}}, nil
+}
+
+func loadSendMedia(c context.Context, m *SendMedia) ([]byte, error) {
+	if m.Data != "" {
+		data, err := base64.StdEncoding.DecodeString(m.Data)
+		if err != nil {
+			return nil, fmt.Errorf("media.data: %w", err)
+		}
+		return data, nil
+	}
+	if err := checkMediaURL(m.URL); err != nil {
+		return nil, err
+	}
+	hreq, err := http.NewRequestWithContext(c, http.MethodGet, m.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sendMediaClient.Do(hreq)
+	if err != nil {
+		return nil, fmt.Errorf("media download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("media download: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, sendMaxMedia+1))
+	if err != nil {
+		return nil, fmt.Errorf("media download: %w", err)
+	}
+	if len(data) > sendMaxMedia {
+		return nil, errors.New("media larger than 100MB")
+	}
+	if m.Mime == "" {
+		m.Mime = strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0])
+	}
+	return data, nil
+}
+
+// 🛡️ media.url سے ڈاؤنلوڈ صرف پبلک http(s) ایڈریس سے — ورنہ API کی والا
+// Redis / Postgres / کلاؤڈ metadata (169.254.169.254) جیسے اندرونی سرور پڑھ سکتا ہے۔
+// چیک dial کے وقت (DNS کے بعد اصل IP پر) ہوتا ہے، اس لیے redirect اور DNS rebinding بھی رکتے ہیں۔
+var sendMediaClient = &http.Client{
+	Timeout: 2 * time.Minute,
+	Transport: &http.Transport{
+		Proxy: nil, // ENV proxy سے چیک نہ بچے
+		DialContext: (&net.Dialer{
+			Timeout: 15 * time.Second,
+			Control: refusePrivateDial,
+		}).DialContext,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("media download: too many redirects")
+		}
+		return checkMediaURL(req.URL.String())
+	},
+}
+
+func checkMediaURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("media.url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("media.url: scheme %q not allowed (http/https only)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("media.url: missing host")
+	}
+	return nil
+}
+
+func refusePrivateDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("bad address %q", host)
+	}
+	if !isPublicIP(ip) {
+		return fmt.Errorf("address %s is not public", ip)
+	}
+	return nil
+}
+
+// isPublicIP: loopback / private / link-local / CGNAT / multicast / unspecified نہیں
+func isPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 — اندرونی IPv4 تک راستہ
+}
+
+// =========================================================
+// 📬 OFFLINE QUEUE
+// =========================================================
+
+func enqueueSend(botID, keyID string, req SendRequest) (queuedSend, int64, error) {
+	item := queuedSend{ID: newJobID(), Key: keyID, Queued: time.Now().Unix(), Request: req}
+	if rdb == nil {
+		return item, 0, errors.New("bot is offline and redis is not connected")
+	}
+	if req.Media != nil && len(req.Media.Data) > sendQueueMaxInline*4/3 {
+		return item, 0, errors.New("bot is offline; media over 16MB can only be queued by url")
+	}
+	qkey := sendQueuePrefix + botID
+	if n, _ := rdb.LLen(ctx, qkey).Result(); n >= sendQueueMax {
+		return item, 0, fmt.Errorf("bot is offline and its send queue is full (%d)", sendQueueMax)
+	}
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return item, 0, err
+	}
+	pos, err := rdb.RPush(ctx, qkey, raw).Result()
+	if err != nil {
+		return item, 0, err
+	}
+	rdb.Expire(ctx, qkey, sendQueueTTL)
+	return item, pos, nil
+}
+
+// flushSendQueue: بوٹ کنیکٹ ہوتے ہی (اور ہر sendQueueTick پر) قطار ترتیب سے خالی
+func flushSendQueue(botID string) {
+	if rdb == nil {
+		return
+	}
+	sendFlushingMu.Lock()
+	if sendFlushing[botID] {
+		sendFlushingMu.Unlock()
+		return
+	}
+	sendFlushing[botID] = true
+	sendFlushingMu.Unlock()
+	defer func() {
+		sendFlushingMu.Lock()
+		delete(sendFlushing, botID)
+		sendFlushingMu.Unlock()
+	}()
+
+	qkey := sendQueuePrefix + botID
+	for {
+		client := onlineClient(botID)
+		if client == nil {
+			return // پھر آف لائن — اگلی بار
+		}
+		raw, err := rdb.LIndex(ctx, qkey, 0).Result()
+		if err != nil {
+			return // خالی (redis.Nil) یا Redis مسئلہ
+		}
+		var item queuedSend
+		if json.Unmarshal([]byte(raw), &item) != nil {
+			rdb.LPop(ctx, qkey)
+			continue
+		}
+		if time.Since(time.Unix(item.Queued, 0)) > sendQueueTTL {
+			rdb.LPop(ctx, qkey)
+			queuedSendDone(botID, item, "", errors.New("expired in queue"))
+			continue
+		}
+
+		sctx, cancel := context.WithTimeout(context.Background(), sendUploadTimeout+time.Minute)
+		resp, err := sendAPIMessage(sctx, client, item.Request)
+		cancel()
+		if err != nil && !client.IsConnected() {
+			return // کنکشن ٹوٹا — آئٹم قطار میں رہے
+		}
+		// ⚠️ LIndex کے بعد LPop: ایک بوٹ کی قطار ایک ہی انسٹینس خالی کرتا ہے (lease)
+		rdb.LPop(ctx, qkey)
+		if err != nil {
+			queuedSendDone(botID, item, "", err)
+		} else {
+			queuedSendDone(botID, item, resp.ID, nil)
+		}
+		time.Sleep(sendQueueGap)
+	}
+}
+
+func queuedSendDone(botID string, item queuedSend, msgID string, err error) {
+	data := map[string]any{"queue_id": item.ID, "to": item.Request.To}
+	evt := EvtMessageSent
+	if err != nil {
+		mAPISends.Inc(botID, "failed")
+		botLog(botID).Warn("📤 queued send failed", "queue_id", item.ID, "key", item.Key, "err", err)
+		evt = EvtMessageFail
+		data["error"] = err.Error()
+	} else {
+		mAPISends.Inc(botID, "sent")
+		botLog(botID).Info("📤 queued send delivered", "queue_id", item.ID, "msg", msgID, "key", item.Key)
+		data["message_id"] = msgID
+	}
+	wsHub.Publish(WSEvent{Event: evt, BotID: botID, Data: data, Scope: ScopeSendMessages})
+}
+
+// StartSendQueueWorker: کنکشن ایونٹ چھوٹ جائے تب بھی قطار نہ رکے
+func StartSendQueueWorker() {
+	go func() {
+		ticker := time.NewTicker(sendQueueTick)
+		defer ticker.Stop()
+		for range ticker.C {
+			if rdb == nil {
+				continue
+			}
+			clientsMutex.RLock()
+			ids := make([]string, 0, len(activeClients))
+			for id := range activeClients {
+				ids = append(ids, id)
+			}
+			clientsMutex.RUnlock()
+			for _, id := range ids {
+				if n, _ := rdb.LLen(ctx, sendQueuePrefix+id).Result(); n > 0 {
+					go flushSendQueue(id)
+				}
+			}
+		}
+	}()
+}
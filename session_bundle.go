@@ -0,0 +1,596 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// =========================================================
+// 📦 SESSION BUNDLE (ایک ہوسٹ سے دوسرے پر بوٹ منتقل کریں)
+// ایک ڈیوائس کا whatsmeow اسٹور (Postgres) + سیٹنگز (prefix, antidm,
+// group settings — جس بھی settingsStore میں ہوں) + LID میپنگ ایک انکرپٹڈ فائل میں۔ امپورٹ کے بعد بوٹ
+// دوبارہ پیئر کیے بغیر چل پڑتا ہے۔
+// فائل: "IBSESS1" + salt(16) + nonce(12) + AES-256-GCM(gzip(JSON))
+// کلید: PBKDF2-SHA256(passphrase)
+// =========================================================
+
+const (
+	bundleMagic      = "IBSESS1"
+	bundleFormat     = 1
+	bundleSaltLen    = 16
+	bundleKDFRounds  = 600000
+	bundleMinPass    = 12
+	bundleMaxSize    = 64 << 20
+	bundlePassHeader = "X-Bundle-Passphrase"
+)
+
+// sessionTables: FK ترتیب میں (device پہلے، version → mutation_macs)؛ owner = اس ڈیوائس کا کالم
+var sessionTables = []struct{ name, owner string }{
+	{"whatsmeow_device", "jid"},
+	{"whatsmeow_identity_keys", "our_jid"},
+	{"whatsmeow_pre_keys", "jid"},
+	{"whatsmeow_sessions", "our_jid"},
+	{"whatsmeow_sender_keys", "our_jid"},
+	{"whatsmeow_app_state_sync_keys", "jid"},
+	{"whatsmeow_app_state_version", "jid"},
+	{"whatsmeow_app_state_mutation_macs", "jid"},
+	{"whatsmeow_contacts", "our_jid"},
+	{"whatsmeow_chat_settings", "our_jid"},
+	{"whatsmeow_message_secrets", "our_jid"},
+	{"whatsmeow_privacy_tokens", "our_jid"},
+}
+
+var bundleColumnRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// SessionBundle: انکرپشن سے پہلے کا مواد
+type SessionBundle struct {
+	Format     int               `json:"format"`
+	BotID      string            `json:"bot_id"`
+	JID        string            `json:"jid"`
+	ExportedAt int64             `json:"exported_at"`
+	Tables     []bundleTable     `json:"tables"`
+	Redis      map[string]string `json:"redis"`         // سیٹنگز کیز جیسی کی تیسی (نام پرانے فارمیٹ سے)
+	LID        string            `json:"lid,omitempty"` // BotLIDInfo JSON
+}
+
+type bundleTable struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Binary  []bool   `json:"binary"` // bytea کالم (JSON میں base64)
+	Rows    [][]any  `json:"rows"`
+}
+
+// BundleResult: API / CLI کے لیے خلاصہ
+type BundleResult struct {
+	BotID string         `json:"bot_id"`
+	JID   string         `json:"jid"`
+	Rows  map[string]int `json:"rows"`
+	Redis int            `json:"redis_keys"`
+}
+
+// botSettingKeys: اس بوٹ کی string سیٹنگز کیز
+func botSettingKeys(c context.Context, botID string) ([]string, error) {
+	keys := []string{"prefix:" + botID, "antidm:" + botID, antiDeleteKey(botID), "settings:" + botID, botSettingsKeyPrefix + botID}
+	groups, err := settingsStore.Keys(c, "group_settings:"+botID+":")
+	return append(keys, groups...), err
+}
+
+// findBotDevice: نمبر سے sqlstore ڈیوائس کا JID
+func findBotDevice(c context.Context, botID string) (types.JID, error) {
+	devices, err := container.GetAllDevices(c)
+	if err != nil {
+		return types.JID{}, err
+	}
+	for _, dev := range devices {
+		if getCleanID(dev.ID.User) == botID {
+			return *dev.ID, nil
+		}
+	}
+	return types.JID{}, fmt.Errorf("no session stored for %s", botID)
+}
+
+// ExportSession: ایک بوٹ کا مکمل بنڈل (ایک ہی اسنیپ شاٹ ٹرانزیکشن میں)
+func ExportSession(c context.Context, botID string) (*SessionBundle, error) {
+	if sessionDB == nil || settingsStore == nil {
+		return nil, errors.New("postgres/settings store not connected")
+	}
+	botID = getCleanID(botID)
+	jid, err := findBotDevice(c, botID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := sessionDB.BeginTx(c, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	b := &SessionBundle{Format: bundleFormat, BotID: botID, JID: jid.String(), ExportedAt: time.Now().Unix(), Redis: make(map[string]string)}
+	for _, t := range sessionTables {
+		bt, err := dumpSessionTable(c, tx, t.name, t.owner, b.JID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+		b.Tables = append(b.Tables, bt)
+	}
+
+	keys, err := botSettingKeys(c, botID)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		val, err := settingsStore.Get(c, k)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("settings %s: %w", k, err)
+		}
+		b.Redis[k] = val
+	}
+	if lid, err := stateStore.Get(c, lidStateKey(botID)); err == nil {
+		b.LID = string(lid)
+	}
+	return b, nil
+}
+
+func dumpSessionTable(c context.Context, tx *sql.Tx, table, owner, jid string) (bundleTable, error) {
+	bt := bundleTable{Name: table}
+	rows, err := tx.QueryContext(c, fmt.Sprintf("SELECT * FROM %s WHERE %s = $1", table, owner), jid)
+	if err != nil {
+		return bt, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return bt, err
+	}
+	for _, col := range cols {
+		bt.Columns = append(bt.Columns, col.Name())
+		bt.Binary = append(bt.Binary, strings.EqualFold(col.DatabaseTypeName(), "BYTEA"))
+	}
+
+	for rows.Next() {
+		vals := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return bt, err
+		}
+		for i, v := range vals {
+			// uuid وغیرہ بھی []byte میں آتے ہیں — صرف bytea بائنری رہے
+			if raw, ok := v.([]byte); ok && !bt.Binary[i] {
+				vals[i] = string(raw)
+			}
+		}
+		bt.Rows = append(bt.Rows, vals)
+	}
+	return bt, rows.Err()
+}
+
+// ImportSession: بنڈل کو اس انسٹینس کے اسٹور اور سیٹنگز میں لکھیں
+func ImportSession(c context.Context, b *SessionBundle) (*BundleResult, error) {
+	if sessionDB == nil || settingsStore == nil {
+		return nil, errors.New("postgres/settings store not connected")
+	}
+	if b.Format != bundleFormat {
+		return nil, fmt.Errorf("unsupported bundle format %d", b.Format)
+	}
+	jid, err := types.ParseJID(b.JID)
+	if err != nil || jid.User == "" {
+		return nil, fmt.Errorf("bundle has invalid jid %q", b.JID)
+	}
+	botID := getCleanID(jid.User)
+	if botID != b.BotID {
+		return nil, fmt.Errorf("bundle jid %s does not match bot %s", b.JID, b.BotID)
+	}
+
+	tables := make(map[string]bundleTable, len(b.Tables))
+	for _, bt := range b.Tables {
+		tables[bt.Name] = bt
+	}
+	if len(tables["whatsmeow_device"].Rows) != 1 {
+		return nil, errors.New("bundle has no device row")
+	}
+
+	tx, err := sessionDB.BeginTx(c, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// device حذف → باقی ٹیبلز cascade؛ privacy_tokens پر FK نہیں
+	if _, err := tx.ExecContext(c, "DELETE FROM whatsmeow_device WHERE jid = $1", b.JID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(c, "DELETE FROM whatsmeow_privacy_tokens WHERE our_jid = $1", b.JID); err != nil {
+		return nil, err
+	}
+
+	res := &BundleResult{BotID: botID, JID: b.JID, Rows: make(map[string]int)}
+	for _, t := range sessionTables {
+		bt, ok := tables[t.name]
+		if !ok || len(bt.Rows) == 0 {
+			continue
+		}
+		n, err := restoreSessionTable(c, tx, bt, t.owner, b.JID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+		res.Rows[t.name] = n
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// بنڈل پورا لکھا گیا — اب اسی نمبر کا چلتا کلائنٹ / پرانی ڈیوائسز ہٹائیں (ناکام امپورٹ پر سیشن سلامت رہے)
+	dropBotSessions(botID, &jid)
+
+	// ⚙️ سیٹنگز + میموری کیشز
+	for k, v := range b.Redis {
+		if !strings.HasSuffix(k, ":"+botID) && !strings.HasPrefix(k, "group_settings:"+botID+":") {
+			continue // کسی اور بوٹ کی کی — نظر انداز
+		}
+		if err := settingsStore.Set(c, k, v); err != nil {
+			return nil, fmt.Errorf("settings %s: %w", k, err)
+		}
+		res.Redis++
+		if strings.HasPrefix(k, "group_settings:") {
+			var gs GroupSettings
+			if json.Unmarshal([]byte(v), &gs) == nil {
+				cacheMutex.Lock()
+				groupCache[strings.TrimPrefix(k, "group_settings:")] = &gs
+				cacheMutex.Unlock()
+			}
+		}
+	}
+	forgetBotSettings(botID)
+	if b.LID != "" {
+		var info BotLIDInfo
+		if json.Unmarshal([]byte(b.LID), &info) == nil && info.LID != "" {
+			stateStore.Put(c, lidStateKey(botID), []byte(b.LID), 0)
+			lidCacheMutex.Lock()
+			lidCache[botID] = info.LID
+			lidCacheMutex.Unlock()
+		}
+	}
+	return res, nil
+}
+
+func restoreSessionTable(c context.Context, tx *sql.Tx, bt bundleTable, owner, jid string) (int, error) {
+	ownerIdx := -1
+	holders := make([]string, len(bt.Columns))
+	for i, col := range bt.Columns {
+		if !bundleColumnRe.MatchString(col) {
+			return 0, fmt.Errorf("bad column name %q", col)
+		}
+		if col == owner {
+			ownerIdx = i
+		}
+		holders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	if ownerIdx < 0 || len(bt.Binary) != len(bt.Columns) {
+		return 0, errors.New("malformed table in bundle")
+	}
+
+	stmt, err := tx.PrepareContext(c, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", bt.Name, strings.Join(bt.Columns, ", "), strings.Join(holders, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, row := range bt.Rows {
+		if len(row) != len(bt.Columns) {
+			return 0, errors.New("row/column count mismatch")
+		}
+		args := make([]any, len(row))
+		for i, v := range row {
+			switch x := v.(type) {
+			case string:
+				if bt.Binary[i] {
+					raw, err := base64.StdEncoding.DecodeString(x)
+					if err != nil {
+						return 0, fmt.Errorf("column %s: %w", bt.Columns[i], err)
+					}
+					args[i] = raw
+				} else {
+					args[i] = x
+				}
+			case json.Number:
+				args[i] = x.String() // Postgres کالم ٹائپ سے خود کاسٹ کرے گا
+			default:
+				args[i] = x // bool / nil
+			}
+		}
+		if args[ownerIdx] != jid {
+			return 0, errors.New("row belongs to a different device")
+		}
+		if _, err := stmt.ExecContext(c, args...); err != nil {
+			return 0, err
+		}
+	}
+	return len(bt.Rows), nil
+}
+
+// =========================================================
+// 🔐 ENCRYPTION
+// =========================================================
+
+func bundleKey(passphrase string, salt []byte) ([]byte, error) {
+	if len(passphrase) < bundleMinPass {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", bundleMinPass)
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, bundleKDFRounds, 32)
+}
+
+// SealBundle: JSON → gzip → AES-GCM
+func SealBundle(b *SessionBundle, passphrase string) ([]byte, error) {
+	salt := make([]byte, bundleSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := bundleKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var plain bytes.Buffer
+	zw := gzip.NewWriter(&plain)
+	if err := json.NewEncoder(zw).Encode(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte(bundleMagic), salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain.Bytes(), []byte(bundleMagic)), nil
+}
+
+// OpenBundle: غلط passphrase یا چھیڑی گئی فائل پر ایرر
+func OpenBundle(data []byte, passphrase string) (*SessionBundle, error) {
+	if !bytes.HasPrefix(data, []byte(bundleMagic)) {
+		return nil, errors.New("not a session bundle")
+	}
+	data = data[len(bundleMagic):]
+	if len(data) < bundleSaltLen+12 {
+		return nil, errors.New("bundle is truncated")
+	}
+	key, err := bundleKey(passphrase, data[:bundleSaltLen])
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	data = data[bundleSaltLen:]
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(bundleMagic))
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted bundle")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(zr)
+	dec.UseNumber() // بڑے عدد float میں نہ بدلیں
+	var b SessionBundle
+	if err := dec.Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// =========================================================
+// 🌐 HTTP (admin)
+// =========================================================
+
+// POST /api/sessions/export  {"bot_id":"923...","passphrase":"...","detach":false}
+// detach=true: ایکسپورٹ کے بعد اس ہوسٹ سے سیشن ہٹا دیں (لاگ آؤٹ نہیں) تاکہ دو جگہ نہ چلے
+func handleSessionExportAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"Method not allowed"}`, 405)
+		return
+	}
+	var req struct {
+		BotID      string `json:"bot_id"`
+		Passphrase string `json:"passphrase"`
+		Detach     bool   `json:"detach"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.BotID == "" {
+		http.Error(w, `{"error":"bot_id and passphrase required"}`, 400)
+		return
+	}
+	botID := getCleanID(req.BotID)
+
+	b, err := ExportSession(r.Context(), botID)
+	if err != nil {
+		writeJSONError(w, 400, err)
+		return
+	}
+	sealed, err := SealBundle(b, req.Passphrase)
+	if err != nil {
+		writeJSONError(w, 400, err)
+		return
+	}
+	botLog(botID).Info("📦 session exported", "tables", len(b.Tables), "redis_keys", len(b.Redis), "detach", req.Detach)
+
+	if req.Detach {
+		dropBotSessions(botID, nil)
+		emitBotEvent(botID, EvtSessionGone, map[string]any{"reason": "exported"})
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.ibsession"`, botID, time.Now().Format("20060102")))
+	w.Write(sealed)
+}
+
+// POST /api/sessions/import  (body = بنڈل فائل، X-Bundle-Passphrase ہیڈر)
+func handleSessionImportAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"Method not allowed"}`, 405)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, bundleMaxSize))
+	if err != nil {
+		writeJSONError(w, 400, err)
+		return
+	}
+	b, err := OpenBundle(data, r.Header.Get(bundlePassHeader))
+	if err != nil {
+		writeJSONError(w, 400, err)
+		return
+	}
+	res, err := ImportSession(r.Context(), b)
+	if err != nil {
+		writeJSONError(w, 500, err)
+		return
+	}
+	botLog(res.BotID).Info("📦 session imported", "rows", res.Rows, "redis_keys", res.Redis)
+
+	// ▶️ پیئرنگ کے بغیر فوراً چلائیں
+	if jid, err := types.ParseJID(res.JID); err == nil {
+		if device, err := container.GetDevice(context.Background(), jid); err == nil && device != nil {
+			takeOverBotLease(res.BotID)
+			go ConnectNewSession(device)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"success": true, "import": res})
+}
+
+func writeJSONError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+}
+
+// =========================================================
+// 💻 CLI: ./bot session export|import
+// passphrase: --passphrase یا SESSION_BUNDLE_PASSPHRASE
+// =========================================================
+
+func runSessionCLI(args []string) int {
+	usage := func() int {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  bot session export --bot <number> --out <file> [--passphrase <p>]")
+		fmt.Fprintln(os.Stderr, "  bot session import --in <file> [--passphrase <p>]")
+		fmt.Fprintln(os.Stderr, "  (passphrase defaults to $SESSION_BUNDLE_PASSPHRASE)")
+		return 2
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	var bot, file, pass string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--bot" && i+1 < len(args):
+			i++
+			bot = args[i]
+		case (args[i] == "--out" || args[i] == "--in") && i+1 < len(args):
+			i++
+			file = args[i]
+		case args[i] == "--passphrase" && i+1 < len(args):
+			i++
+			pass = args[i]
+		default:
+			return usage()
+		}
+	}
+	if pass == "" {
+		pass = os.Getenv("SESSION_BUNDLE_PASSPHRASE")
+	}
+	if file == "" {
+		return usage()
+	}
+
+	switch args[0] {
+	case "export":
+		if bot == "" {
+			return usage()
+		}
+		b, err := ExportSession(context.Background(), bot)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		sealed, err := SealBundle(b, pass)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		if err := os.WriteFile(file, sealed, 0600); err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		botLog(b.BotID).Info("✅ session exported", "file", file)
+		fmt.Println("⚠️ Stop or delete the bot on this host after importing it elsewhere — one session cannot run in two places.")
+		return 0
+
+	case "import":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		b, err := OpenBundle(data, pass)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		res, err := ImportSession(context.Background(), b)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		botLog(res.BotID).Info("✅ session imported", "rows", res.Rows, "redis_keys", res.Redis)
+		fmt.Println("ℹ️ A running server picks the session up within a minute (or on next start).")
+		return 0
+	}
+	return usage()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSealOpenBundle(t *testing.T) {
+	const pass = "correct horse battery"
+	in := &SessionBundle{
+		Format:     bundleFormat,
+		BotID:      "923001234567",
+		JID:        "923001234567:12@s.whatsapp.net",
+		ExportedAt: 1735732800,
+		Tables: []bundleTable{{
+			Name:    "whatsmeow_device",
+			Columns: []string{"jid", "registration_id", "noise_key"},
+			Binary:  []bool{false, false, true},
+			Rows:    [][]any{{"923001234567:12@s.whatsapp.net", json.Number("9007199254740993"), "AAEC"}},
+		}},
+		Redis: map[string]string{"prefix:923001234567": "!"},
+		LID:   `{"lid":"88001"}`,
+	}
+
+	sealed, err := SealBundle(in, pass)
+	if err != nil {
+		t.Fatalf("SealBundle: %v", err)
+	}
+	out, err := OpenBundle(sealed, pass)
+	if err != nil {
+		t.Fatalf("OpenBundle: %v", err)
+	}
+	// بڑا عدد float میں نہیں بدلنا چاہیے
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", out, in)
+	}
+
+	again, err := SealBundle(in, pass)
+	if err != nil {
+		t.Fatalf("SealBundle(again): %v", err)
+	}
+	if string(again) == string(sealed) {
+		t.Error("two seals are identical — salt/nonce not random")
+	}
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0xff
+	for name, tc := range map[string]struct {
+		data []byte
+		pass string
+	}{
+		"wrong passphrase": {sealed, pass + "!"},
+		"tampered":         {tampered, pass},
+		"truncated":        {sealed[:len(bundleMagic)+4], pass},
+		"not a bundle":     {[]byte("{}"), pass},
+	} {
+		if _, err := OpenBundle(tc.data, tc.pass); err == nil {
+			t.Errorf("OpenBundle(%s) succeeded", name)
+		}
+	}
+
+	if _, err := SealBundle(in, "short"); err == nil {
+		t.Error("SealBundle accepted a short passphrase")
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// =========================================================
+// 🩺 SESSION SUPERVISOR (ہر بوٹ کی کنکشن صحت)
+// whatsmeow کا اپنا auto-reconnect بند ہے؛ یہاں exponential backoff سے
+// دوبارہ کنیکٹ ہوتا ہے۔ LoggedOut پر ڈیوائس صاف، StreamReplaced / TemporaryBan
+// پر وقفہ، اور مالک کو کسی دوسرے صحت مند بوٹ یا WebSocket سے اطلاع۔
+// =========================================================
+
+const (
+	BotOnline       = "online"
+	BotReconnecting = "reconnecting"
+	BotLoggedOut    = "logged-out"
+	BotReplaced     = "replaced"
+	BotBanned       = "temp-banned"
+
+	reconnectBase     = 2 * time.Second
+	reconnectMax      = 5 * time.Minute
+	replacedHold      = 15 * time.Minute // دوسری جگہ کھلا سیشن — فوراً چھین کر لڑائی نہ کریں
+	defaultBanHold    = time.Hour        // expire نہ ملے تو
+	ownerNotifyWindow = 30 * time.Minute // ایک ہی اطلاع بار بار نہیں
+)
+
+// BotHealth: کسی بوٹ کی موجودہ حالت
+type BotHealth struct {
+	State     string    `json:"state"`
+	Attempts  int       `json:"attempts,omitempty"`
+	HoldUntil time.Time `json:"hold_until,omitempty"` // اس سے پہلے دوبارہ کنیکٹ نہیں
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since"`
+
+	stop     chan struct{} // چلتا ہوا reconnect loop روکنے کے لیے
+	notified map[string]time.Time
+}
+
+var (
+	botHealth   = make(map[string]*BotHealth)
+	botHealthMu sync.Mutex
+)
+
+// healthOf: لاک کے اندر بلائیں
+func healthOf(botID string) *BotHealth {
+	h, ok := botHealth[botID]
+	if !ok {
+		h = &BotHealth{State: BotOnline, Since: time.Now(), notified: make(map[string]time.Time)}
+		botHealth[botID] = h
+	}
+	return h
+}
+
+func setBotState(botID, state string) {
+	botHealthMu.Lock()
+	h := healthOf(botID)
+	if h.State != state {
+		h.State = state
+		h.Since = time.Now()
+	}
+	botHealthMu.Unlock()
+}
+
+// GetBotHealth: API / کمانڈز کے لیے کاپی
+func GetBotHealth(botID string) (BotHealth, bool) {
+	botHealthMu.Lock()
+	defer botHealthMu.Unlock()
+	h, ok := botHealth[getCleanID(botID)]
+	if !ok {
+		return BotHealth{}, false
+	}
+	return BotHealth{State: h.State, Attempts: h.Attempts, HoldUntil: h.HoldUntil, LastError: h.LastError, Since: h.Since}, true
+}
+
+// isBotOnHold: monitorNewSessions اس بوٹ کو ابھی نہ چھیڑے
+func isBotOnHold(botID string) bool {
+	botHealthMu.Lock()
+	defer botHealthMu.Unlock()
+	h, ok := botHealth[botID]
+	return ok && time.Now().Before(h.HoldUntil)
+}
+
+// superviseClient: ConnectNewSession سے — ہمارا backoff استعمال ہو
+func superviseClient(c *whatsmeow.Client) {
+	c.EnableAutoReconnect = false
+	c.AddEventHandler(func(evt interface{}) {
+		switch v := evt.(type) {
+		case *events.Connected:
+			onBotConnected(c)
+		case *events.Disconnected:
+			scheduleReconnect(c, "disconnected", 0)
+		case *events.LoggedOut:
+			onBotLoggedOut(c, v)
+		case *events.StreamReplaced:
+			onBotHeld(c, BotReplaced, replacedHold, "session opened somewhere else (stream replaced)")
+		case *events.TemporaryBan:
+			hold := v.Expire
+			if hold <= 0 {
+				hold = defaultBanHold
+			}
+			onBotHeld(c, BotBanned, hold, v.String())
+		}
+	})
+}
+
+func onBotConnected(c *whatsmeow.Client) {
+	if c.Store.ID == nil {
+		return
+	}
+	botID := getCleanID(c.Store.ID.User)
+	botHealthMu.Lock()
+	h := healthOf(botID)
+	wasDown := h.State != BotOnline
+	h.State, h.Attempts, h.LastError, h.HoldUntil = BotOnline, 0, "", time.Time{}
+	h.Since = time.Now()
+	botHealthMu.Unlock()
+	if wasDown {
+		botLog(botID).Info("🩺 bot back online")
+	}
+	go flushSendQueue(botID) // 📤 آف لائن کے دوران API سے آئے میسجز
+}
+
+// scheduleReconnect: exponential backoff + jitter؛ ایک بوٹ کا ایک ہی loop
+func scheduleReconnect(c *whatsmeow.Client, reason string, delayFloor time.Duration) {
+	if c.Store.ID == nil {
+		return // لاگ آؤٹ — دوبارہ کنیکٹ کرنے کو کچھ نہیں
+	}
+	botID := getCleanID(c.Store.ID.User)
+
+	botHealthMu.Lock()
+	h := healthOf(botID)
+	if h.stop != nil {
+		botHealthMu.Unlock()
+		return // پہلے سے چل رہا ہے
+	}
+	stop := make(chan struct{})
+	h.stop = stop
+	if h.State == BotOnline {
+		h.State = BotReconnecting
+		h.Since = time.Now()
+	}
+	botHealthMu.Unlock()
+
+	blog := botLog(botID)
+	blog.Warn("🩺 bot disconnected, reconnecting", "reason", reason)
+
+	go func() {
+		defer func() {
+			botHealthMu.Lock()
+			if h.stop == stop {
+				h.stop = nil
+			}
+			botHealthMu.Unlock()
+		}()
+
+		for {
+			botHealthMu.Lock()
+			h.Attempts++
+			attempt := h.Attempts
+			hold := time.Until(h.HoldUntil)
+			botHealthMu.Unlock()
+
+			delay := backoffDelay(attempt)
+			if delay < delayFloor {
+				delay = delayFloor
+			}
+			if hold > delay {
+				delay = hold
+			}
+			emitBotEvent(botID, EvtReconnecting, map[string]any{"attempt": attempt, "in_seconds": int(delay.Seconds()), "reason": reason})
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+
+			if c.Store.ID == nil {
+				return
+			}
+			if c.IsConnected() {
+				return
+			}
+			err := c.Connect()
+			if err == nil || err == whatsmeow.ErrAlreadyConnected {
+				return // Connected ایونٹ حالت online کر دے گا
+			}
+
+			botHealthMu.Lock()
+			h.LastError = err.Error()
+			botHealthMu.Unlock()
+			blog.Warn("🩺 reconnect failed", "attempt", attempt, "err", err)
+			if attempt == 5 {
+				notifyBotOwner(botID, "reconnect", fmt.Sprintf("⚠️ Bot *%s* has been offline for a while and keeps failing to reconnect.\nLast error: %v", botID, err))
+			}
+		}
+	}()
+}
+
+func backoffDelay(attempt int) time.Duration {
+	d := reconnectBase
+	for i := 1; i < attempt && d < reconnectMax; i++ {
+		d *= 2
+	}
+	if d > reconnectMax {
+		d = reconnectMax
+	}
+	// ±20% jitter تاکہ سب بوٹس ایک ساتھ نہ ٹکرائیں
+	jitter := time.Duration(rand.Int63n(int64(d)/5+1)) - d/10
+	return d + jitter
+}
+
+func stopReconnect(botID string) {
+	botHealthMu.Lock()
+	if h, ok := botHealth[botID]; ok && h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+	botHealthMu.Unlock()
+}
+
+// onBotLoggedOut: مستقل — کلائنٹ ہٹائیں، ڈیوائس صاف، مالک کو بتائیں
+func onBotLoggedOut(c *whatsmeow.Client, v *events.LoggedOut) {
+	// whatsmeow اسی وقت Store.Delete کرتا ہے، اس لیے ID پہلے ہی پکڑ لیں
+	var jid types.JID
+	if c.Store.ID != nil {
+		jid = *c.Store.ID
+	}
+	botID := ""
+	clientsMutex.Lock()
+	for id, ac := range activeClients {
+		if ac == c {
+			botID = id
+			delete(activeClients, id)
+		}
+	}
+	clientsMutex.Unlock()
+	if botID == "" && !jid.IsEmpty() {
+		botID = getCleanID(jid.User)
+	}
+	if botID == "" {
+		return
+	}
+
+	stopReconnect(botID)
+	releaseBotLease(botID)
+	setBotState(botID, BotLoggedOut)
+	c.Disconnect()
+
+	// 🧹 sqlstore میں بچی ہوئی ڈیوائس (whatsmeow کا delete ناکام ہو تو)
+	if dbContainer != nil && !jid.IsEmpty() {
+		if dev, err := dbContainer.GetDevice(context.Background(), jid); err == nil && dev != nil {
+			dev.Delete(context.Background())
+		}
+	}
+
+	botLog(botID).Warn("🩺 bot logged out, session removed", "reason", v.Reason.String(), "on_connect", v.OnConnect)
+	notifyBotOwner(botID, "logged-out", fmt.Sprintf("╔════════════════════╗\n║ 🔌 BOT LOGGED OUT\n╠════════════════════╣\n║ Bot: %s\n║ Reason: %s\n╚════════════════════╝\n\nThe session was removed from the server. Pair again to bring it back.", botID, v.Reason.String()))
+}
+
+// onBotHeld: StreamReplaced / TemporaryBan — کچھ دیر رکیں پھر کوشش
+func onBotHeld(c *whatsmeow.Client, state string, hold time.Duration, reason string) {
+	if c.Store.ID == nil {
+		return
+	}
+	botID := getCleanID(c.Store.ID.User)
+
+	stopReconnect(botID)
+	botHealthMu.Lock()
+	h := healthOf(botID)
+	h.State, h.LastError, h.Since = state, reason, time.Now()
+	h.HoldUntil = time.Now().Add(hold)
+	h.Attempts = 0
+	botHealthMu.Unlock()
+
+	botLog(botID).Warn("🩺 bot on hold", "state", state, "reason", reason, "retry_in", hold.Round(time.Second))
+	notifyBotOwner(botID, state, fmt.Sprintf("╔════════════════════╗\n║ ⏸️ BOT PAUSED\n╠════════════════════╣\n║ Bot: %s\n║ %s\n║ Retry in: %s\n╚════════════════════╝", botID, reason, hold.Round(time.Minute)))
+	scheduleReconnect(c, state, hold)
+}
+
+// notifyBotOwner: WebSocket ایونٹ + کسی دوسرے صحت مند بوٹ سے مالک کے نمبر پر میسج
+func notifyBotOwner(botID, kind, text string) {
+	botHealthMu.Lock()
+	h := healthOf(botID)
+	if last, ok := h.notified[kind]; ok && time.Since(last) < ownerNotifyWindow {
+		botHealthMu.Unlock()
+		return
+	}
+	h.notified[kind] = time.Now()
+	botHealthMu.Unlock()
+
+	emitBotEvent(botID, EvtOwnerAlert, map[string]any{"kind": kind, "message": text})
+
+	var messenger *whatsmeow.Client
+	clientsMutex.RLock()
+	for id, c := range activeClients {
+		if id != botID && c != nil && c.IsConnected() && c.IsLoggedIn() {
+			messenger = c
+			break
+		}
+	}
+	clientsMutex.RUnlock()
+	if messenger == nil {
+		botLog(botID).Warn("🩺 no healthy bot to notify owner", "kind", kind)
+		return
+	}
+
+	to := types.NewJID(botID, types.DefaultUserServer)
+	_, err := messenger.SendMessage(context.Background(), to, &waProto.Message{Conversation: proto.String(text)})
+	if err != nil {
+		botLog(botID).Warn("🩺 owner notification failed", "kind", kind, "err", err)
+	}
+}
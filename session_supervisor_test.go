@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	cases := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{0, 2 * time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{6, 64 * time.Second},
+		{8, 256 * time.Second},
+		{9, reconnectMax},
+		{50, reconnectMax},
+	}
+	for _, tc := range cases {
+		lo, hi := tc.base-tc.base/10, tc.base+tc.base/10
+		for i := 0; i < 200; i++ {
+			if d := backoffDelay(tc.attempt); d < lo || d > hi {
+				t.Fatalf("backoffDelay(%d) = %v, want %v..%v", tc.attempt, d, lo, hi)
+			}
+		}
+	}
+}
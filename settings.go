@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"encoding/json"
 	"time"
 
 	"go.mau.fi/whatsmeow"
@@ -24,10 +23,9 @@ func toggleAlwaysOnline(client *whatsmeow.Client, v *events.Message) {
 	status := "OFF 🔴"
 	statusText := "Disabled"
 	
-	dataMutex.Lock()
-	data.AlwaysOnline = !data.AlwaysOnline
-	newState := data.AlwaysOnline
-	dataMutex.Unlock()
+	newState := UpdateBotSettings(settingsBotID(client), func(s *BotData) {
+		s.AlwaysOnline = !s.AlwaysOnline
+	}).AlwaysOnline
 
 	// ⚡ فوری اثر کے لیے ابھی بھیجیں
 	if newState {
@@ -37,9 +35,6 @@ func toggleAlwaysOnline(client *whatsmeow.Client, v *events.Message) {
 	} else {
 		client.SendPresence(context.Background(), types.PresenceUnavailable)
 	}
-    
-    // 💾 سیٹنگز کو محفوظ کرنا نہ بھولیں (Redis/File میں)
-    // saveGlobalSettings() // اگر آپ کا کوئی سیو فنکشن ہے تو یہاں کال کریں
 
 	msg := fmt.Sprintf(`╔════════════════╗
 ║ ⚙️ ALWAYS ONLINE
@@ -66,13 +61,13 @@ func toggleAutoRead(client *whatsmeow.Client, v *events.Message) {
 
 	status := "OFF 🔴"
 	statusText := "Disabled"
-	dataMutex.Lock()
-	data.AutoRead = !data.AutoRead
-	if data.AutoRead {
+	newState := UpdateBotSettings(settingsBotID(client), func(s *BotData) {
+		s.AutoRead = !s.AutoRead
+	}).AutoRead
+	if newState {
 		status = "ON 🟢"
 		statusText = "Enabled"
 	}
-	dataMutex.Unlock()
 
 	msg := fmt.Sprintf(`╔════════════════╗
 ║ ⚙️ AUTO READ
@@ -102,14 +97,14 @@ func toggleAutoReact(client *whatsmeow.Client, v *events.Message) {
 	body := strings.TrimSpace(getText(v.Message))
 	parts := strings.Fields(body)
 
-	dataMutex.Lock()
-	defer dataMutex.Unlock()
+	botID := settingsBotID(client)
+	current := GetBotSettings(botID)
 
 	// 3. اگر صرف کمانڈ ہے (.autoreact) تو اسٹیٹس دکھائیں
 	if len(parts) == 1 {
 		statusIcon := "🔴"
 		statusText := "Disabled"
-		if data.AutoReact {
+		if current.AutoReact {
 			statusIcon = "🟢"
 			statusText = "Enabled"
 		}
@@ -128,7 +123,7 @@ func toggleAutoReact(client *whatsmeow.Client, v *events.Message) {
 	action := strings.ToLower(parts[1])
 
 	if action == "on" || action == "enable" {
-		if data.AutoReact {
+		if current.AutoReact {
 			// اگر پہلے سے آن ہے
 			msg := `╔════════════════╗
 ║ ⚠️ ALREADY ACTIVE
@@ -139,7 +134,7 @@ func toggleAutoReact(client *whatsmeow.Client, v *events.Message) {
 			replyMessage(client, v, msg)
 		} else {
 			// اب آن کریں
-			data.AutoReact = true
+			UpdateBotSettings(botID, func(s *BotData) { s.AutoReact = true })
 			msg := `╔════════════════╗
 ║ ✅ SUCCESS
 ╠════════════════╣
@@ -149,7 +144,7 @@ func toggleAutoReact(client *whatsmeow.Client, v *events.Message) {
 			replyMessage(client, v, msg)
 		}
 	} else if action == "off" || action == "disable" {
-		if !data.AutoReact {
+		if !current.AutoReact {
 			// اگر پہلے سے آف ہے
 			msg := `╔════════════════╗
 ║ ⚠️ ALREADY OFF
@@ -160,7 +155,7 @@ func toggleAutoReact(client *whatsmeow.Client, v *events.Message) {
 			replyMessage(client, v, msg)
 		} else {
 			// اب آف کریں
-			data.AutoReact = false
+			UpdateBotSettings(botID, func(s *BotData) { s.AutoReact = false })
 			msg := `╔════════════════╗
 ║ 🛑 STOPPED
 ╠════════════════╣
@@ -175,14 +170,6 @@ func toggleAutoReact(client *whatsmeow.Client, v *events.Message) {
 	}
 }
 
-// ✅ گلوبل سیٹنگز سیو کرنے کا ہیلپر فنکشن
-func saveGlobalSettings() {
-	if rdb != nil {
-		jsonBytes, _ := json.Marshal(data)
-		rdb.Set(ctx, "bot_global_settings", jsonBytes, 0)
-	}
-}
-
 func toggleAutoStatus(client *whatsmeow.Client, v *events.Message) {
 	if !isOwner(client, v.Info.Sender) {
 		replyMessage(client, v, "❌ Owner Only!")
@@ -193,34 +180,30 @@ func toggleAutoStatus(client *whatsmeow.Client, v *events.Message) {
 	body := strings.TrimSpace(getText(v.Message))
 	parts := strings.Fields(body)
 
-	dataMutex.Lock()
-	defer dataMutex.Unlock()
+	botID := settingsBotID(client)
 
 	// 2. اگر صرف سٹیٹس چیک کرنا ہو
 	if len(parts) == 1 {
 		status := "OFF 🔴"
-		if data.AutoStatus { status = "ON 🟢" }
+		if GetBotSettings(botID).AutoStatus { status = "ON 🟢" }
 		replyMessage(client, v, fmt.Sprintf("📊 *Auto Status:* %s", status))
 		return
 	}
 
 	// 3. On/Off لاجک
 	arg := strings.ToLower(parts[1])
-	if arg == "on" || arg == "enable" {
-		data.AutoStatus = true
-	} else if arg == "off" || arg == "disable" {
-		data.AutoStatus = false
-	} else {
+	if arg != "on" && arg != "enable" && arg != "off" && arg != "disable" {
 		replyMessage(client, v, "⚠️ Usage: .autostatus on | off")
 		return
 	}
 
-	// 4. ✅ Redis میں سیو کریں (تاکہ ری سٹارٹ پر یاد رہے)
-	saveGlobalSettings()
+	// 4. ✅ اسی بوٹ کی سیٹنگ Redis میں سیو (تاکہ ری سٹارٹ پر یاد رہے)
+	enabled := arg == "on" || arg == "enable"
+	UpdateBotSettings(botID, func(s *BotData) { s.AutoStatus = enabled })
 
 	state := "Disabled"
 	icon := "🔴"
-	if data.AutoStatus {
+	if enabled {
 		state = "Enabled"
 		icon = "🟢"
 	}
@@ -244,32 +227,28 @@ func toggleStatusReact(client *whatsmeow.Client, v *events.Message) {
 	body := strings.TrimSpace(getText(v.Message))
 	parts := strings.Fields(body)
 
-	dataMutex.Lock()
-	defer dataMutex.Unlock()
+	botID := settingsBotID(client)
 
 	if len(parts) == 1 {
 		status := "OFF 🔴"
-		if data.StatusReact { status = "ON 🟢" }
+		if GetBotSettings(botID).StatusReact { status = "ON 🟢" }
 		replyMessage(client, v, fmt.Sprintf("📊 *Status React:* %s", status))
 		return
 	}
 
 	arg := strings.ToLower(parts[1])
-	if arg == "on" || arg == "enable" {
-		data.StatusReact = true
-	} else if arg == "off" || arg == "disable" {
-		data.StatusReact = false
-	} else {
+	if arg != "on" && arg != "enable" && arg != "off" && arg != "disable" {
 		replyMessage(client, v, "⚠️ Usage: .statusreact on | off")
 		return
 	}
 
-	// ✅ Redis Save
-	saveGlobalSettings()
+	// ✅ Redis Save (صرف اس بوٹ کے لیے)
+	enabled := arg == "on" || arg == "enable"
+	UpdateBotSettings(botID, func(s *BotData) { s.StatusReact = enabled })
 
 	state := "Disabled"
 	icon := "🔴"
-	if data.StatusReact {
+	if enabled {
 		state = "Enabled"
 		icon = "🟢"
 	}
@@ -307,16 +286,16 @@ func handleAddStatus(client *whatsmeow.Client, v *events.Message, args []string)
 	}
 
 	num := args[0]
-	dataMutex.Lock()
-	data.StatusTargets = append(data.StatusTargets, num)
-	dataMutex.Unlock()
+	updated := UpdateBotSettings(settingsBotID(client), func(s *BotData) {
+		s.StatusTargets = append(s.StatusTargets, num)
+	})
 
 	msg := fmt.Sprintf(`╔════════════════╗
 ║ ✅ TARGET ADDED
 ╠════════════════╣
 ║ 📱 %s
 ║ 📊 Total: %d
-╚════════════════╝`, num, len(data.StatusTargets))
+╚════════════════╝`, num, len(updated.StatusTargets))
 
 	replyMessage(client, v, msg)
 }
@@ -344,18 +323,18 @@ func handleDelStatus(client *whatsmeow.Client, v *events.Message, args []string)
 	}
 
 	num := args[0]
-	dataMutex.Lock()
-	newList := []string{}
 	found := false
-	for _, n := range data.StatusTargets {
-		if n != num {
-			newList = append(newList, n)
-		} else {
-			found = true
+	updated := UpdateBotSettings(settingsBotID(client), func(s *BotData) {
+		newList := []string{}
+		for _, n := range s.StatusTargets {
+			if n != num {
+				newList = append(newList, n)
+			} else {
+				found = true
+			}
 		}
-	}
-	data.StatusTargets = newList
-	dataMutex.Unlock()
+		s.StatusTargets = newList
+	})
 
 	if found {
 		msg := fmt.Sprintf(`╔════════════════╗
@@ -363,7 +342,7 @@ func handleDelStatus(client *whatsmeow.Client, v *events.Message, args []string)
 ╠════════════════╣
 ║ 📱 %s
 ║ 📊 Remaining: %d
-╚════════════════╝`, num, len(data.StatusTargets))
+╚════════════════╝`, num, len(updated.StatusTargets))
 		replyMessage(client, v, msg)
 	} else {
 		msg := `╔════════════════╗
@@ -380,9 +359,7 @@ func handleListStatus(client *whatsmeow.Client, v *events.Message) {
 		return
 	}
 
-	dataMutex.RLock()
-	targets := data.StatusTargets
-	dataMutex.RUnlock()
+	targets := GetBotSettings(settingsBotID(client)).StatusTargets
 
 	if len(targets) == 0 {
 		msg := `╔════════════════╗
@@ -430,9 +407,7 @@ func handleSetPrefix(client *whatsmeow.Client, v *events.Message, args []string)
 	}
 
 	newPrefix := args[0]
-	dataMutex.Lock()
-	data.Prefix = newPrefix
-	dataMutex.Unlock()
+	updatePrefixDB(settingsBotID(client), newPrefix)
 
 	msg := fmt.Sprintf(`╔════════════════╗
 ║ ✅ PREFIX UPDATED
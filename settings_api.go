@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// =========================================================
+// ⚙️ SETTINGS REST API (manage-settings)
+//   GET/PATCH /api/bots/{id}/settings
+//   GET       /api/bots/{id}/groups
+//   GET/PATCH /api/bots/{id}/groups/{chat}/settings
+// چیٹ کمانڈز والے ہی فنکشن (updatePrefixDB, UpdateBotSettings, setAntiDM,
+// setAntiDelete, saveGroupSettings) — دونوں راستے ایک ہی جگہ لکھتے ہیں۔
+// PATCH میں صرف بھیجی گئی فیلڈز بدلتی ہیں۔
+// =========================================================
+
+const settingsChangedChannel = "settings:changed" // 🧩 دوسرے انسٹینسز اپنا RAM کیش چھوڑ دیں
+
+var (
+	validGroupModes      = map[string]bool{"public": true, "private": true, "admin": true}
+	validAntilinkActions = map[string]bool{"delete": true, "deletekick": true, "deletewarn": true}
+)
+
+// AntiDeleteView: SettingsStore "antidelete:<bot>"
+type AntiDeleteView struct {
+	Enabled   bool   `json:"enabled"`
+	DumpGroup string `json:"dump_group,omitempty"`
+}
+
+// BotSettingsView: ایک بوٹ کی تمام سیٹنگز
+type BotSettingsView struct {
+	BotID         string          `json:"bot_id"`
+	Prefix        string          `json:"prefix"`
+	AlwaysOnline  bool            `json:"always_online"`
+	AutoRead      bool            `json:"auto_read"`
+	AutoReact     bool            `json:"auto_react"`
+	AutoStatus    bool            `json:"auto_status"`
+	StatusReact   bool            `json:"status_react"`
+	StatusTargets []string        `json:"status_targets"`
+	AntiDM        bool            `json:"antidm"`
+	AntiDelete    *AntiDeleteView `json:"antidelete"` // null = اسٹور دستیاب نہیں
+}
+
+// BotSettingsPatch: nil = نہ بدلیں
+type BotSettingsPatch struct {
+	Prefix          *string   `json:"prefix"`
+	AlwaysOnline    *bool     `json:"always_online"`
+	AutoRead        *bool     `json:"auto_read"`
+	AutoReact       *bool     `json:"auto_react"`
+	AutoStatus      *bool     `json:"auto_status"`
+	StatusReact     *bool     `json:"status_react"`
+	StatusTargets   *[]string `json:"status_targets"`
+	AntiDM          *bool     `json:"antidm"`
+	AntiDelete      *bool     `json:"antidelete"`
+	AntiDeleteGroup *string   `json:"antidelete_group"`
+}
+
+type GroupSettingsPatch struct {
+	Mode           *string `json:"mode"`
+	Antilink       *bool   `json:"antilink"`
+	AntilinkAdmin  *bool   `json:"antilink_admin"`
+	AntilinkAction *string `json:"antilink_action"`
+	AntiPic        *bool   `json:"antipic"`
+	AntiVideo      *bool   `json:"antivideo"`
+	AntiSticker    *bool   `json:"antisticker"`
+	Welcome        *bool   `json:"welcome"`
+}
+
+// apiBotID: پاتھ سے بوٹ + کلید کی رسائی + سیشن موجود ہو
+func apiBotID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	botID := getCleanID(r.PathValue("id"))
+	if botID == "" || botID == "unknown" {
+		writeJSONError(w, http.StatusBadRequest, errors.New("bot id required"))
+		return "", false
+	}
+	if !requireBotAccess(w, r, botID) {
+		return "", false
+	}
+	clientsMutex.RLock()
+	_, local := activeClients[botID]
+	clientsMutex.RUnlock()
+	if !local {
+		if _, err := findBotDevice(r.Context(), botID); err != nil {
+			writeJSONError(w, http.StatusNotFound, err)
+			return "", false
+		}
+	}
+	return botID, true
+}
+
+// apiGroupJID: "1203630xxx" یا "1203630xxx@g.us"
+func apiGroupJID(raw string) (string, error) {
+	if raw == "" {
+		return "", errors.New("group id required")
+	}
+	if !strings.Contains(raw, "@") {
+		raw += "@" + types.GroupServer
+	}
+	jid, err := types.ParseJID(raw)
+	if err != nil || jid.Server != types.GroupServer || jid.User == "" {
+		return "", errors.New("not a group id: " + raw)
+	}
+	return jid.String(), nil
+}
+
+func botSettingsView(botID string) BotSettingsView {
+	s := GetBotSettings(botID)
+	view := BotSettingsView{
+		BotID:         botID,
+		Prefix:        getPrefix(botID),
+		AlwaysOnline:  s.AlwaysOnline,
+		AutoRead:      s.AutoRead,
+		AutoReact:     s.AutoReact,
+		AutoStatus:    s.AutoStatus,
+		StatusReact:   s.StatusReact,
+		StatusTargets: s.StatusTargets,
+		AntiDM:        isAntiDMOn(botID),
+	}
+	if view.StatusTargets == nil {
+		view.StatusTargets = []string{}
+	}
+	if fs, err := getAntiDeleteSettings(botID); err == nil {
+		view.AntiDelete = &AntiDeleteView{Enabled: fs.IsAntiDelete, DumpGroup: fs.DumpGroupID}
+	}
+	return view
+}
+
+// 🌐 GET / PATCH /api/bots/{id}/settings
+func handleBotSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	botID, ok := apiBotID(w, r)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodPatch {
+		var p BotSettingsPatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&p); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := applyBotSettingsPatch(botID, p); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		logger.Info("⚙️ bot settings updated via API", "bot", redactID(botID), "key", requestAPIKey(r).ID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(botSettingsView(botID))
+}
+
+func applyBotSettingsPatch(botID string, p BotSettingsPatch) error {
+	// پہلے سب چیک — آدھی تبدیلی نہ ہو
+	if p.Prefix != nil && (strings.TrimSpace(*p.Prefix) == "" || len(*p.Prefix) > 5) {
+		return errors.New("prefix must be 1-5 characters")
+	}
+	dumpGroup := ""
+	if p.AntiDeleteGroup != nil {
+		g, err := apiGroupJID(*p.AntiDeleteGroup)
+		if err != nil {
+			return err
+		}
+		dumpGroup = g
+	}
+	if (p.AntiDelete != nil || dumpGroup != "") && settingsStore == nil {
+		return errors.New("anti-delete storage is not configured")
+	}
+
+	// اینٹی ڈیلیٹ واحد لکھائی ہے جو فیل ہو سکتی ہے — سب سے پہلے، تاکہ ناکامی پر
+	// باقی کچھ بھی نہ بدلا ہو
+	if p.AntiDelete != nil || dumpGroup != "" {
+		if err := setAntiDelete(botID, p.AntiDelete, dumpGroup); err != nil {
+			return err
+		}
+	}
+
+	if p.Prefix != nil {
+		updatePrefixDB(botID, strings.TrimSpace(*p.Prefix))
+	}
+	if p.AlwaysOnline != nil || p.AutoRead != nil || p.AutoReact != nil || p.AutoStatus != nil || p.StatusReact != nil || p.StatusTargets != nil {
+		UpdateBotSettings(botID, func(s *BotData) {
+			setIf(&s.AlwaysOnline, p.AlwaysOnline)
+			setIf(&s.AutoRead, p.AutoRead)
+			setIf(&s.AutoReact, p.AutoReact)
+			setIf(&s.AutoStatus, p.AutoStatus)
+			setIf(&s.StatusReact, p.StatusReact)
+			if p.StatusTargets != nil {
+				targets := []string{}
+				for _, t := range *p.StatusTargets {
+					if t = getCleanID(strings.TrimSpace(t)); t != "" && t != "unknown" {
+						targets = append(targets, t)
+					}
+				}
+				s.StatusTargets = targets
+			}
+		})
+	}
+	if p.AlwaysOnline != nil {
+		// ⚡ toggleAlwaysOnline کی طرح فوری اثر (اگر بوٹ یہیں چل رہا ہو)
+		clientsMutex.RLock()
+		c := activeClients[botID]
+		clientsMutex.RUnlock()
+		if c != nil && c.IsConnected() {
+			presence := types.PresenceUnavailable
+			if *p.AlwaysOnline {
+				presence = types.PresenceAvailable
+			}
+			c.SendPresence(context.Background(), presence)
+		}
+	}
+	if p.AntiDM != nil {
+		setAntiDM(botID, *p.AntiDM)
+	}
+	return nil
+}
+
+func setIf[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// 🌐 GET /api/bots/{id}/groups — جن گروپس کی سیٹنگز محفوظ ہیں
+func handleBotGroupsAPI(w http.ResponseWriter, r *http.Request) {
+	botID, ok := apiBotID(w, r)
+	if !ok {
+		return
+	}
+	groups := []*GroupSettings{}
+	if settingsStore != nil {
+		prefix := "group_settings:" + botID + ":"
+		keys, err := settingsStore.Keys(r.Context(), prefix)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		chats := make([]string, 0, len(keys))
+		for _, k := range keys {
+			chats = append(chats, strings.TrimPrefix(k, prefix))
+		}
+		sort.Strings(chats)
+		for _, chatID := range chats {
+			s := *getGroupSettings(botID, chatID)
+			groups = append(groups, &s)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// 🌐 GET / PATCH /api/bots/{id}/groups/{chat}/settings
+func handleGroupSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	botID, ok := apiBotID(w, r)
+	if !ok {
+		return
+	}
+	chatID, err := apiGroupJID(r.PathValue("chat"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// کاپی پر کام — چلتے ہینڈلرز والا pointer نہ بدلے
+	s := *getGroupSettings(botID, chatID)
+	if r.Method == http.MethodPatch {
+		var p GroupSettingsPatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&p); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		if p.Mode != nil && !validGroupModes[strings.ToLower(*p.Mode)] {
+			writeJSONError(w, http.StatusBadRequest, errors.New("mode must be public, private or admin"))
+			return
+		}
+		if p.AntilinkAction != nil && !validAntilinkActions[strings.ToLower(*p.AntilinkAction)] {
+			writeJSONError(w, http.StatusBadRequest, errors.New("antilink_action must be delete, deletekick or deletewarn"))
+			return
+		}
+		if p.Mode != nil {
+			s.Mode = strings.ToLower(*p.Mode)
+		}
+		if p.AntilinkAction != nil {
+			s.AntilinkAction = strings.ToLower(*p.AntilinkAction)
+		}
+		setIf(&s.Antilink, p.Antilink)
+		setIf(&s.AntilinkAdmin, p.AntilinkAdmin)
+		setIf(&s.AntiPic, p.AntiPic)
+		setIf(&s.AntiVideo, p.AntiVideo)
+		setIf(&s.AntiSticker, p.AntiSticker)
+		setIf(&s.Welcome, p.Welcome)
+		s.ChatID = chatID
+		saveGroupSettings(botID, &s)
+		logger.Info("⚙️ group settings updated via API", "bot", redactID(botID), "chat", redactID(chatID), "key", requestAPIKey(r).ID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// =========================================================
+// 🧩 CLUSTER SYNC
+// API کسی بھی انسٹینس پر لگ سکتی ہے جبکہ بوٹ کسی اور پر چل رہا ہو۔
+// Redis میں لکھنے کے بعد اعلان؛ باقی انسٹینس RAM کیش چھوڑ کر اگلی بار Redis سے پڑھتے ہیں۔
+// اعلان مشترکہ setters (UpdateBotSettings، updatePrefixDB، saveGroupSettings، setAntiDM)
+// سے ہوتا ہے — API ہو یا واٹس ایپ کمانڈ، دونوں راستے ایک جیسے۔
+// =========================================================
+
+type settingsChange struct {
+	Bot  string `json:"bot"`
+	Chat string `json:"chat,omitempty"` // خالی = بوٹ لیول
+	From string `json:"from"`
+}
+
+func publishSettingsChange(botID, chatID string) {
+	if !clusterEnabled() {
+		return
+	}
+	raw, _ := json.Marshal(settingsChange{Bot: botID, Chat: chatID, From: InstanceID()})
+	if err := rdb.Publish(ctx, settingsChangedChannel, raw).Err(); err != nil {
+		logger.Warn("🧩 settings publish failed", "bot", redactID(botID), "err", err)
+	}
+}
+
+// dropCachedSettings: اگلی بار Redis سے تازہ
+func dropCachedSettings(botID, chatID string) {
+	if chatID != "" {
+		cacheMutex.Lock()
+		delete(groupCache, botID+":"+chatID)
+		cacheMutex.Unlock()
+		return
+	}
+	prefixMutex.Lock()
+	delete(botPrefixes, botID)
+	prefixMutex.Unlock()
+	antiDMMutex.Lock()
+	delete(antiDMState, botID)
+	antiDMMutex.Unlock()
+	forgetBotSettings(botID)
+}
+
+// dropBotCaches: بوٹ اس انسٹینس سے چلا گیا (lease / rebalance) — واپس آئے تو سب تازہ پڑھے
+func dropBotCaches(botID string) {
+	dropCachedSettings(botID, "")
+	cacheMutex.Lock()
+	for key := range groupCache {
+		if strings.HasPrefix(key, botID+":") {
+			delete(groupCache, key)
+		}
+	}
+	cacheMutex.Unlock()
+}
+
+// startSettingsSync: StartCluster سے
+func startSettingsSync() {
+	sub := rdb.Subscribe(context.Background(), settingsChangedChannel)
+	go func() {
+		for msg := range sub.Channel() {
+			var ch settingsChange
+			if json.Unmarshal([]byte(msg.Payload), &ch) != nil || ch.From == InstanceID() {
+				continue
+			}
+			dropCachedSettings(ch.Bot, ch.Chat)
+		}
+	}()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// failingSettingsStore: "antidelete:" کیز لکھنے پر ناکام، باقی میموری میں
+type failingSettingsStore struct{ *memorySettingsStore }
+
+func (s failingSettingsStore) Set(c context.Context, key, value string) error {
+	if strings.HasPrefix(key, "antidelete:") {
+		return errors.New("store offline")
+	}
+	return s.memorySettingsStore.Set(c, key, value)
+}
+
+func withTestSettingsStore(t *testing.T, s SettingsStore) {
+	t.Helper()
+	prev := settingsStore
+	settingsStore = s
+	t.Cleanup(func() { settingsStore = prev })
+}
+
+func TestApplyBotSettingsPatchAntiDeleteFirst(t *testing.T) {
+	bot := "923009990001"
+	t.Cleanup(func() {
+		prefixMutex.Lock()
+		delete(botPrefixes, bot)
+		prefixMutex.Unlock()
+	})
+	prefix, on := "!", true
+
+	withTestSettingsStore(t, failingSettingsStore{newMemorySettingsStore()})
+	err := applyBotSettingsPatch(bot, BotSettingsPatch{Prefix: &prefix, AntiDelete: &on})
+	if err == nil {
+		t.Fatal("patch succeeded with an unwritable anti-delete store")
+	}
+	if got := getPrefix(bot); got == prefix {
+		t.Error("prefix applied although the patch failed")
+	}
+
+	withTestSettingsStore(t, newMemorySettingsStore())
+	if err := applyBotSettingsPatch(bot, BotSettingsPatch{Prefix: &prefix, AntiDelete: &on}); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if got := getPrefix(bot); got != prefix {
+		t.Errorf("prefix = %q, want %q", got, prefix)
+	}
+	if fs, err := getAntiDeleteSettings(bot); err != nil || !fs.IsAntiDelete {
+		t.Errorf("anti-delete = %+v, %v; want enabled", fs, err)
+	}
+}
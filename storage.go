@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// =========================================================
+// 🗄️ STORAGE INTERFACES
+// چیٹ ہسٹری، میڈیا، سیٹنگز اور سیشن حالت اب انٹرفیسز کے پیچھے ہیں تاکہ
+// چھوٹی ڈیپلائمنٹ صرف Postgres پر چل سکے اور ٹیسٹ میموری پر۔
+//   auto     = ہسٹری/میڈیا Mongo (کنیکٹ ہو تو) ورنہ Postgres؛ سیٹنگز/حالت Redis
+//   mongo    = ہسٹری/میڈیا Mongo؛ سیٹنگز/حالت Redis
+//   postgres = سب کچھ Postgres (whatsmeow والا DATABASE_URL)
+//   memory   = سب کچھ RAM میں — ری سٹارٹ پر ختم (ٹیسٹ / ڈیمو)
+// Redis پھر بھی لازمی ہے: API کیز، کلسٹر لیز، ریٹ لمٹ، send queue اور AI سیشن وہیں ہیں۔
+// =========================================================
+
+const (
+	StorageAuto     = "auto"
+	StorageMongo    = "mongo"
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// ErrNotFound: ہر اسٹور "ریکارڈ نہیں" پر یہی لوٹاتا ہے
+var ErrNotFound = errors.New("not found")
+
+// چیٹ کی قسمیں (retention پالیسی انہی پر)
+const (
+	ChatKindDM     = "dm"
+	ChatKindGroup  = "group"
+	ChatKindStatus = "status"
+)
+
+var chatKinds = []string{ChatKindDM, ChatKindGroup, ChatKindStatus}
+
+func chatKind(chatID string) string {
+	switch {
+	case chatID == "status@broadcast":
+		return ChatKindStatus
+	case strings.HasSuffix(chatID, "@g.us"):
+		return ChatKindGroup
+	}
+	return ChatKindDM
+}
+
+// PurgeFilter: DeleteMessages / DeleteMedia — BotID ہمیشہ لازمی، باقی خالی = کوئی شرط نہیں
+type PurgeFilter struct {
+	BotID   string
+	ChatIDs []string
+	Kind    string    // ChatKindDM / ChatKindGroup / ChatKindStatus
+	Types   []string  // صرف میسجز: "text", "image" ... (میڈیا پر نظرانداز)
+	Before  time.Time // میسج: timestamp، میڈیا: created_at
+}
+
+func (f PurgeFilter) match(botID, chatID, typ string, ts time.Time) bool {
+	if botID != f.BotID || (f.Kind != "" && chatKind(chatID) != f.Kind) {
+		return false
+	}
+	if !f.Before.IsZero() && !ts.Before(f.Before) {
+		return false
+	}
+	return (len(f.ChatIDs) == 0 || containsString(f.ChatIDs, chatID)) &&
+		(len(f.Types) == 0 || containsString(f.Types, typ))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatSummary: ایک چیٹ کا آخری میسج (چیٹ لسٹ کے لیے)
+type ChatSummary struct {
+	ChatID string
+	Name   string
+	LastTS time.Time
+}
+
+// MessageStore: چیٹ ہسٹری
+type MessageStore interface {
+	// SaveMessage: وہی (bot_id, message_id) دوبارہ آئے تو nil — پہلا ریکارڈ رہتا ہے
+	SaveMessage(c context.Context, m *ChatMessage) error
+	// GetMessage: botID خالی = کسی بھی بوٹ کا
+	GetMessage(c context.Context, botID, messageID string) (*ChatMessage, error)
+	// GetLegacyMessage: صرف بغیر bot_id والے (پرانے مائیگریٹ شدہ) ریکارڈ
+	GetLegacyMessage(c context.Context, messageID string) (*ChatMessage, error)
+	// ListMessages: ان chat IDs کے میسجز، نئے پہلے
+	ListMessages(c context.Context, botID string, chatIDs []string, limit int) ([]ChatMessage, error)
+	// ListChats: ہر chat_id کا آخری میسج، نئے پہلے
+	ListChats(c context.Context, botID string, limit int) ([]ChatSummary, error)
+	BotIDs(c context.Context) ([]string, error)
+	// DeleteMessages: کتنے ہٹے
+	DeleteMessages(c context.Context, f PurgeFilter) (int64, error)
+}
+
+// MediaStore: میسجز کا میڈیا (data-url یا catbox لنک)
+type MediaStore interface {
+	SaveMedia(c context.Context, m *MediaDoc) error // (bot_id, message_id) پر upsert
+	// GetMedia: botID خالی = کسی بھی بوٹ کا
+	GetMedia(c context.Context, botID, messageID string) (*MediaDoc, error)
+	DeleteMedia(c context.Context, f PurgeFilter) (int64, error)
+}
+
+// SettingsStore: string سیٹنگز (prefix:, antidm:, bot_settings:, group_settings: ...)
+type SettingsStore interface {
+	Get(c context.Context, key string) (string, error)
+	Set(c context.Context, key, value string) error
+	Delete(c context.Context, keys ...string) error
+	Keys(c context.Context, prefix string) ([]string, error)
+}
+
+// SessionStateStore: عارضی حالت (conversations, job records, LIDs) — ttl 0 = ہمیشہ
+type SessionStateStore interface {
+	Put(c context.Context, key string, value []byte, ttl time.Duration) error
+	Get(c context.Context, key string) ([]byte, error)
+	Delete(c context.Context, keys ...string) error
+	Scan(c context.Context, prefix string) (map[string][]byte, error)
+}
+
+// settingsKeyPrefixes: SettingsStore میں رہنے والی کیز (بنڈل / مائیگریشن کے لیے)
+var settingsKeyPrefixes = []string{"prefix:", "antidm:", "antidelete:", "settings:", botSettingsKeyPrefix, "group_settings:", legacyGlobalSettingsKey}
+
+var (
+	messageStore  MessageStore
+	mediaStore    MediaStore
+	settingsStore SettingsStore
+	stateStore    SessionStateStore
+
+	// storageBackends: کون سا اسٹور کہاں ہے (/readyz)
+	storageBackends = map[string]string{}
+)
+
+// initStores: Redis، Postgres اور (اختیاری) Mongo کے بعد بلائیں
+func initStores() {
+	initHistoryStores()
+	initStateStores()
+	logger.Info("🗄️ storage ready", "mode", Config().StorageBackend(), "history", storageBackends["messages"], "settings", storageBackends["settings"])
+}
+
+// initHistoryStores: MessageStore + MediaStore
+func initHistoryStores() {
+	mode := Config().StorageBackend()
+	switch {
+	case mode == StorageMemory:
+		messageStore, mediaStore = newMemoryMessageStore(), newMemoryMediaStore()
+		storageBackends["messages"] = StorageMemory
+	case mode == StorageMongo || (mode == StorageAuto && mongoClient != nil):
+		if mongoClient == nil {
+			log.Fatalf("❌ [STORAGE] storage=mongo but MongoDB is not connected")
+		}
+		messageStore, mediaStore = newMongoHistoryStores(mongoClient.Database("whatsapp_bot"))
+		storageBackends["messages"] = StorageMongo
+	default:
+		msgs, media, err := newPostgresHistoryStores(sessionDB)
+		if err != nil {
+			log.Fatalf("❌ [STORAGE] Postgres history tables: %v", err)
+		}
+		messageStore, mediaStore = msgs, media
+		storageBackends["messages"] = StoragePostgres
+	}
+	storageBackends["media"] = storageBackends["messages"]
+}
+
+// initStateStores: SettingsStore + SessionStateStore (session CLI کو صرف یہی چاہیے)
+func initStateStores() {
+	mode := Config().StorageBackend()
+	switch mode {
+	case StorageMemory:
+		settingsStore, stateStore = newMemorySettingsStore(), newMemoryStateStore()
+		storageBackends["settings"] = StorageMemory
+	case StoragePostgres:
+		settings, state, err := newPostgresStateStores(sessionDB)
+		if err != nil {
+			log.Fatalf("❌ [STORAGE] Postgres settings tables: %v", err)
+		}
+		settingsStore, stateStore = settings, state
+		seedSettingsFromRedis()
+		storageBackends["settings"] = StoragePostgres
+	default:
+		settingsStore, stateStore = redisSettingsStore{}, redisStateStore{}
+		storageBackends["settings"] = "redis"
+	}
+	storageBackends["state"] = storageBackends["settings"]
+}
+
+// seedSettingsFromRedis: پہلی بار storage=postgres پر پرانی Redis سیٹنگز ساتھ لائیں
+func seedSettingsFromRedis() {
+	if rdb == nil {
+		return
+	}
+	c, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	existing, err := settingsStore.Keys(c, "")
+	if err != nil || len(existing) > 0 {
+		return
+	}
+	copied := 0
+	for _, prefix := range settingsKeyPrefixes {
+		iter := rdb.Scan(c, 0, prefix+"*", 200).Iterator()
+		for iter.Next(c) {
+			val, err := rdb.Get(c, iter.Val()).Result()
+			if err != nil {
+				continue
+			}
+			if settingsStore.Set(c, iter.Val(), val) == nil {
+				copied++
+			}
+		}
+	}
+	if copied > 0 {
+		logger.Info("📦 copied settings from Redis to Postgres", "keys", copied)
+	}
+}
+
+// getSetting: چھوٹا ہیلپر — نہ ملے یا اسٹور نہ ہو تو def
+func getSetting(key, def string) string {
+	if settingsStore == nil {
+		return def
+	}
+	val, err := settingsStore.Get(context.Background(), key)
+	if err != nil || val == "" {
+		return def
+	}
+	return val
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =========================================================
+// 🧠 IN-MEMORY STORES (storage=memory)
+// ٹیسٹ اور ڈیمو کے لیے — ری سٹارٹ پر سب کچھ ختم۔
+// =========================================================
+
+func memKey(botID, messageID string) string { return botID + "|" + messageID }
+
+type memoryMessageStore struct {
+	mu   sync.RWMutex
+	msgs map[string]ChatMessage // bot|message_id → میسج
+}
+
+func newMemoryMessageStore() *memoryMessageStore {
+	return &memoryMessageStore{msgs: make(map[string]ChatMessage)}
+}
+
+func (s *memoryMessageStore) SaveMessage(_ context.Context, m *ChatMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := memKey(m.BotID, m.MessageID)
+	if _, ok := s.msgs[k]; !ok {
+		s.msgs[k] = *m
+	}
+	return nil
+}
+
+func (s *memoryMessageStore) GetMessage(_ context.Context, botID, messageID string) (*ChatMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if botID != "" {
+		if m, ok := s.msgs[memKey(botID, messageID)]; ok {
+			return &m, nil
+		}
+		return nil, ErrNotFound
+	}
+	for _, m := range s.msgs {
+		if m.MessageID == messageID {
+			return &m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryMessageStore) GetLegacyMessage(_ context.Context, messageID string) (*ChatMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if m, ok := s.msgs[memKey("", messageID)]; ok {
+		return &m, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryMessageStore) ListMessages(_ context.Context, botID string, chatIDs []string, limit int) ([]ChatMessage, error) {
+	want := make(map[string]bool, len(chatIDs))
+	for _, id := range chatIDs {
+		want[id] = true
+	}
+	var out []ChatMessage
+	s.mu.RLock()
+	for _, m := range s.msgs {
+		if m.BotID == botID && want[m.ChatID] {
+			out = append(out, m)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryMessageStore) ListChats(_ context.Context, botID string, limit int) ([]ChatSummary, error) {
+	last := make(map[string]ChatSummary)
+	s.mu.RLock()
+	for _, m := range s.msgs {
+		if m.BotID != botID || m.ChatID == "" {
+			continue
+		}
+		if ex, ok := last[m.ChatID]; !ok || m.Timestamp.After(ex.LastTS) {
+			last[m.ChatID] = ChatSummary{ChatID: m.ChatID, Name: m.SenderName, LastTS: m.Timestamp}
+		}
+	}
+	s.mu.RUnlock()
+	out := make([]ChatSummary, 0, len(last))
+	for _, cs := range last {
+		out = append(out, cs)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastTS.After(out[j].LastTS) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryMessageStore) BotIDs(_ context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	s.mu.RLock()
+	for _, m := range s.msgs {
+		seen[m.BotID] = true
+	}
+	s.mu.RUnlock()
+	return sortedKeys(seen), nil
+}
+
+func (s *memoryMessageStore) DeleteMessages(_ context.Context, f PurgeFilter) (int64, error) {
+	var n int64
+	s.mu.Lock()
+	for k, m := range s.msgs {
+		if f.match(m.BotID, m.ChatID, m.Type, m.Timestamp) {
+			delete(s.msgs, k)
+			n++
+		}
+	}
+	s.mu.Unlock()
+	return n, nil
+}
+
+type memoryMediaStore struct {
+	mu    sync.RWMutex
+	media map[string]MediaDoc
+}
+
+func newMemoryMediaStore() *memoryMediaStore {
+	return &memoryMediaStore{media: make(map[string]MediaDoc)}
+}
+
+func (s *memoryMediaStore) SaveMedia(_ context.Context, m *MediaDoc) error {
+	s.mu.Lock()
+	s.media[memKey(m.BotID, m.MessageID)] = *m
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryMediaStore) GetMedia(_ context.Context, botID, messageID string) (*MediaDoc, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, m := range s.media {
+		if m.MessageID == messageID && (botID == "" || m.BotID == botID) {
+			return &m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryMediaStore) DeleteMedia(_ context.Context, f PurgeFilter) (int64, error) {
+	f.Types = nil // میڈیا پر Types نظرانداز (pgPurgeWhere / mongoPurgeFilter کی طرح)
+	var n int64
+	s.mu.Lock()
+	for k, m := range s.media {
+		if f.match(m.BotID, m.ChatID, "", m.CreatedAt) {
+			delete(s.media, k)
+			n++
+		}
+	}
+	s.mu.Unlock()
+	return n, nil
+}
+
+type memorySettingsStore struct {
+	mu   sync.RWMutex
+	vals map[string]string
+}
+
+func newMemorySettingsStore() *memorySettingsStore {
+	return &memorySettingsStore{vals: make(map[string]string)}
+}
+
+func (s *memorySettingsStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.vals[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *memorySettingsStore) Set(_ context.Context, key, value string) error {
+	s.mu.Lock()
+	s.vals[key] = value
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memorySettingsStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	for _, k := range keys {
+		delete(s.vals, k)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memorySettingsStore) Keys(_ context.Context, prefix string) ([]string, error) {
+	var out []string
+	s.mu.RLock()
+	for k := range s.vals {
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, k)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Strings(out)
+	return out, nil
+}
+
+type memoryStateEntry struct {
+	value     []byte
+	expiresAt time.Time // zero = ہمیشہ
+}
+
+func (e memoryStateEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type memoryStateStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryStateEntry
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{entries: make(map[string]memoryStateEntry)}
+}
+
+func (s *memoryStateStore) Put(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	e := memoryStateEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	s.mu.Lock()
+	s.entries[key] = e
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStateStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || e.expired(time.Now()) {
+		delete(s.entries, key)
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), e.value...), nil
+}
+
+func (s *memoryStateStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	for _, k := range keys {
+		delete(s.entries, k)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStateStore) Scan(_ context.Context, prefix string) (map[string][]byte, error) {
+	now := time.Now()
+	out := make(map[string][]byte)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, k)
+			continue
+		}
+		if strings.HasPrefix(k, prefix) {
+			out[k] = append([]byte(nil), e.value...)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// =========================================================
+// 🍃 MONGO STORES (whatsapp_bot.messages / whatsapp_bot.media)
+// =========================================================
+
+type mongoMessageStore struct{ col *mongo.Collection }
+
+type mongoMediaStore struct{ col *mongo.Collection }
+
+// newMongoHistoryStores: انڈیکس بیک گراؤنڈ میں بنتے ہیں تاکہ بوٹ سٹارٹ ہونے میں دیر نہ لگے
+func newMongoHistoryStores(db *mongo.Database) (*mongoMessageStore, *mongoMediaStore) {
+	msgs := &mongoMessageStore{col: db.Collection("messages")}
+	media := &mongoMediaStore{col: db.Collection("media")}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		_, err := msgs.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			// Fast paging: bot_id + chat_id + timestamp desc + message_id desc
+			{Keys: bson.D{{Key: "bot_id", Value: 1}, {Key: "chat_id", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "message_id", Value: -1}}},
+			// ✅ unique per bot (so multi-bot collision na ho)
+			{Keys: bson.D{{Key: "bot_id", Value: 1}, {Key: "message_id", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+			// ListChats کے لیے
+			{Keys: bson.D{{Key: "bot_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		})
+		if err != nil {
+			logger.Warn("⚠️ mongo messages index failed", "err", err)
+		}
+
+		_, err = media.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "bot_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "bot_id", Value: 1}, {Key: "message_id", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		})
+		if err != nil {
+			logger.Warn("⚠️ mongo media index failed", "err", err)
+		}
+		logger.Info("✅ mongo indexes ensured")
+	}()
+	return msgs, media
+}
+
+func mongoIDFilter(botID, messageID string) bson.M {
+	filter := bson.M{"message_id": messageID}
+	if botID != "" {
+		filter["bot_id"] = botID
+	}
+	return filter
+}
+
+// mongoPurgeFilter: PurgeFilter → bson (tsField: timestamp یا created_at)
+func mongoPurgeFilter(f PurgeFilter, tsField string, withTypes bool) bson.M {
+	and := bson.A{bson.M{"bot_id": f.BotID}}
+	if len(f.ChatIDs) > 0 {
+		and = append(and, bson.M{"chat_id": bson.M{"$in": f.ChatIDs}})
+	}
+	groupRe := primitive.Regex{Pattern: `@g\.us$`}
+	switch f.Kind {
+	case ChatKindStatus:
+		and = append(and, bson.M{"chat_id": "status@broadcast"})
+	case ChatKindGroup:
+		and = append(and, bson.M{"chat_id": groupRe})
+	case ChatKindDM:
+		and = append(and, bson.M{"chat_id": bson.M{"$not": groupRe, "$ne": "status@broadcast"}})
+	}
+	if withTypes && len(f.Types) > 0 {
+		and = append(and, bson.M{"type": bson.M{"$in": f.Types}})
+	}
+	if !f.Before.IsZero() {
+		and = append(and, bson.M{tsField: bson.M{"$lt": f.Before}})
+	}
+	return bson.M{"$and": and}
+}
+
+func (s *mongoMessageStore) SaveMessage(c context.Context, m *ChatMessage) error {
+	_, err := s.col.InsertOne(c, m)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *mongoMessageStore) GetMessage(c context.Context, botID, messageID string) (*ChatMessage, error) {
+	var m ChatMessage
+	err := s.col.FindOne(c, mongoIDFilter(botID, messageID)).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *mongoMessageStore) GetLegacyMessage(c context.Context, messageID string) (*ChatMessage, error) {
+	var m ChatMessage
+	// پرانے ڈاکیومنٹس میں bot_id فیلڈ ہی نہیں ہوتا
+	err := s.col.FindOne(c, bson.M{"message_id": messageID, "bot_id": bson.M{"$in": bson.A{"", nil}}}).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *mongoMessageStore) ListMessages(c context.Context, botID string, chatIDs []string, limit int) ([]ChatMessage, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := s.col.Find(c, bson.M{"bot_id": botID, "chat_id": bson.M{"$in": chatIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var out []ChatMessage
+	if err := cur.All(c, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *mongoMessageStore) ListChats(c context.Context, botID string, limit int) ([]ChatSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"bot_id": botID, "chat_id": bson.M{"$ne": ""}}}},
+		// انڈیکس استعمال کرنے کے لیے ٹائم سورٹ
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$chat_id",
+			"last_ts": bson.M{"$last": "$timestamp"},
+			"name":    bson.M{"$last": "$sender_name"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "last_ts", Value: -1}}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	cur, err := s.col.Aggregate(c, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(c)
+
+	var out []ChatSummary
+	for cur.Next(c) {
+		var row struct {
+			ChatID string    `bson:"_id"`
+			LastTS time.Time `bson:"last_ts"`
+			Name   string    `bson:"name"`
+		}
+		if cur.Decode(&row) != nil {
+			continue
+		}
+		out = append(out, ChatSummary{ChatID: row.ChatID, Name: row.Name, LastTS: row.LastTS})
+	}
+	return out, cur.Err()
+}
+
+func (s *mongoMessageStore) BotIDs(c context.Context) ([]string, error) {
+	distinct, err := s.col.Distinct(c, "bot_id", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(distinct))
+	for _, id := range distinct {
+		if str, ok := id.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out, nil
+}
+
+func (s *mongoMessageStore) DeleteMessages(c context.Context, f PurgeFilter) (int64, error) {
+	res, err := s.col.DeleteMany(c, mongoPurgeFilter(f, "timestamp", true))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (s *mongoMediaStore) SaveMedia(c context.Context, m *MediaDoc) error {
+	_, err := s.col.UpdateOne(c,
+		bson.M{"bot_id": m.BotID, "message_id": m.MessageID},
+		bson.M{"$set": m},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *mongoMediaStore) GetMedia(c context.Context, botID, messageID string) (*MediaDoc, error) {
+	var m MediaDoc
+	err := s.col.FindOne(c, mongoIDFilter(botID, messageID)).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *mongoMediaStore) DeleteMedia(c context.Context, f PurgeFilter) (int64, error) {
+	res, err := s.col.DeleteMany(c, mongoPurgeFilter(f, "created_at", false))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
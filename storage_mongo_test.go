@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoStores: TEST_MONGO_URL نہ ہو تو skip؛ ڈیٹا بیس impossible_bot_test
+func testMongoStores(t *testing.T) (*mongoMessageStore, *mongoMediaStore) {
+	t.Helper()
+	url := os.Getenv("TEST_MONGO_URL")
+	if url == "" {
+		t.Skip("TEST_MONGO_URL not set")
+	}
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(c, options.Client().ApplyURI(url))
+	if err != nil {
+		t.Fatalf("connect mongo: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	if err := client.Ping(c, nil); err != nil {
+		t.Fatalf("ping mongo: %v", err)
+	}
+
+	msgs, media := newMongoHistoryStores(client.Database("impossible_bot_test"))
+	// انڈیکس بیک گراؤنڈ میں بنتے ہیں — duplicate والا ٹیسٹ unique انڈیکس پر منحصر ہے
+	for _, col := range []*mongo.Collection{msgs.col, media.col} {
+		waitForUniqueIndex(t, col)
+	}
+	return msgs, media
+}
+
+func waitForUniqueIndex(t *testing.T, col *mongo.Collection) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		cur, err := col.Indexes().List(context.Background())
+		if err == nil {
+			var idx []bson.M
+			if cur.All(context.Background(), &idx) == nil {
+				for _, ix := range idx {
+					if unique, _ := ix["unique"].(bool); unique {
+						return
+					}
+				}
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	t.Fatalf("unique index on %s not ready", col.Name())
+}
+
+func TestMongoMessageStore(t *testing.T) {
+	msgs, _ := testMongoStores(t)
+	testMessageStoreContract(t, func(*testing.T) MessageStore { return msgs })
+}
+
+func TestMongoMediaStore(t *testing.T) {
+	_, media := testMongoStores(t)
+	testMediaStoreContract(t, func(*testing.T) MediaStore { return media })
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// =========================================================
+// 🐘 POSTGRES STORES (storage=postgres، یا auto بغیر Mongo کے)
+// whatsmeow والے DATABASE_URL میں ہی اپنی ٹیبلز — چھوٹی ڈیپلائمنٹ کو
+// Mongo کی ضرورت نہیں۔ میعاد والی حالت کو sweeper صاف کرتا ہے۔
+// =========================================================
+
+const stateSweepEvery = 10 * time.Minute
+
+var historySchema = []string{
+	`CREATE TABLE IF NOT EXISTS bot_chat_messages (
+		bot_id        TEXT NOT NULL,
+		message_id    TEXT NOT NULL,
+		chat_id       TEXT NOT NULL,
+		sender        TEXT NOT NULL DEFAULT '',
+		sender_name   TEXT NOT NULL DEFAULT '',
+		ts            TIMESTAMPTZ NOT NULL,
+		type          TEXT NOT NULL DEFAULT '',
+		content       TEXT NOT NULL DEFAULT '',
+		is_from_me    BOOLEAN NOT NULL DEFAULT false,
+		is_group      BOOLEAN NOT NULL DEFAULT false,
+		is_channel    BOOLEAN NOT NULL DEFAULT false,
+		is_sticker    BOOLEAN NOT NULL DEFAULT false,
+		quoted_msg    TEXT NOT NULL DEFAULT '',
+		quoted_sender TEXT NOT NULL DEFAULT '',
+		has_media     BOOLEAN NOT NULL DEFAULT false,
+		media_ref     TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (bot_id, message_id)
+	)`,
+	`ALTER TABLE bot_chat_messages ADD COLUMN IF NOT EXISTS raw BYTEA`,
+	`CREATE INDEX IF NOT EXISTS bot_chat_messages_chat_idx ON bot_chat_messages (bot_id, chat_id, ts DESC)`,
+	`CREATE INDEX IF NOT EXISTS bot_chat_messages_ts_idx ON bot_chat_messages (bot_id, ts DESC)`,
+	`CREATE TABLE IF NOT EXISTS bot_chat_media (
+		bot_id     TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		chat_id    TEXT NOT NULL DEFAULT '',
+		type       TEXT NOT NULL DEFAULT '',
+		mime       TEXT NOT NULL DEFAULT '',
+		content    TEXT NOT NULL,
+		size       INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (bot_id, message_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS bot_chat_media_msg_idx ON bot_chat_media (message_id)`,
+	`CREATE INDEX IF NOT EXISTS bot_chat_media_created_idx ON bot_chat_media (bot_id, created_at)`, // retention
+}
+
+var stateSchema = []string{
+	`CREATE TABLE IF NOT EXISTS bot_settings_kv (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS bot_session_state (
+		key        TEXT PRIMARY KEY,
+		value      BYTEA NOT NULL,
+		expires_at TIMESTAMPTZ
+	)`,
+}
+
+func execSchema(db *sql.DB, stmts []string) error {
+	if db == nil {
+		return errors.New("postgres not connected")
+	}
+	c, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(c, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// likePrefix: LIKE کے خاص حروف (% _ \) سے بچاؤ
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(prefix) + "%"
+}
+
+type pgMessageStore struct{ db *sql.DB }
+
+type pgMediaStore struct{ db *sql.DB }
+
+func newPostgresHistoryStores(db *sql.DB) (*pgMessageStore, *pgMediaStore, error) {
+	if err := execSchema(db, historySchema); err != nil {
+		return nil, nil, err
+	}
+	return &pgMessageStore{db: db}, &pgMediaStore{db: db}, nil
+}
+
+const pgMessageCols = `bot_id, message_id, chat_id, sender, sender_name, ts, type, content,
+	is_from_me, is_group, is_channel, is_sticker, quoted_msg, quoted_sender, has_media, media_ref, raw`
+
+func scanPGMessage(row interface{ Scan(...any) error }) (ChatMessage, error) {
+	var m ChatMessage
+	err := row.Scan(&m.BotID, &m.MessageID, &m.ChatID, &m.Sender, &m.SenderName, &m.Timestamp, &m.Type, &m.Content,
+		&m.IsFromMe, &m.IsGroup, &m.IsChannel, &m.IsSticker, &m.QuotedMsg, &m.QuotedSender, &m.HasMedia, &m.MediaRef, &m.Raw)
+	return m, err
+}
+
+func (s *pgMessageStore) SaveMessage(c context.Context, m *ChatMessage) error {
+	_, err := s.db.ExecContext(c, `INSERT INTO bot_chat_messages (`+pgMessageCols+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
+		ON CONFLICT (bot_id, message_id) DO NOTHING`,
+		m.BotID, m.MessageID, m.ChatID, m.Sender, m.SenderName, m.Timestamp, m.Type, m.Content,
+		m.IsFromMe, m.IsGroup, m.IsChannel, m.IsSticker, m.QuotedMsg, m.QuotedSender, m.HasMedia, m.MediaRef, m.Raw)
+	return err
+}
+
+func (s *pgMessageStore) GetMessage(c context.Context, botID, messageID string) (*ChatMessage, error) {
+	row := s.db.QueryRowContext(c, `SELECT `+pgMessageCols+` FROM bot_chat_messages
+		WHERE message_id = $1 AND ($2 = '' OR bot_id = $2) LIMIT 1`, messageID, botID)
+	m, err := scanPGMessage(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *pgMessageStore) GetLegacyMessage(c context.Context, messageID string) (*ChatMessage, error) {
+	row := s.db.QueryRowContext(c, `SELECT `+pgMessageCols+` FROM bot_chat_messages
+		WHERE bot_id = '' AND message_id = $1 LIMIT 1`, messageID)
+	m, err := scanPGMessage(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *pgMessageStore) ListMessages(c context.Context, botID string, chatIDs []string, limit int) ([]ChatMessage, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := s.db.QueryContext(c, `SELECT `+pgMessageCols+` FROM bot_chat_messages
+		WHERE bot_id = $1 AND chat_id = ANY($2) ORDER BY ts DESC LIMIT $3`, botID, pq.Array(chatIDs), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ChatMessage
+	for rows.Next() {
+		m, err := scanPGMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgMessageStore) ListChats(c context.Context, botID string, limit int) ([]ChatSummary, error) {
+	if limit <= 0 {
+		limit = 3000
+	}
+	rows, err := s.db.QueryContext(c, `SELECT chat_id, sender_name, ts FROM (
+			SELECT DISTINCT ON (chat_id) chat_id, sender_name, ts FROM bot_chat_messages
+			WHERE bot_id = $1 AND chat_id <> '' ORDER BY chat_id, ts DESC
+		) last ORDER BY ts DESC LIMIT $2`, botID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ChatSummary
+	for rows.Next() {
+		var cs ChatSummary
+		if err := rows.Scan(&cs.ChatID, &cs.Name, &cs.LastTS); err != nil {
+			return nil, err
+		}
+		out = append(out, cs)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgMessageStore) BotIDs(c context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(c, `SELECT DISTINCT bot_id FROM bot_chat_messages`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgMediaStore) SaveMedia(c context.Context, m *MediaDoc) error {
+	_, err := s.db.ExecContext(c, `INSERT INTO bot_chat_media (bot_id, message_id, chat_id, type, mime, content, size, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		ON CONFLICT (bot_id, message_id) DO UPDATE SET chat_id = EXCLUDED.chat_id, type = EXCLUDED.type,
+			mime = EXCLUDED.mime, content = EXCLUDED.content, size = EXCLUDED.size, created_at = EXCLUDED.created_at`,
+		m.BotID, m.MessageID, m.ChatID, m.Type, m.Mime, m.Content, m.Size, m.CreatedAt)
+	return err
+}
+
+func (s *pgMediaStore) GetMedia(c context.Context, botID, messageID string) (*MediaDoc, error) {
+	var m MediaDoc
+	err := s.db.QueryRowContext(c, `SELECT bot_id, message_id, chat_id, type, mime, content, size, created_at
+		FROM bot_chat_media WHERE message_id = $1 AND ($2 = '' OR bot_id = $2) LIMIT 1`, messageID, botID).
+		Scan(&m.BotID, &m.MessageID, &m.ChatID, &m.Type, &m.Mime, &m.Content, &m.Size, &m.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// pgPurgeWhere: PurgeFilter → WHERE (tsCol: ts یا created_at)
+func pgPurgeWhere(f PurgeFilter, tsCol string, withTypes bool) (string, []any) {
+	conds := []string{"bot_id = $1"}
+	args := []any{f.BotID}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if len(f.ChatIDs) > 0 {
+		add("chat_id = ANY($%d)", pq.Array(f.ChatIDs))
+	}
+	switch f.Kind {
+	case ChatKindStatus:
+		conds = append(conds, `chat_id = 'status@broadcast'`)
+	case ChatKindGroup:
+		conds = append(conds, `chat_id LIKE '%@g.us'`)
+	case ChatKindDM:
+		conds = append(conds, `chat_id NOT LIKE '%@g.us' AND chat_id <> 'status@broadcast'`)
+	}
+	if withTypes && len(f.Types) > 0 {
+		add("type = ANY($%d)", pq.Array(f.Types))
+	}
+	if !f.Before.IsZero() {
+		add(tsCol+" < $%d", f.Before)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func (s *pgMessageStore) DeleteMessages(c context.Context, f PurgeFilter) (int64, error) {
+	where, args := pgPurgeWhere(f, "ts", true)
+	res, err := s.db.ExecContext(c, `DELETE FROM bot_chat_messages WHERE `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *pgMediaStore) DeleteMedia(c context.Context, f PurgeFilter) (int64, error) {
+	where, args := pgPurgeWhere(f, "created_at", false)
+	res, err := s.db.ExecContext(c, `DELETE FROM bot_chat_media WHERE `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type pgSettingsStore struct{ db *sql.DB }
+
+type pgStateStore struct{ db *sql.DB }
+
+func newPostgresStateStores(db *sql.DB) (*pgSettingsStore, *pgStateStore, error) {
+	if err := execSchema(db, stateSchema); err != nil {
+		return nil, nil, err
+	}
+	state := &pgStateStore{db: db}
+	go state.sweep()
+	return &pgSettingsStore{db: db}, state, nil
+}
+
+func (s *pgSettingsStore) Get(c context.Context, key string) (string, error) {
+	var val string
+	err := s.db.QueryRowContext(c, `SELECT value FROM bot_settings_kv WHERE key = $1`, key).Scan(&val)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (s *pgSettingsStore) Set(c context.Context, key, value string) error {
+	_, err := s.db.ExecContext(c, `INSERT INTO bot_settings_kv (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value`, key, value)
+	return err
+}
+
+func (s *pgSettingsStore) Delete(c context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.db.ExecContext(c, `DELETE FROM bot_settings_kv WHERE key = ANY($1)`, pq.Array(keys))
+	return err
+}
+
+func (s *pgSettingsStore) Keys(c context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.QueryContext(c, `SELECT key FROM bot_settings_kv WHERE key LIKE $1 ORDER BY key`, likePrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+func (s *pgStateStore) Put(c context.Context, key string, value []byte, ttl time.Duration) error {
+	var expires sql.NullTime
+	if ttl > 0 {
+		expires = sql.NullTime{Time: time.Now().Add(ttl), Valid: true}
+	}
+	_, err := s.db.ExecContext(c, `INSERT INTO bot_session_state (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at`, key, value, expires)
+	return err
+}
+
+func (s *pgStateStore) Get(c context.Context, key string) ([]byte, error) {
+	var val []byte
+	err := s.db.QueryRowContext(c, `SELECT value FROM bot_session_state
+		WHERE key = $1 AND (expires_at IS NULL OR expires_at > now())`, key).Scan(&val)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return val, err
+}
+
+func (s *pgStateStore) Delete(c context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.db.ExecContext(c, `DELETE FROM bot_session_state WHERE key = ANY($1)`, pq.Array(keys))
+	return err
+}
+
+func (s *pgStateStore) Scan(c context.Context, prefix string) (map[string][]byte, error) {
+	rows, err := s.db.QueryContext(c, `SELECT key, value FROM bot_session_state
+		WHERE key LIKE $1 AND (expires_at IS NULL OR expires_at > now())`, likePrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string][]byte)
+	for rows.Next() {
+		var k string
+		var v []byte
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, rows.Err()
+}
+
+// sweep: میعاد ختم شدہ حالت ہٹائیں (Redis یہ خود کرتا ہے)
+func (s *pgStateStore) sweep() {
+	ticker := time.NewTicker(stateSweepEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		c, cancel := context.WithTimeout(context.Background(), time.Minute)
+		res, err := s.db.ExecContext(c, `DELETE FROM bot_session_state WHERE expires_at IS NOT NULL AND expires_at <= now()`)
+		cancel()
+		if err != nil {
+			logger.Warn("⚠️ state sweep failed", "err", err)
+			continue
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			logger.Info("🧹 swept expired state rows", "count", n)
+		}
+	}
+}
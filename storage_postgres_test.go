@@ -0,0 +1,53 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// testPostgresDB: TEST_POSTGRES_URL نہ ہو تو skip (اصلی ٹیبلز بنتی ہیں — الگ ٹیسٹ ڈیٹا بیس دیں)
+func testPostgresDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("TEST_POSTGRES_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping postgres: %v", err)
+	}
+	return db
+}
+
+func TestPostgresMessageStore(t *testing.T) {
+	db := testPostgresDB(t)
+	msgs, _, err := newPostgresHistoryStores(db)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	testMessageStoreContract(t, func(*testing.T) MessageStore { return msgs })
+}
+
+func TestPostgresMediaStore(t *testing.T) {
+	db := testPostgresDB(t)
+	_, media, err := newPostgresHistoryStores(db)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	testMediaStoreContract(t, func(*testing.T) MediaStore { return media })
+}
+
+func TestPostgresStateStores(t *testing.T) {
+	db := testPostgresDB(t)
+	settings, state, err := newPostgresStateStores(db)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	t.Run("settings", func(t *testing.T) { testSettingsStoreContract(t, settings) })
+	t.Run("state", func(t *testing.T) { testStateStoreContract(t, state) })
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// =========================================================
+// 🔴 REDIS STORES (storage=auto / mongo)
+// کیز وہی پرانی ہیں — موجودہ ڈیپلائمنٹس کو کوئی مائیگریشن نہیں چاہیے۔
+// =========================================================
+
+type redisSettingsStore struct{}
+
+func (redisSettingsStore) Get(c context.Context, key string) (string, error) {
+	val, err := rdb.Get(c, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (redisSettingsStore) Set(c context.Context, key, value string) error {
+	return rdb.Set(c, key, value, 0).Err()
+}
+
+func (redisSettingsStore) Delete(c context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return rdb.Del(c, keys...).Err()
+}
+
+func (redisSettingsStore) Keys(c context.Context, prefix string) ([]string, error) {
+	return scanRedisKeys(c, prefix)
+}
+
+type redisStateStore struct{}
+
+func (redisStateStore) Put(c context.Context, key string, value []byte, ttl time.Duration) error {
+	return rdb.Set(c, key, value, ttl).Err()
+}
+
+func (redisStateStore) Get(c context.Context, key string) ([]byte, error) {
+	val, err := rdb.Get(c, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return val, err
+}
+
+func (redisStateStore) Delete(c context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return rdb.Del(c, keys...).Err()
+}
+
+func (redisStateStore) Scan(c context.Context, prefix string) (map[string][]byte, error) {
+	keys, err := scanRedisKeys(c, prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		val, err := rdb.Get(c, k).Bytes()
+		if err != nil {
+			continue // اسی دوران expire ہو گئی
+		}
+		out[k] = val
+	}
+	return out, nil
+}
+
+func scanRedisKeys(c context.Context, prefix string) ([]string, error) {
+	var keys []string
+	iter := rdb.Scan(c, 0, prefix+"*", 200).Iterator()
+	for iter.Next(c) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
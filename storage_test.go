@@ -0,0 +1,638 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// =========================================================
+// 🧪 STORE CONTRACT
+// ہر بیک اینڈ (memory / postgres / mongo) پر ایک ہی ٹیسٹ۔ اصلی ڈیٹا بیس
+// والے ٹیسٹ تب ہی چلتے ہیں جب TEST_POSTGRES_URL / TEST_MONGO_URL سیٹ ہوں۔
+// ہر رن اپنے الگ bot IDs استعمال کرتا ہے اور آخر میں صاف کرتا ہے۔
+// =========================================================
+
+var testEpoch = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// testBotIDs: اس رن کے منفرد بوٹ نمبر (شیئرڈ ڈیٹا بیس پر ٹکراؤ نہ ہو)
+func testBotIDs(n int) []string {
+	base := time.Now().UnixNano() % 1_000_000_000
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("9%09d%d", base, i)
+	}
+	return out
+}
+
+func testMessage(bot, chat, id, typ string, age time.Duration) ChatMessage {
+	return ChatMessage{
+		BotID: bot, ChatID: chat, MessageID: id, Type: typ,
+		Sender: "111@s.whatsapp.net", SenderName: "sender-" + id, Content: "body " + id,
+		Timestamp: testEpoch.Add(-age), IsGroup: strings.HasSuffix(chat, "@g.us"),
+	}
+}
+
+func messageIDs(msgs []ChatMessage) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.MessageID
+	}
+	return out
+}
+
+// remainingIDs: ان بوٹس کے بچے ہوئے میسج IDs (ترتیب شدہ)
+func remainingIDs(t *testing.T, s MessageStore, bots []string, chats []string) []string {
+	t.Helper()
+	var out []string
+	for _, bot := range bots {
+		msgs, err := s.ListMessages(context.Background(), bot, chats, 0)
+		if err != nil {
+			t.Fatalf("ListMessages(%s): %v", bot, err)
+		}
+		out = append(out, messageIDs(msgs)...)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// testMessageStoreContract: MessageStore کی ہر implementation کو یہی کرنا چاہیے
+func testMessageStoreContract(t *testing.T, newStore func(t *testing.T) MessageStore) {
+	c := context.Background()
+	dm, group, status := "222@s.whatsapp.net", "333-444@g.us", "status@broadcast"
+	chats := []string{dm, group, status}
+
+	t.Run("save and get", func(t *testing.T) {
+		s := newStore(t)
+		bots := testBotIDs(2)
+		m := testMessage(bots[0], dm, "MSG-A-"+bots[0], "text", time.Minute)
+		m.Raw = []byte{1, 2, 3}
+		if err := s.SaveMessage(c, &m); err != nil {
+			t.Fatalf("SaveMessage: %v", err)
+		}
+		t.Cleanup(func() { _, _ = s.DeleteMessages(c, PurgeFilter{BotID: bots[0]}) })
+
+		for _, bot := range []string{bots[0], ""} {
+			got, err := s.GetMessage(c, bot, m.MessageID)
+			if err != nil {
+				t.Fatalf("GetMessage(%q): %v", bot, err)
+			}
+			if got.Content != m.Content || got.ChatID != dm || !got.Timestamp.Equal(m.Timestamp) || string(got.Raw) != string(m.Raw) {
+				t.Errorf("GetMessage(%q) = %+v, want %+v", bot, got, m)
+			}
+		}
+		if _, err := s.GetMessage(c, bots[1], m.MessageID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetMessage(other bot) err = %v, want ErrNotFound", err)
+		}
+		if _, err := s.GetMessage(c, "", "missing-"+bots[0]); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetMessage(missing) err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("duplicate keeps first", func(t *testing.T) {
+		s := newStore(t)
+		bot := testBotIDs(1)[0]
+		first := testMessage(bot, dm, "DUP-"+bot, "text", time.Minute)
+		second := first
+		second.Content = "overwritten"
+		t.Cleanup(func() { _, _ = s.DeleteMessages(c, PurgeFilter{BotID: bot}) })
+		for _, m := range []ChatMessage{first, second} {
+			if err := s.SaveMessage(c, &m); err != nil {
+				t.Fatalf("SaveMessage: %v", err)
+			}
+		}
+		got, err := s.GetMessage(c, bot, first.MessageID)
+		if err != nil {
+			t.Fatalf("GetMessage: %v", err)
+		}
+		if got.Content != first.Content {
+			t.Errorf("content = %q, want first write %q", got.Content, first.Content)
+		}
+	})
+
+	t.Run("legacy lookup", func(t *testing.T) {
+		s := newStore(t)
+		bot := testBotIDs(1)[0]
+		legacyChat := "legacy-" + bot + "@s.whatsapp.net"
+		legacy := testMessage("", legacyChat, "LEGACY-"+bot, "text", time.Hour)
+		owned := testMessage(bot, dm, "OWNED-"+bot, "text", time.Hour)
+		for _, m := range []ChatMessage{legacy, owned} {
+			if err := s.SaveMessage(c, &m); err != nil {
+				t.Fatalf("SaveMessage: %v", err)
+			}
+		}
+		t.Cleanup(func() {
+			_, _ = s.DeleteMessages(c, PurgeFilter{BotID: "", ChatIDs: []string{legacyChat}})
+			_, _ = s.DeleteMessages(c, PurgeFilter{BotID: bot})
+		})
+
+		if got, err := s.GetLegacyMessage(c, legacy.MessageID); err != nil || got.Content != legacy.Content {
+			t.Errorf("GetLegacyMessage(legacy) = %v, %v", got, err)
+		}
+		if _, err := s.GetLegacyMessage(c, owned.MessageID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetLegacyMessage(owned) err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list messages and chats", func(t *testing.T) {
+		s := newStore(t)
+		bots := testBotIDs(2)
+		seed := []ChatMessage{
+			testMessage(bots[0], dm, "L1-"+bots[0], "text", 3*time.Minute),
+			testMessage(bots[0], dm, "L2-"+bots[0], "text", 1*time.Minute),
+			testMessage(bots[0], group, "L3-"+bots[0], "image", 2*time.Minute),
+			testMessage(bots[1], dm, "L4-"+bots[1], "text", 0),
+		}
+		for _, m := range seed {
+			if err := s.SaveMessage(c, &m); err != nil {
+				t.Fatalf("SaveMessage: %v", err)
+			}
+		}
+		t.Cleanup(func() {
+			for _, bot := range bots {
+				_, _ = s.DeleteMessages(c, PurgeFilter{BotID: bot})
+			}
+		})
+
+		msgs, err := s.ListMessages(c, bots[0], []string{dm, group}, 0)
+		if err != nil {
+			t.Fatalf("ListMessages: %v", err)
+		}
+		if got, want := messageIDs(msgs), []string{"L2-" + bots[0], "L3-" + bots[0], "L1-" + bots[0]}; !slices.Equal(got, want) {
+			t.Errorf("ListMessages = %v, want %v (newest first)", got, want)
+		}
+		msgs, err = s.ListMessages(c, bots[0], []string{dm}, 1)
+		if err != nil {
+			t.Fatalf("ListMessages(limit): %v", err)
+		}
+		if got, want := messageIDs(msgs), []string{"L2-" + bots[0]}; !slices.Equal(got, want) {
+			t.Errorf("ListMessages(limit 1) = %v, want %v", got, want)
+		}
+
+		sums, err := s.ListChats(c, bots[0], 0)
+		if err != nil {
+			t.Fatalf("ListChats: %v", err)
+		}
+		if len(sums) != 2 || sums[0].ChatID != dm || sums[1].ChatID != group {
+			t.Fatalf("ListChats = %+v, want [%s %s]", sums, dm, group)
+		}
+		if !sums[0].LastTS.Equal(seed[1].Timestamp) || sums[0].Name != seed[1].SenderName {
+			t.Errorf("ListChats[0] = %+v, want last message %s", sums[0], seed[1].MessageID)
+		}
+
+		ids, err := s.BotIDs(c)
+		if err != nil {
+			t.Fatalf("BotIDs: %v", err)
+		}
+		for _, bot := range bots {
+			if !slices.Contains(ids, bot) {
+				t.Errorf("BotIDs = %v, missing %s", ids, bot)
+			}
+		}
+	})
+
+	t.Run("delete messages", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			filter func(bot string) PurgeFilter
+			want   []string // بچنے والے (bot[0] پر)
+		}{
+			{"whole bot", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot} }, nil},
+			{"older than", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot, Before: testEpoch.Add(-time.Hour)} },
+				[]string{"d-new", "g-new", "s-new"}},
+			{"kind dm", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot, Kind: ChatKindDM} },
+				[]string{"g-new", "g-old-img", "s-new", "s-old"}},
+			{"kind group", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot, Kind: ChatKindGroup} },
+				[]string{"d-new", "d-old", "d-untyped", "s-new", "s-old"}},
+			{"kind status", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot, Kind: ChatKindStatus} },
+				[]string{"d-new", "d-old", "d-untyped", "g-new", "g-old-img"}},
+			{"chat ids", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot, ChatIDs: []string{group, status}} },
+				[]string{"d-new", "d-old", "d-untyped"}},
+			// بغیر type والا میسج type فلٹر سے نہیں ہٹتا
+			{"types", func(bot string) PurgeFilter { return PurgeFilter{BotID: bot, Types: []string{"text"}} },
+				[]string{"d-untyped", "g-old-img"}},
+			{"combined", func(bot string) PurgeFilter {
+				return PurgeFilter{BotID: bot, Kind: ChatKindGroup, Types: []string{"image"}, Before: testEpoch.Add(-time.Hour)}
+			}, []string{"d-new", "d-old", "d-untyped", "g-new", "s-new", "s-old"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				s := newStore(t)
+				bots := testBotIDs(2)
+				seed := []ChatMessage{
+					testMessage(bots[0], dm, "d-new", "text", time.Minute),
+					testMessage(bots[0], dm, "d-old", "text", 48*time.Hour),
+					testMessage(bots[0], dm, "d-untyped", "", 48*time.Hour),
+					testMessage(bots[0], group, "g-new", "text", time.Minute),
+					testMessage(bots[0], group, "g-old-img", "image", 48*time.Hour),
+					testMessage(bots[0], status, "s-new", "text", time.Minute),
+					testMessage(bots[0], status, "s-old", "text", 48*time.Hour),
+					testMessage(bots[1], dm, "other-bot", "text", 48*time.Hour),
+				}
+				for _, m := range seed {
+					if err := s.SaveMessage(c, &m); err != nil {
+						t.Fatalf("SaveMessage: %v", err)
+					}
+				}
+				t.Cleanup(func() {
+					for _, bot := range bots {
+						_, _ = s.DeleteMessages(c, PurgeFilter{BotID: bot})
+					}
+				})
+
+				n, err := s.DeleteMessages(c, tc.filter(bots[0]))
+				if err != nil {
+					t.Fatalf("DeleteMessages: %v", err)
+				}
+				got := remainingIDs(t, s, bots[:1], chats)
+				if !slices.Equal(got, tc.want) {
+					t.Errorf("remaining = %v, want %v", got, tc.want)
+				}
+				if want := int64(7 - len(tc.want)); n != want {
+					t.Errorf("deleted = %d, want %d", n, want)
+				}
+				if other := remainingIDs(t, s, bots[1:], chats); !slices.Equal(other, []string{"other-bot"}) {
+					t.Errorf("other bot touched: %v", other)
+				}
+			})
+		}
+	})
+}
+
+// testMediaStoreContract: MediaStore کی ہر implementation کو یہی کرنا چاہیے
+func testMediaStoreContract(t *testing.T, newStore func(t *testing.T) MediaStore) {
+	c := context.Background()
+
+	t.Run("upsert and get", func(t *testing.T) {
+		s := newStore(t)
+		bots := testBotIDs(2)
+		id := "MEDIA-" + bots[0]
+		t.Cleanup(func() { _, _ = s.DeleteMedia(c, PurgeFilter{BotID: bots[0]}) })
+
+		doc := MediaDoc{BotID: bots[0], ChatID: "222@s.whatsapp.net", MessageID: id, Type: "image", Mime: "image/jpeg", Content: "data:first", Size: 5, CreatedAt: testEpoch}
+		if err := s.SaveMedia(c, &doc); err != nil {
+			t.Fatalf("SaveMedia: %v", err)
+		}
+		doc.Content = "data:second"
+		if err := s.SaveMedia(c, &doc); err != nil {
+			t.Fatalf("SaveMedia(again): %v", err)
+		}
+		for _, bot := range []string{bots[0], ""} {
+			got, err := s.GetMedia(c, bot, id)
+			if err != nil {
+				t.Fatalf("GetMedia(%q): %v", bot, err)
+			}
+			if got.Content != "data:second" || got.Mime != doc.Mime || !got.CreatedAt.Equal(doc.CreatedAt) {
+				t.Errorf("GetMedia(%q) = %+v, want upserted %+v", bot, got, doc)
+			}
+		}
+		if _, err := s.GetMedia(c, bots[1], id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetMedia(other bot) err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("delete ignores types", func(t *testing.T) {
+		s := newStore(t)
+		bot := testBotIDs(1)[0]
+		t.Cleanup(func() { _, _ = s.DeleteMedia(c, PurgeFilter{BotID: bot}) })
+		for i, chat := range []string{"222@s.whatsapp.net", "333-444@g.us"} {
+			doc := MediaDoc{BotID: bot, ChatID: chat, MessageID: fmt.Sprintf("M%d-%s", i, bot), Type: "video", Content: "x", CreatedAt: testEpoch.Add(-48 * time.Hour)}
+			if err := s.SaveMedia(c, &doc); err != nil {
+				t.Fatalf("SaveMedia: %v", err)
+			}
+		}
+		n, err := s.DeleteMedia(c, PurgeFilter{BotID: bot, Kind: ChatKindGroup, Types: []string{"image"}, Before: testEpoch})
+		if err != nil {
+			t.Fatalf("DeleteMedia: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("deleted = %d, want 1 (group only, Types ignored)", n)
+		}
+		if _, err := s.GetMedia(c, bot, "M0-"+bot); err != nil {
+			t.Errorf("DM media deleted: %v", err)
+		}
+	})
+}
+
+// testSettingsStoreContract / testStateStoreContract: سیٹنگز اور عارضی حالت
+func testSettingsStoreContract(t *testing.T, s SettingsStore) {
+	c := context.Background()
+	prefix := "test_" + testBotIDs(1)[0] + ":"
+	t.Cleanup(func() {
+		keys, _ := s.Keys(c, prefix)
+		_ = s.Delete(c, keys...)
+	})
+
+	if _, err := s.Get(c, prefix+"missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) err = %v, want ErrNotFound", err)
+	}
+	for k, v := range map[string]string{"b": "2", "a": "1", "a_b": "3"} {
+		if err := s.Set(c, prefix+k, v); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := s.Set(c, prefix+"a", "updated"); err != nil {
+		t.Fatalf("Set(overwrite): %v", err)
+	}
+	if v, err := s.Get(c, prefix+"a"); err != nil || v != "updated" {
+		t.Errorf("Get(a) = %q, %v, want updated", v, err)
+	}
+	// "_" اور "%" LIKE میں خاص ہیں — prefix لفظی ہونا چاہیے
+	keys, err := s.Keys(c, prefix+"a_")
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+	if want := []string{prefix + "a_b"}; !slices.Equal(keys, want) {
+		t.Errorf("Keys(a_) = %v, want %v", keys, want)
+	}
+	if err := s.Delete(c, prefix+"a", prefix+"b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	keys, _ = s.Keys(c, prefix)
+	if want := []string{prefix + "a_b"}; !slices.Equal(keys, want) {
+		t.Errorf("Keys after delete = %v, want %v", keys, want)
+	}
+}
+
+func testStateStoreContract(t *testing.T, s SessionStateStore) {
+	c := context.Background()
+	prefix := "test_" + testBotIDs(1)[0] + ":"
+	t.Cleanup(func() {
+		rows, _ := s.Scan(c, prefix)
+		for k := range rows {
+			_ = s.Delete(c, k)
+		}
+	})
+
+	if err := s.Put(c, prefix+"keep", []byte("v1"), 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(c, prefix+"ttl", []byte("v2"), time.Hour); err != nil {
+		t.Fatalf("Put(ttl): %v", err)
+	}
+	if err := s.Put(c, prefix+"gone", []byte("v3"), time.Millisecond); err != nil {
+		t.Fatalf("Put(short ttl): %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if v, err := s.Get(c, prefix+"keep"); err != nil || string(v) != "v1" {
+		t.Errorf("Get(keep) = %q, %v", v, err)
+	}
+	if _, err := s.Get(c, prefix+"gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(expired) err = %v, want ErrNotFound", err)
+	}
+	rows, err := s.Scan(c, prefix)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(rows) != 2 || string(rows[prefix+"ttl"]) != "v2" {
+		t.Errorf("Scan = %v, want keep + ttl", rows)
+	}
+	if err := s.Delete(c, prefix+"keep"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(c, prefix+"keep"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(deleted) err = %v, want ErrNotFound", err)
+	}
+}
+
+// =========================================================
+// 🧠 MEMORY
+// =========================================================
+
+func TestMemoryMessageStore(t *testing.T) {
+	testMessageStoreContract(t, func(*testing.T) MessageStore { return newMemoryMessageStore() })
+}
+
+func TestMemoryMediaStore(t *testing.T) {
+	testMediaStoreContract(t, func(*testing.T) MediaStore { return newMemoryMediaStore() })
+}
+
+func TestMemorySettingsStore(t *testing.T) {
+	testSettingsStoreContract(t, newMemorySettingsStore())
+}
+
+func TestMemoryStateStore(t *testing.T) {
+	testStateStoreContract(t, newMemoryStateStore())
+}
+
+// =========================================================
+// 🧹 PURGE FILTER — memory / postgres / mongo ایک ہی قطاریں چنیں
+// =========================================================
+
+type purgeRow struct {
+	bot, chat, typ string
+	ts             time.Time
+}
+
+var purgeRows = func() []purgeRow {
+	var rows []purgeRow
+	for _, bot := range []string{"100", "200"} {
+		for _, chat := range []string{"222@s.whatsapp.net", "555@lid", "333-444@g.us", "status@broadcast", "120363@newsletter", ""} {
+			for _, typ := range []string{"text", "image", ""} {
+				for _, age := range []time.Duration{0, time.Hour, 48 * time.Hour} {
+					rows = append(rows, purgeRow{bot, chat, typ, testEpoch.Add(-age)})
+				}
+			}
+		}
+	}
+	return rows
+}()
+
+var purgeFilters = []PurgeFilter{
+	{BotID: "100"},
+	{BotID: "100", Kind: ChatKindDM},
+	{BotID: "100", Kind: ChatKindGroup},
+	{BotID: "100", Kind: ChatKindStatus},
+	{BotID: "100", ChatIDs: []string{"222@s.whatsapp.net", "status@broadcast"}},
+	{BotID: "100", Types: []string{"text"}},
+	{BotID: "100", Types: []string{"image", "video"}},
+	{BotID: "100", Before: testEpoch.Add(-time.Hour)}, // ٹھیک ایک گھنٹہ پرانا نہیں ہٹتا
+	{BotID: "200", Kind: ChatKindDM, Types: []string{"text"}, Before: testEpoch},
+	{BotID: "", ChatIDs: []string{"222@s.whatsapp.net"}},
+}
+
+// pgCondRe: pgPurgeWhere کی ہر شرط "column op value"
+var pgCondRe = regexp.MustCompile(`^(\w+) (=|<>|<|LIKE|NOT LIKE) (.+)$`)
+
+// evalPGWhere: pgPurgeWhere کی بنائی WHERE کو ایک قطار پر چلائیں (صرف وہی
+// شکلیں جو pgPurgeWhere بناتا ہے؛ نئی شکل ہو تو ٹیسٹ فیل)
+func evalPGWhere(t *testing.T, where string, args []any, row purgeRow, tsCol string) bool {
+	t.Helper()
+	arg := func(ref string) any {
+		n, err := strconv.Atoi(strings.TrimPrefix(ref, "$"))
+		if err != nil || n < 1 || n > len(args) {
+			t.Fatalf("bad placeholder %q in %q", ref, where)
+		}
+		return args[n-1]
+	}
+	for _, cond := range strings.Split(where, " AND ") {
+		m := pgCondRe.FindStringSubmatch(cond)
+		if m == nil {
+			t.Fatalf("unsupported condition %q", cond)
+		}
+		col, op, val := m[1], m[2], m[3]
+		var field string
+		switch col {
+		case "bot_id":
+			field = row.bot
+		case "chat_id":
+			field = row.chat
+		case "type":
+			field = row.typ
+		case tsCol:
+			before, ok := arg(val).(time.Time)
+			if op != "<" || !ok {
+				t.Fatalf("unsupported time condition %q", cond)
+			}
+			if !row.ts.Before(before) {
+				return false
+			}
+			continue
+		default:
+			t.Fatalf("unknown column in %q", cond)
+		}
+
+		var ok bool
+		switch {
+		case op == "=" && strings.HasPrefix(val, "ANY("):
+			list, isArr := arg(strings.TrimSuffix(strings.TrimPrefix(val, "ANY("), ")")).(*pq.StringArray)
+			if !isArr {
+				t.Fatalf("ANY() arg is not a string array in %q", cond)
+			}
+			ok = slices.Contains(*list, field)
+		case op == "=" || op == "<>":
+			want := strings.Trim(val, "'")
+			if strings.HasPrefix(val, "$") {
+				want = arg(val).(string)
+			}
+			ok = (field == want) == (op == "=")
+		case op == "LIKE" || op == "NOT LIKE":
+			pat := strings.Trim(val, "'")
+			if !strings.HasPrefix(pat, "%") || strings.ContainsAny(pat[1:], "%_") {
+				t.Fatalf("unsupported LIKE pattern in %q", cond)
+			}
+			ok = strings.HasSuffix(field, pat[1:]) == (op == "LIKE")
+		default:
+			t.Fatalf("unsupported operator in %q", cond)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// evalMongoFilter: mongoPurgeFilter کی بنائی bson کو ایک ڈاکیومنٹ پر چلائیں
+func evalMongoFilter(t *testing.T, filter bson.M, doc map[string]any) bool {
+	t.Helper()
+	for key, cond := range filter {
+		if key == "$and" {
+			for _, sub := range cond.(bson.A) {
+				if !evalMongoFilter(t, sub.(bson.M), doc) {
+					return false
+				}
+			}
+			continue
+		}
+		if !evalMongoValue(t, doc[key], cond) {
+			return false
+		}
+	}
+	return true
+}
+
+func evalMongoValue(t *testing.T, field, cond any) bool {
+	t.Helper()
+	switch c := cond.(type) {
+	case primitive.Regex:
+		s, _ := field.(string)
+		return regexp.MustCompile(c.Pattern).MatchString(s)
+	case bson.M:
+		for op, v := range c {
+			var ok bool
+			switch op {
+			case "$in":
+				rv := reflect.ValueOf(v)
+				for i := 0; i < rv.Len(); i++ {
+					if reflect.DeepEqual(rv.Index(i).Interface(), field) {
+						ok = true
+					}
+				}
+			case "$ne":
+				ok = !reflect.DeepEqual(field, v)
+			case "$not":
+				ok = !evalMongoValue(t, field, v)
+			case "$lt":
+				ts, _ := field.(time.Time)
+				ok = ts.Before(v.(time.Time))
+			default:
+				t.Fatalf("unsupported operator %s", op)
+			}
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(field, cond)
+}
+
+func TestPurgeFilterBackendsAgree(t *testing.T) {
+	for _, f := range purgeFilters {
+		for _, media := range []bool{false, true} {
+			name := fmt.Sprintf("%+v/media=%v", f, media)
+			tsCol, tsField := "ts", "timestamp"
+			if media {
+				tsCol, tsField = "created_at", "created_at"
+			}
+			where, args := pgPurgeWhere(f, tsCol, !media)
+			mf := mongoPurgeFilter(f, tsField, !media)
+
+			for _, row := range purgeRows {
+				var mem bool
+				if media {
+					// memoryMediaStore.DeleteMedia والا اصل راستہ
+					s := newMemoryMediaStore()
+					_ = s.SaveMedia(context.Background(), &MediaDoc{BotID: row.bot, ChatID: row.chat, MessageID: "m", Type: row.typ, CreatedAt: row.ts})
+					n, _ := s.DeleteMedia(context.Background(), f)
+					mem = n == 1
+				} else {
+					mem = f.match(row.bot, row.chat, row.typ, row.ts)
+				}
+				pg := evalPGWhere(t, where, args, row, tsCol)
+				mongo := evalMongoFilter(t, mf, map[string]any{"bot_id": row.bot, "chat_id": row.chat, "type": row.typ, tsField: row.ts})
+				if mem != pg || mem != mongo {
+					t.Errorf("%s: row %+v memory=%v postgres=%v mongo=%v", name, row, mem, pg, mongo)
+				}
+			}
+		}
+	}
+}
+
+func TestChatKind(t *testing.T) {
+	cases := map[string]string{
+		"222@s.whatsapp.net": ChatKindDM,
+		"555@lid":            ChatKindDM,
+		"333-444@g.us":       ChatKindGroup,
+		"status@broadcast":   ChatKindStatus,
+		"":                   ChatKindDM,
+	}
+	for chat, want := range cases {
+		if got := chatKind(chat); got != want {
+			t.Errorf("chatKind(%q) = %q, want %q", chat, got, want)
+		}
+	}
+}
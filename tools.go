@@ -55,40 +55,41 @@ func handleToSticker(client *whatsmeow.Client, v *events.Message) {
 
 	os.WriteFile(input, data, 0644)
 
-	// FFmpeg Logic
-	if isAnimated {
-		// ویڈیو کے لیے سیٹنگز:
-		// 1. fps=10: فریم کم کیے تاکہ سائز کم ہو
-		// 2. scale=512:512...crop: یہ ویڈیو کو بھی کراپ کرے گا (اگر نہیں چاہیے تو پرانا فلٹر لگا سکتے ہو)
-		// 3. -t 6: ویڈیو کو 6 سیکنڈ تک کاٹ دیا (لمبی ویڈیو ایرر دیتی ہے)
-		// 4. -q:v 40: کوالٹی تھوڑی کم کی تاکہ 500kb سے نیچے رہے
-		// 5. -lossless 0: یہ بہت ضروری ہے، ورنہ فائل بہت بڑی بنے گی
-		cmd := exec.Command("ffmpeg", "-y", "-i", input,
-			"-vcodec", "libwebp",
-			"-filter:v", "fps=10,scale=512:512:force_original_aspect_ratio=increase,crop=512:512",
-			"-loop", "0",
-			"-preset", "default",
-			"-an", "-vsync", "0",
-			"-q:v", "40", // Quality control specifically for WebP
-			"-t", "00:00:15", // Max duration 6 seconds
-			output)
-		
-		err = cmd.Run()
-	} else {
+	// FFmpeg Logic (کنورٹ جاب پول میں)
+	err = runMediaJob(client, v, JobConvert, "sticker", func(ctx context.Context) error {
+		if isAnimated {
+			// ویڈیو کے لیے سیٹنگز:
+			// 1. fps=10: فریم کم کیے تاکہ سائز کم ہو
+			// 2. scale=512:512...crop: یہ ویڈیو کو بھی کراپ کرے گا (اگر نہیں چاہیے تو پرانا فلٹر لگا سکتے ہو)
+			// 3. -t 6: ویڈیو کو 6 سیکنڈ تک کاٹ دیا (لمبی ویڈیو ایرر دیتی ہے)
+			// 4. -q:v 40: کوالٹی تھوڑی کم کی تاکہ 500kb سے نیچے رہے
+			// 5. -lossless 0: یہ بہت ضروری ہے، ورنہ فائل بہت بڑی بنے گی
+			cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-i", input,
+				"-vcodec", "libwebp",
+				"-filter:v", "fps=10,scale=512:512:force_original_aspect_ratio=increase,crop=512:512",
+				"-loop", "0",
+				"-preset", "default",
+				"-an", "-vsync", "0",
+				"-q:v", "40", // Quality control specifically for WebP
+				"-t", "00:00:15", // Max duration 6 seconds
+				output)
+			return cmd.Run()
+		}
 		// تصویر کے لیے: Center Crop Logic (Edge-to-Edge)
 		// force_original_aspect_ratio=increase: تصویر کو اتنا بڑا کرو کہ باکس بھر جائے
 		// crop=512:512: پھر درمیان سے 512x512 کاٹ لو
-		cmd := exec.Command("ffmpeg", "-y", "-i", input,
+		cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-i", input,
 			"-vcodec", "libwebp",
 			"-filter:v", "scale=512:512:force_original_aspect_ratio=increase,crop=512:512",
 			output)
-		
-		err = cmd.Run()
-	}
+		return cmd.Run()
+	})
 
 	if err != nil {
 		fmt.Println("FFmpeg error:", err)
+		replyMessage(client, v, jobErrorText(err, "❌ Sticker conversion failed."))
 		os.Remove(input)
+		os.Remove(output)
 		return
 	}
 
@@ -150,8 +151,15 @@ func handleToImg(client *whatsmeow.Client, v *events.Message) {
 	os.WriteFile(input, data, 0644)
 
 	// FFmpeg conversion (Transparency handle کرنے کے لیے)
-	exec.Command("ffmpeg", "-y", "-i", input, output).Run()
-	
+	err = runMediaJob(client, v, JobConvert, "toimg", func(ctx context.Context) error {
+		return exec.CommandContext(ctx, "ffmpeg", "-y", "-i", input, output).Run()
+	})
+	if err != nil {
+		replyMessage(client, v, jobErrorText(err, "❌ Image conversion failed."))
+		os.Remove(input); os.Remove(output)
+		return
+	}
+
 	finalData, _ := os.ReadFile(output)
 	up, err := client.Upload(context.Background(), finalData, whatsmeow.MediaImage)
 	if err != nil { return }
@@ -196,31 +204,34 @@ func handleToMedia(client *whatsmeow.Client, v *events.Message, isGif bool) {
 
 	// 🛠️ STEP 1: ImageMagick کے ذریعے WebP کو GIF میں تبدیل کریں (Animation بچانے کے لیے)
 	// -coalesce لیئرز کو مکس ہونے سے روکتا ہے
-	cmdConvert := exec.Command("convert", inputWebP, "-coalesce", tempGif)
-	if err := cmdConvert.Run(); err != nil {
-		fmt.Printf("🔥 ImageMagick Error: %v\n", err)
-		replyMessage(client, v, "❌ Failed to parse sticker animation.")
-		os.Remove(inputWebP)
-		return
-	}
+	var outLog []byte
+	err = runMediaJob(client, v, JobConvert, "tovideo", func(ctx context.Context) error {
+		cmdConvert := exec.CommandContext(ctx, "convert", inputWebP, "-coalesce", tempGif)
+		if err := cmdConvert.Run(); err != nil {
+			fmt.Printf("🔥 ImageMagick Error: %v\n", err)
+			return err
+		}
 
-	// 🛠️ STEP 2: اب GIF کو FFmpeg کے ذریعے MP4 بنائیں
-	cmd := exec.Command("ffmpeg", "-y",
-		"-i", tempGif,          // اب ان پٹ GIF ہے
-		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2,format=yuv420p", // Even dimensions
-		"-c:v", "libx264",
-		"-preset", "faster",
-		"-crf", "26",
-		"-movflags", "+faststart",
-		"-pix_fmt", "yuv420p",
-		"-t", "10",
-		outputMp4)
-	
-	outLog, err := cmd.CombinedOutput()
+		// 🛠️ STEP 2: اب GIF کو FFmpeg کے ذریعے MP4 بنائیں
+		cmd := exec.CommandContext(ctx, "ffmpeg", "-y",
+			"-i", tempGif, // اب ان پٹ GIF ہے
+			"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2,format=yuv420p", // Even dimensions
+			"-c:v", "libx264",
+			"-preset", "faster",
+			"-crf", "26",
+			"-movflags", "+faststart",
+			"-pix_fmt", "yuv420p",
+			"-t", "10",
+			outputMp4)
+
+		var runErr error
+		outLog, runErr = cmd.CombinedOutput()
+		return runErr
+	})
 	if err != nil {
 		fmt.Printf("🔥 Graphics Engine Error: %s\n", string(outLog))
-		replyMessage(client, v, "❌ Graphics Engine failed.")
-		os.Remove(inputWebP); os.Remove(tempGif)
+		replyMessage(client, v, jobErrorText(err, "❌ Graphics Engine failed."))
+		os.Remove(inputWebP); os.Remove(tempGif); os.Remove(outputMp4)
 		return
 	}
 
@@ -369,6 +380,10 @@ func handleVV(client *whatsmeow.Client, v *events.Message) {
 	}
 
 	quoted := cInfo.GetQuotedMessage()
+	// 📦 پہلے ہسٹری اسٹور سے اصل میسج (quoted کاپی میں view-once میڈیا اکثر نہیں ہوتا)
+	if _, stored := findStoredMessage(client.Store.ID.User, cInfo.GetStanzaID()); stored != nil {
+		quoted = stored
+	}
 	if quoted == nil {
 		fmt.Println("❌ [VV] Quoted message is nil")
 		return
@@ -528,7 +543,7 @@ func uploadToCatbox(d []byte) string {
 	p.Write(d)
 	w.WriteField("reqtype", "fileupload")
 	w.Close()
-	r, _ := http.Post("https://catbox.moe/user/api.php", w.FormDataContentType(), b)
+	r, _ := http.Post(Config().APIs.Catbox, w.FormDataContentType(), b)
 	res, _ := ioutil.ReadAll(r.Body)
 	return string(res)
 }
\ No newline at end of file
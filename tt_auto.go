@@ -37,7 +37,6 @@ type AutoStatusConfig struct {
 }
 
 // 💾 Global Maps (In-Memory Database)
-var ttSearchCache = make(map[string]TTSearchSession)   // MessageID -> Results
 var autoStatusMap = make(map[string]*AutoStatusConfig) // UserID -> Config
 
 // 🔍 1. TIKTOK SEARCH (.tts query)
@@ -106,34 +105,25 @@ func handleTTSearch(client *whatsmeow.Client, v *events.Message, query string) {
 	})
 
 	if err == nil {
-		ttSearchCache[resp.ID] = TTSearchSession{
+		StartConversation(client, v, FlowTTSearch, resp.ID, 5*time.Minute, TTSearchSession{
 			Results:  results,
 			SenderID: v.Info.Sender.User,
-		}
-		
-		go func() {
-			time.Sleep(5 * time.Minute)
-			delete(ttSearchCache, resp.ID)
-		}()
+		})
 	}
 }
 
-// 📥 2. TIKTOK SEARCH REPLY HANDLER
-func handleTTSearchReply(client *whatsmeow.Client, v *events.Message, choice string, quotedID string) {
-	session, exists := ttSearchCache[quotedID]
-	if !exists {
-		return
-	}
-
-	// Sender Check
-	if v.Info.Sender.User != session.SenderID {
-		return
+// 📥 2. TIKTOK SEARCH REPLY HANDLER (FlowTTSearch — سینڈر اور مینیو کی جانچ سیشن مینیجر کرتا ہے)
+func handleTTSearchReply(client *whatsmeow.Client, v *events.Message, conv *Conversation, choice string) bool {
+	var session TTSearchSession
+	if !conv.Decode(&session) {
+		EndConversation(conv)
+		return false
 	}
 
 	index, err := strconv.Atoi(strings.TrimSpace(choice))
 	if err != nil || index < 1 || index > len(session.Results) {
 		replyMessage(client, v, "❌ Invalid Number.")
-		return
+		return true
 	}
 
 	selectedVideo := session.Results[index-1]
@@ -146,7 +136,8 @@ func handleTTSearchReply(client *whatsmeow.Client, v *events.Message, choice str
 	go downloadAndSend(client, v, selectedVideo.Url, "video")
 
 	// مینیو ڈیلیٹ کر دیں (صفائی)
-	delete(ttSearchCache, quotedID)
+	EndConversation(conv)
+	return true
 }
 
 // ⚙️ 3. AUTO STATUS SETUP (.ttauto / .ttautoset)
@@ -217,13 +208,25 @@ func runSingleAutoStatusCheck(client *whatsmeow.Client, userID string) {
 		return
 	}
 
+	botID := "unknown"
+	if client.Store != nil && client.Store.ID != nil {
+		botID = getCleanID(client.Store.ID.User)
+	}
+
+	// 🏭 پورا بیچ ایک جاب ہے تاکہ ایک وقت میں محدود yt-dlp چلیں
+	RunJob(&Job{Type: JobAutoStatus, Label: "#" + config.Tags, BotID: botID, SenderID: userID}, func(ctx context.Context) error {
+		return runAutoStatusBatch(ctx, client, userID, config)
+	})
+}
+
+func runAutoStatusBatch(ctx context.Context, client *whatsmeow.Client, userID string, config *AutoStatusConfig) error {
 	fmt.Printf("🤖 [AUTO-STATUS] Running for %s | Tag: %s\n", userID, config.Tags)
 
 	// 1. Python سے ویڈیوز کی لسٹ منگوائیں
-	cmd := exec.Command("python3", "tiktok_nav.py", "#"+config.Tags)
+	cmd := exec.CommandContext(ctx, "python3", "tiktok_nav.py", "#"+config.Tags)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return
+		return err
 	}
 
 	var results []TTSearchItem
@@ -231,7 +234,7 @@ func runSingleAutoStatusCheck(client *whatsmeow.Client, userID string) {
 
 	// اگر ویڈیوز نہیں ملیں تو واپسی
 	if len(results) == 0 {
-		return
+		return nil
 	}
 
 	// 2. لسٹ کو شفل (Mix) کریں
@@ -255,7 +258,7 @@ func runSingleAutoStatusCheck(client *whatsmeow.Client, userID string) {
 		filename := fmt.Sprintf("autostatus_%s_%d.mp4", userID, time.Now().UnixNano())
 
 		// A. ڈاؤن لوڈ کریں
-		dlCmd := exec.Command("yt-dlp", "-o", filename, video.Url)
+		dlCmd := exec.CommandContext(ctx, "yt-dlp", "-o", filename, video.Url)
 		if err := dlCmd.Run(); err != nil {
 			fmt.Println("❌ Skip: Download failed for", video.Title)
 			continue // اگر ایک فیل ہو تو اگلی پر جائیں
@@ -291,7 +294,12 @@ func runSingleAutoStatusCheck(client *whatsmeow.Client, userID string) {
 
 		// ⚠️ تھوڑا انتظار (15 سیکنڈ)
 		if i < limit-1 {
-			time.Sleep(15 * time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(15 * time.Second):
+			}
 		}
 	}
+	return nil
 }
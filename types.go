@@ -23,6 +23,14 @@ type GroupSettings struct {
 	AntiSticker    bool           `bson:"antisticker" json:"antisticker"`
 	Warnings       map[string]int `bson:"warnings" json:"warnings"`
 	Welcome        bool   `json:"welcome"`
+	Perms          []PermRule     `bson:"perms" json:"perms,omitempty"` // 🔐 .perm ACL رولز
+}
+
+// 🔐 PermRule: گروپ میں کسی کمانڈ/کیٹیگری کی اجازت یا پابندی
+type PermRule struct {
+	Action  string `bson:"action" json:"action"`   // "allow" | "deny"
+	Target  string `bson:"target" json:"target"`   // کمانڈ کا نام یا "cat:<category>"
+	Subject string `bson:"subject" json:"subject"` // "all" | "members" | "admins" | نمبر/LID
 }
 // ✅ نام کو TikTokState سے بدل کر TTState کر دیا گیا ہے
 type TTState struct {
@@ -76,7 +84,6 @@ type SetupState struct {
 // --- 🌍 GLOBAL VARIABLES ---
 var (
 	startTime  = time.Now()
-	data       BotData
+	data       BotData // پرانی مشترکہ سیٹنگز — اب صرف per-bot ڈیفالٹ (bot_settings.go)
 	dataMutex  sync.RWMutex
-	setupMap   = make(map[string]*SetupState)
 )
\ No newline at end of file
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// =========================================================
+// 📡 WEBSOCKET EVENT HUB
+// ہر کلائنٹ کی اپنی send queue اور writer goroutine (gorilla conn پر
+// ایک وقت میں ایک ہی writer)۔ کلائنٹ مخصوص بوٹس کو subscribe کر سکتا ہے:
+//   {"action":"subscribe","bots":["923001234567"]}   (خالی لسٹ = سب)
+//   {"action":"unsubscribe","bots":["923001234567"]}
+// بوٹ تک محدود API کلید صرف اپنے بوٹ کے ایونٹس دیکھتی ہے۔
+// =========================================================
+
+const (
+	EvtStatus       = "status" // کنیکٹ ہوتے ہی اسنیپ شاٹ
+	EvtConnected    = "connected"
+	EvtDisconnected = "disconnected"
+	EvtLoggedOut    = "logged-out"
+	EvtPairingCode  = "pairing-code-issued"
+	EvtReconnecting = "reconnecting"
+	EvtNewMessages  = "new-messages"
+	EvtSessionGone  = "session-deleted"
+	EvtOwnerAlert   = "owner-alert"    // لاگ آؤٹ / بین / بار بار ناکام ری کنیکٹ
+	EvtMessageSent  = "message-sent"   // قطار والا API میسج بھیج دیا گیا
+	EvtMessageFail  = "message-failed" // قطار والا API میسج نہیں جا سکا
+
+	wsSendBuffer    = 64
+	wsWriteTimeout  = 10 * time.Second
+	wsPongTimeout   = 60 * time.Second
+	wsPingInterval  = 25 * time.Second
+	wsMsgFlushEvery = 3 * time.Second // نئے میسجز کی گنتی اتنی دیر بعد ایک ساتھ
+)
+
+// WSEvent: کلائنٹ کو جانے والا ہر پیغام
+type WSEvent struct {
+	Event string         `json:"event"`
+	BotID string         `json:"bot_id,omitempty"`
+	Time  int64          `json:"ts"`
+	Data  map[string]any `json:"data,omitempty"`
+
+	Scope string `json:"-"` // خالی = read-history؛ مثلاً پیئرنگ کوڈ صرف manage-sessions کو
+}
+
+type wsClient struct {
+	conn *websocket.Conn
+	key  *APIKey
+	send chan []byte
+
+	mu   sync.RWMutex
+	bots map[string]bool // خالی = تمام (جن کی کلید اجازت دے)
+}
+
+// wants: کیا یہ ایونٹ اس کلائنٹ کو جانا چاہیے
+func (c *wsClient) wants(botID, scope string) bool {
+	if scope != "" && !c.key.Allows(scope) {
+		return false
+	}
+	if botID == "" {
+		return len(c.key.Bots) == 0 // بغیر بوٹ والے ایونٹس صرف آپریٹرز کے لیے
+	}
+	if !c.key.CanAccessBot(botID) {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.bots) == 0 || c.bots[botID]
+}
+
+func (c *wsClient) subscribe(bots []string, on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if on && len(bots) == 0 {
+		c.bots = make(map[string]bool)
+		return
+	}
+	for _, b := range bots {
+		b = getCleanID(b)
+		if on {
+			c.bots[b] = true
+		} else {
+			delete(c.bots, b)
+		}
+	}
+}
+
+type wsHubT struct {
+	mu      sync.RWMutex
+	clients map[*wsClient]struct{}
+
+	countMu sync.Mutex
+	counts  map[string]map[string]int // bot → chat → نئے میسج
+}
+
+var wsHub = &wsHubT{
+	clients: make(map[*wsClient]struct{}),
+	counts:  make(map[string]map[string]int),
+}
+
+func (h *wsHubT) add(c *wsClient) {
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *wsHubT) remove(c *wsClient) {
+	h.mu.Lock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+	h.mu.Unlock()
+}
+
+// Publish: ایونٹ تمام دلچسپی رکھنے والے کلائنٹس کو (سست کلائنٹ ڈراپ)
+func (h *wsHubT) Publish(evt WSEvent) {
+	if evt.Time == 0 {
+		evt.Time = time.Now().Unix()
+	}
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+
+	var slow []*wsClient
+	h.mu.RLock()
+	for c := range h.clients {
+		if !c.wants(evt.BotID, evt.Scope) {
+			continue
+		}
+		select {
+		case c.send <- raw:
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		logger.Warn("📡 ws client too slow, dropping", "remote", c.conn.RemoteAddr().String())
+		h.remove(c)
+	}
+}
+
+// sendTo: ایک کلائنٹ کو (اگر ابھی ہب میں ہے — بند چینل پر نہ لکھیں)
+func (h *wsHubT) sendTo(c *wsClient, evt WSEvent) {
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- raw:
+	default:
+	}
+}
+
+// emitBotEvent: کسی بوٹ کا لائف سائیکل ایونٹ
+func emitBotEvent(botID, event string, data map[string]any) {
+	wsHub.Publish(WSEvent{Event: event, BotID: getCleanID(botID), Data: data})
+}
+
+// countNewMessage: ہر میسج پر ایونٹ کے بجائے گنتی، wsMsgFlushEvery پر ایک ایونٹ
+func (h *wsHubT) countNewMessage(botID, chatID string) {
+	h.countMu.Lock()
+	m, ok := h.counts[botID]
+	if !ok {
+		m = make(map[string]int)
+		h.counts[botID] = m
+	}
+	m[chatID]++
+	h.countMu.Unlock()
+}
+
+func (h *wsHubT) flushCounts() {
+	h.countMu.Lock()
+	pending := h.counts
+	h.counts = make(map[string]map[string]int)
+	h.countMu.Unlock()
+
+	for botID, chats := range pending {
+		total := 0
+		for _, n := range chats {
+			total += n
+		}
+		emitBotEvent(botID, EvtNewMessages, map[string]any{"count": total, "chats": chats})
+	}
+}
+
+// StartWSHub: میسج کاؤنٹر فلشر
+func StartWSHub() {
+	go func() {
+		ticker := time.NewTicker(wsMsgFlushEvery)
+		defer ticker.Stop()
+		for range ticker.C {
+			wsHub.flushCounts()
+		}
+	}()
+}
+
+// botStatusSnapshot: کنیکٹ ہوتے ہی ہر (اجازت یافتہ) بوٹ کی حالت
+func botStatusSnapshot(c *wsClient) []WSEvent {
+	clientsMutex.RLock()
+	defer clientsMutex.RUnlock()
+
+	var out []WSEvent
+	now := time.Now().Unix()
+	for id, bc := range activeClients {
+		if !c.wants(id, "") {
+			continue
+		}
+		out = append(out, WSEvent{Event: EvtStatus, BotID: id, Time: now, Data: map[string]any{
+			"connected": bc != nil && bc.IsConnected(),
+			"logged_in": bc != nil && bc.IsLoggedIn(),
+		}})
+	}
+	return out
+}
+
+// 🌐 GET /ws (read-history یا manage-sessions کلید)
+func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	key := requestAPIKey(r)
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warn("📡 ws upgrade failed", "err", err)
+		return
+	}
+
+	c := &wsClient{conn: conn, key: key, send: make(chan []byte, wsSendBuffer), bots: make(map[string]bool)}
+	if q := r.URL.Query().Get("bots"); q != "" {
+		c.subscribe(splitList(q), true)
+	}
+	wsHub.add(c)
+	logger.Info("📡 ws client connected", "remote", r.RemoteAddr, "key", key.ID)
+
+	go c.writeLoop()
+
+	for _, evt := range botStatusSnapshot(c) {
+		wsHub.sendTo(c, evt)
+	}
+
+	c.readLoop()
+	wsHub.remove(c)
+	logger.Info("📡 ws client gone", "remote", r.RemoteAddr, "key", key.ID)
+}
+
+// readLoop: subscribe/unsubscribe کمانڈز + pong
+func (c *wsClient) readLoop() {
+	c.conn.SetReadLimit(4096)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		var req struct {
+			Action string   `json:"action"`
+			Bots   []string `json:"bots"`
+		}
+		if err := c.conn.ReadJSON(&req); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+			return
+		}
+		switch strings.ToLower(req.Action) {
+		case "subscribe":
+			c.subscribe(req.Bots, true)
+		case "unsubscribe":
+			c.subscribe(req.Bots, false)
+		default:
+			continue
+		}
+		for _, evt := range botStatusSnapshot(c) {
+			wsHub.sendTo(c, evt)
+		}
+	}
+}
+
+// writeLoop: اس کنکشن کا واحد writer
+func (c *wsClient) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case raw, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, nil)
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, raw); err != nil {
+				return
+			}
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}